@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/cluster/kmeans"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+// runList prints the registered clusterers with their params, the quality estimation methods and the centroids
+// initers of the k-means clusterers.
+func runList(w io.Writer, cmd command, args []string) error {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: patterns %s [flags]\n\n%s\n\nflags:\n", cmd.name, cmd.description)
+		fs.PrintDefaults()
+	}
+
+	var entries int
+	fs.IntVar(&entries, "entries", 100, "number of the entries the bounds of the params depending on the data are given for")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if entries < 1 {
+		return fmt.Errorf("entries must be positive, got %d", entries)
+	}
+
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "clusterers (params for %d entries):\n", entries)
+	for _, c := range cluster.Clusterers() {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.Name, c.Description)
+
+		space, err := c.Params(ctx, entries)
+		if err != nil {
+			return err
+		}
+		for _, spec := range space {
+			fmt.Fprintf(tw, "  \t  -param %s\n", spec)
+		}
+	}
+
+	fmt.Fprintf(tw, "\nquality estimation methods:\n")
+	for _, q := range cluster.QualityEstimators() {
+		description := q.Description
+		if q.Comparing {
+			description += " (compares the clusterings)"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", q.Name, description)
+	}
+
+	fmt.Fprintf(tw, "\ncentroids initers of the k-means clusterers:\n")
+	for _, i := range kmeans.CentroidsIniters() {
+		fmt.Fprintf(tw, "  %s\t%s\n", i.Name, i.Description)
+	}
+
+	return tw.Flush()
+}
@@ -2,56 +2,236 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 
 	"github.com/boson-research/patterns/internal"
-	"github.com/boson-research/patterns/internal/alphabet"
 	"github.com/boson-research/patterns/internal/processor"
 	"github.com/boson-research/patterns/internal/telemetry"
 	"github.com/boson-research/patterns/internal/telemetry/logger"
 	"go.opentelemetry.io/otel"
 )
 
-const (
-	alphabetPath = "input/alphabet"
-	textPath     = "input/text"
-)
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, p *processor.Processor, opts *options) error
+	// print runs the command which reads neither the alphabet nor the texts instead of run, the args are the command
+	// line args after the command name.
+	print func(w io.Writer, cmd command, args []string) error
+}
+
+var commands = []command{
+	{
+		name:        "analyze",
+		description: "find the neighbourhoods entries in the text and print a summary",
+		run:         runAnalyze,
+	},
+	{
+		name:        "cluster",
+		description: "find the neighbourhoods entries in the text, clusterize and export them with the clusters",
+		run:         runCluster,
+	},
+	{
+		name:        "stability",
+		description: "find the neighbourhoods entries in the text, clusterize them, measure the stability of the clusters by resampling and export them with the clusters",
+		run:         runStability,
+	},
+	{
+		name:        "predict",
+		description: "find the neighbourhoods entries of a saved model in the text, assign them to the clusters of the model without refitting and export them with the clusters",
+		run:         runPredict,
+	},
+	{
+		name:        "segment",
+		description: "find the neighbourhoods entries in the text, split the text into the regions of their constant rate and export them with the segments",
+		run:         runSegment,
+	},
+	{
+		name:        "export",
+		description: "find the neighbourhoods entries in the text and export them",
+		run:         runExport,
+	},
+	{
+		name:        "list",
+		description: "list the registered clusterers with their params, quality estimation methods and centroids initers",
+		print:       runList,
+	},
+}
 
 func main() {
-	ctx := context.Background()
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := findCommand(os.Args[1])
+	if !ok {
+		if os.Args[1] != "-h" && os.Args[1] != "-help" && os.Args[1] != "help" {
+			fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", os.Args[1])
+		}
+		usage()
+		os.Exit(2)
+	}
+
+	if cmd.print != nil {
+		if err := cmd.print(os.Stdout, cmd, os.Args[2:]); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			os.Exit(2)
+		}
+		return
+	}
+
+	opts, err := parseOptions(cmd, os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if err := run(context.Background(), cmd, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, cmd command, opts *options) error {
+	version, err := internal.GetGitVersion()
+	if err != nil {
+		version = "unknown"
+	}
+
 	l, closer, err := telemetry.Init(ctx, telemetry.Config{
 		Name:               "patterns",
-		Version:            internal.MustGetGitVersion(),
-		JaegerOTLPEndpoint: "jaeger:4318",
+		Version:            version,
+		JaegerOTLPEndpoint: opts.jaegerEndpoint,
 	})
 	if err != nil {
-		log.Fatalf("failed to initialize tracing: %s", err)
+		return fmt.Errorf("initialize telemetry: %w", err)
 	}
 	defer closer(ctx)
 
 	ctx = logger.InjectIntoContext(ctx, l)
 
-	ctx, span := otel.Tracer("").Start(ctx, "main")
+	ctx, span := otel.Tracer("").Start(ctx, cmd.name)
 	defer span.End()
 
 	logger.MustFromContext(ctx).Info("starting")
 
-	alphabetRaw, err := os.ReadFile(alphabetPath)
-	if err != nil {
-		log.Fatalf("failed to read alphabet: %s", err)
+	p := processor.New(ctx, opts.processorConfig)
+	if opts.modelPath != "" {
+		m, err := readModel(opts.modelPath)
+		if err != nil {
+			return err
+		}
+
+		if err := p.LoadModel(ctx, m); err != nil {
+			return err
+		}
+
+		logger.MustFromContext(ctx).Info("model loaded")
+	} else {
+		a, err := readAlphabet(opts.alphabetPath, opts.encoding)
+		if err != nil {
+			return err
+		}
+
+		logger.MustFromContext(ctx).Info("alphabet loaded")
+
+		if err := p.AnalyzeAlphabet(ctx, a); err != nil {
+			return err
+		}
+	}
+
+	if err := forEachText(opts.textPaths, func(text []byte) {
+		p.AnalyzeText(ctx, text)
+	}); err != nil {
+		return err
 	}
 
-	logger.MustFromContext(ctx).Info("alphabet loaded")
+	logger.MustFromContext(ctx).Info("text analyzed")
 
-	text, err := os.ReadFile(textPath)
-	if err != nil {
-		log.Fatalf("failed to read text: %s", err)
+	return cmd.run(ctx, p, opts)
+}
+
+func runAnalyze(ctx context.Context, p *processor.Processor, opts *options) error {
+	for _, n := range p.Neighbourhoods() {
+		if len(n.TextEntries.Locations()) == 0 {
+			continue
+		}
+
+		fmt.Printf("%s\t%d\n", n.Center, len(n.TextEntries.Locations()))
 	}
 
-	logger.MustFromContext(ctx).Info("text loaded")
+	return nil
+}
+
+func runCluster(ctx context.Context, p *processor.Processor, opts *options) error {
+	if err := p.Clusterize(ctx); err != nil {
+		return err
+	}
+
+	if err := saveModel(ctx, p, opts.saveModelPath); err != nil {
+		return err
+	}
+
+	return p.Export(ctx)
+}
+
+func runPredict(ctx context.Context, p *processor.Processor, opts *options) error {
+	if err := p.Predict(ctx); err != nil {
+		return err
+	}
+
+	return p.Export(ctx)
+}
+
+func runStability(ctx context.Context, p *processor.Processor, opts *options) error {
+	if err := p.Clusterize(ctx); err != nil {
+		return err
+	}
+
+	if err := p.AnalyzeStability(ctx); err != nil {
+		return err
+	}
 
-	p := processor.New(ctx)
-	p.AnalyzeAlphabet(ctx, alphabet.Alphabet(alphabetRaw))
-	p.AnalyzeText(ctx, text)
+	if err := saveModel(ctx, p, opts.saveModelPath); err != nil {
+		return err
+	}
+
+	return p.Export(ctx)
+}
+
+func runSegment(ctx context.Context, p *processor.Processor, opts *options) error {
+	if err := p.Segment(ctx); err != nil {
+		return err
+	}
+
+	return p.Export(ctx)
+}
+
+func runExport(ctx context.Context, p *processor.Processor, opts *options) error {
+	return p.Export(ctx)
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: patterns <command> [flags]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s%s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nrun 'patterns <command> -h' for the command flags\n")
 }
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/boson-research/patterns/internal/alphabet"
+	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/export"
+	"github.com/boson-research/patterns/internal/model"
+	"github.com/boson-research/patterns/internal/neighbourhood"
+	"github.com/boson-research/patterns/internal/processor"
+	"github.com/boson-research/patterns/internal/segment"
+	"github.com/boson-research/patterns/internal/shape"
+)
+
+// stdinPath is the text path that stands for the standard input.
+const stdinPath = "-"
+
+type options struct {
+	alphabetPath    string
+	encoding        alphabet.Encoding
+	textPaths       []string
+	jaegerEndpoint  string
+	processorConfig processor.Config
+	// modelPath is the model the neighbourhoods are loaded from instead of the alphabet, saveModelPath is the file the
+	// model of the clusters is saved to.
+	modelPath     string
+	saveModelPath string
+}
+
+// stringsFlag is a flag that could be repeated to collect several values.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func parseOptions(cmd command, args []string) (*options, error) {
+	opts := &options{processorConfig: processor.DefaultConfig()}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: patterns %s [flags]\n\n%s\n\nflags:\n", cmd.name, cmd.description)
+		fs.PrintDefaults()
+	}
+
+	var textPaths stringsFlag
+	fs.Var(&textPaths, "text", "path to the text file, could be repeated; '-' or no value reads the standard input")
+	fs.StringVar(&opts.jaegerEndpoint, "jaeger", "", "jaeger OTLP endpoint, tracing is disabled if empty")
+
+	// predict takes the alphabet and the shape from the model
+	encoding, shapeSpec, shapePath := alphabet.UTF8.String(), shape.Default, ""
+	if cmd.name == "predict" {
+		fs.StringVar(&opts.modelPath, "model", "model.json", "path to the model saved by -save-model, the alphabet, the shape and the features are taken from it")
+	} else {
+		fs.StringVar(&opts.alphabetPath, "alphabet", "input/alphabet", "path to the alphabet file")
+		fs.StringVar(&encoding, "encoding", encoding, "encoding of the alphabet and the text: utf8, bytes or the symbol width in bytes")
+		fs.StringVar(&shapeSpec, "shape", shapeSpec, "neighbourhood shape spec, e.g. 'center=ABC; element=A?C,?BC; centers=abc'")
+		fs.StringVar(&shapePath, "shape-file", "", "path to the file with the neighbourhood shape spec, overrides -shape")
+	}
+
+	var clustererParams, patternWeights stringsFlag
+	var format, clusterer, quality, report, features, metric, search, segmentation, weighting, scoresPath, resampling string
+	switch cmd.name {
+	case "export", "cluster", "stability", "predict", "segment":
+		fs.StringVar(&opts.processorConfig.Export.Dir, "out", opts.processorConfig.Export.Dir, "output directory, a file per neighbourhood center is written")
+		fs.StringVar(&opts.processorConfig.Export.File, "out-file", "", "single output file for all the neighbourhoods, overrides -out")
+		fs.StringVar(&format, "format", opts.processorConfig.Export.Format.String(), "output format: csv, json, jsonl, columnar")
+	}
+
+	switch cmd.name {
+	case "cluster", "stability":
+		fs.StringVar(&clusterer, "clusterer", opts.processorConfig.Clusterer.String(), "clusterer: "+strings.Join(clustererNames(), ", "))
+		fs.IntVar(&opts.processorConfig.Concurrency, "concurrency", 0, "number of the clusterer params evaluated in parallel, GOMAXPROCS if not positive")
+		fs.StringVar(&quality, "quality", opts.processorConfig.QualityEstimationMethod.String(), "quality estimation method: "+strings.Join(qualityMethodNames(), ", "))
+		fs.IntVar(&opts.processorConfig.SilhouetteSample, "silhouette-sample", 0, "number of the random entries the silhouette of the multi-dimensional features is estimated on, all of them if not positive")
+		fs.StringVar(&report, "report", "", "comma separated quality estimation methods to score the clusterings with besides -quality, 'all' for all of them")
+		fs.StringVar(&features, "features", neighbourhood.LocationFeature.String(), "comma separated features of the entries to clusterize: "+strings.Join(featureNames(), ", "))
+		fs.IntVar(&opts.processorConfig.DensityRadius, "density-radius", opts.processorConfig.DensityRadius, "radius in symbols the density feature is counted within")
+		fs.BoolVar(&opts.processorConfig.Standardize, "standardize", false, "scale every feature to zero mean and unit variance")
+		fs.StringVar(&weighting, "weighting", opts.processorConfig.Weighting.String(), "policy the entries are weighted by in the clustering: "+strings.Join(weightingNames(), ", "))
+		fs.Var(&patternWeights, "pattern-weight", "weight of the entries of the pattern for -weighting pattern as pattern=weight, could be repeated")
+		fs.StringVar(&scoresPath, "scores-file", "", "path to the file of the scores of the locations for -weighting score, a location and its score per line")
+		fs.StringVar(&metric, "metric", "euclidean", "distance between the feature vectors: "+strings.Join(metricNames(), ", "))
+		fs.Int64Var(&opts.processorConfig.Seed, "seed", opts.processorConfig.Seed, "seed of the random numbers of the clusterers and the search, the results are reproducible with the same seed")
+		fs.Var(&clustererParams, "param", "clusterer param fixed as name=value, e.g. k=3, could be repeated")
+		fs.StringVar(&search, "search", opts.processorConfig.Search.String(), "strategy of the clusterer params search: "+strings.Join(searchNames(), ", "))
+		fs.IntVar(&opts.processorConfig.MaxEvaluations, "max-evaluations", 0, "maximal number of the clusterings evaluated per neighbourhood, unlimited if not positive")
+		fs.DurationVar(&opts.processorConfig.Timeout, "timeout", 0, "maximal time of the clusterer params search per neighbourhood, unlimited if not positive")
+		fs.BoolVar(&opts.processorConfig.Stream, "stream", false, "clusterize the locations by the streaming k-means into -param k clusters instead of -clusterer, stopping as the centroids converge")
+		fs.StringVar(&opts.saveModelPath, "save-model", "", "path to the file the model of the clusters is saved to, so that the entries of other texts could be assigned to them by predict")
+	}
+
+	if cmd.name == "stability" {
+		fs.StringVar(&resampling, "resampling", opts.processorConfig.Resampling.String(), "method of the resampling of the entries: "+strings.Join(resamplingNames(), ", "))
+		fs.IntVar(&opts.processorConfig.Resamples, "resamples", cluster.DefaultResamples, "number of the resamples the stability is measured on")
+		fs.Float64Var(&opts.processorConfig.SampleFraction, "sample-fraction", cluster.DefaultSampleFraction, "fraction of the entries drawn by -resampling subsample")
+	}
+
+	if cmd.name == "segment" {
+		fs.StringVar(&segmentation, "segmentation", opts.processorConfig.Segmentation.String(), "change point detection method: "+strings.Join(segmentationNames(), ", "))
+		fs.Float64Var(&opts.processorConfig.Penalty, "penalty", 0, "penalty of a change point in log-likelihood units, the bayesian information criterion if not positive")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	enc, err := alphabet.ParseEncoding(encoding)
+	if err != nil {
+		return nil, err
+	}
+	opts.encoding = enc
+
+	if shapePath != "" {
+		raw, err := os.ReadFile(shapePath)
+		if err != nil {
+			return nil, fmt.Errorf("read shape: %w", err)
+		}
+		shapeSpec = string(raw)
+	}
+
+	sh, err := shape.Parse(shapeSpec)
+	if err != nil {
+		return nil, fmt.Errorf("parse shape: %w", err)
+	}
+	opts.processorConfig.Shape = sh
+
+	opts.textPaths = textPaths
+	if len(opts.textPaths) == 0 {
+		opts.textPaths = []string{stdinPath}
+	}
+
+	if format != "" {
+		f, err := export.ParseFormat(format)
+		if err != nil {
+			return nil, err
+		}
+		opts.processorConfig.Export.Format = f
+	}
+
+	if clusterer != "" {
+		t, err := cluster.ParseClustererType(clusterer)
+		if err != nil {
+			return nil, err
+		}
+		opts.processorConfig.Clusterer = t
+	}
+
+	if quality != "" {
+		m, err := cluster.ParseQualityEstimationMethod(quality)
+		if err != nil {
+			return nil, err
+		}
+		opts.processorConfig.QualityEstimationMethod = m
+	}
+
+	if report != "" {
+		methods := cluster.QualityEstimationMethods()
+		if report != "all" {
+			methods = nil
+			for _, name := range strings.Split(report, ",") {
+				m, err := cluster.ParseQualityEstimationMethod(strings.TrimSpace(name))
+				if err != nil {
+					return nil, err
+				}
+				methods = append(methods, m)
+			}
+		}
+		opts.processorConfig.ReportedMethods = methods
+	}
+
+	if features != "" {
+		var parsed []neighbourhood.Feature
+		for _, name := range strings.Split(features, ",") {
+			f, err := neighbourhood.ParseFeature(strings.TrimSpace(name))
+			if err != nil {
+				return nil, err
+			}
+			parsed = append(parsed, f)
+		}
+		opts.processorConfig.Features = parsed
+	}
+
+	if weighting != "" {
+		w, err := neighbourhood.ParseWeighting(weighting)
+		if err != nil {
+			return nil, err
+		}
+		opts.processorConfig.Weighting = w
+	}
+
+	if len(patternWeights) > 0 {
+		opts.processorConfig.PatternWeights = make(map[string]float64, len(patternWeights))
+		for _, a := range patternWeights {
+			name, value, err := params.ParseAssignment(a)
+			if err != nil {
+				return nil, fmt.Errorf("parse pattern weight: %w", err)
+			}
+
+			w, err := strconv.ParseFloat(value, 64)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("weight of pattern %s must be a non-negative number, got %s", name, value)
+			}
+			opts.processorConfig.PatternWeights[name] = w
+		}
+	}
+
+	if scoresPath != "" {
+		f, err := os.Open(scoresPath)
+		if err != nil {
+			return nil, fmt.Errorf("open scores: %w", err)
+		}
+		defer f.Close()
+
+		scores, err := neighbourhood.ReadScores(f)
+		if err != nil {
+			return nil, fmt.Errorf("read scores %s: %w", scoresPath, err)
+		}
+		opts.processorConfig.Scores = scores
+	}
+
+	if metric != "" {
+		m, err := points.ParseMetric(metric)
+		if err != nil {
+			return nil, err
+		}
+		opts.processorConfig.Metric = m
+	}
+
+	if len(clustererParams) > 0 {
+		opts.processorConfig.Params = make(map[string]string, len(clustererParams))
+		for _, a := range clustererParams {
+			name, value, err := params.ParseAssignment(a)
+			if err != nil {
+				return nil, err
+			}
+			opts.processorConfig.Params[name] = value
+		}
+	}
+
+	if search != "" {
+		st, err := cluster.ParseSearchStrategy(search)
+		if err != nil {
+			return nil, err
+		}
+		opts.processorConfig.Search = st
+	}
+
+	if resampling != "" {
+		m, err := cluster.ParseResamplingMethod(resampling)
+		if err != nil {
+			return nil, err
+		}
+		opts.processorConfig.Resampling = m
+	}
+
+	if segmentation != "" {
+		m, err := segment.ParseMethod(segmentation)
+		if err != nil {
+			return nil, err
+		}
+		opts.processorConfig.Segmentation = m
+	}
+
+	return opts, nil
+}
+
+func clustererNames() []string {
+	names := make([]string, 0, len(cluster.Clusterers()))
+	for _, c := range cluster.Clusterers() {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+func qualityMethodNames() []string {
+	names := make([]string, 0, len(cluster.QualityEstimationMethods()))
+	for _, m := range cluster.QualityEstimationMethods() {
+		names = append(names, m.String())
+	}
+
+	return names
+}
+
+func searchNames() []string {
+	names := make([]string, 0, len(cluster.SearchStrategies))
+	for _, s := range cluster.SearchStrategies {
+		names = append(names, s.String())
+	}
+
+	return names
+}
+
+func featureNames() []string {
+	names := make([]string, 0, len(neighbourhood.Features))
+	for _, f := range neighbourhood.Features {
+		names = append(names, f.String())
+	}
+
+	return names
+}
+
+func weightingNames() []string {
+	names := make([]string, 0, len(neighbourhood.Weightings))
+	for _, w := range neighbourhood.Weightings {
+		names = append(names, w.String())
+	}
+
+	return names
+}
+
+func resamplingNames() []string {
+	names := make([]string, 0, len(cluster.ResamplingMethods))
+	for _, m := range cluster.ResamplingMethods {
+		names = append(names, m.String())
+	}
+
+	return names
+}
+
+func metricNames() []string {
+	names := make([]string, 0, len(points.Metrics))
+	for name := range points.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func segmentationNames() []string {
+	names := make([]string, 0, len(segment.Methods))
+	for _, m := range segment.Methods {
+		names = append(names, m.String())
+	}
+
+	return names
+}
+
+func readAlphabet(path string, encoding alphabet.Encoding) (*alphabet.Alphabet, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read alphabet: %w", err)
+	}
+
+	return alphabet.New(raw, encoding), nil
+}
+
+func readModel(path string) (*model.Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open model: %w", err)
+	}
+	defer f.Close()
+
+	m, err := model.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("read model %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// saveModel writes the model of the clusters of the processor to the path, nothing is written if the path is empty.
+func saveModel(ctx context.Context, p *processor.Processor, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create model: %w", err)
+	}
+
+	if err := p.SaveModel(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// forEachText reads the texts one by one and passes them to fn.
+func forEachText(paths []string, fn func(text []byte)) error {
+	stdinRead := false
+	for _, path := range paths {
+		var text []byte
+		var err error
+		if path == stdinPath {
+			if stdinRead {
+				return fmt.Errorf("standard input could be read only once")
+			}
+			stdinRead = true
+
+			text, err = io.ReadAll(os.Stdin)
+		} else {
+			text, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return fmt.Errorf("read text %s: %w", path, err)
+		}
+
+		fn(text)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package ahocorasick
+
+// Automaton is the Aho-Corasick automaton that finds all the occurrences of a set of patterns in a single pass over
+// the text. Symbols of the patterns and of the text are integers in the range [0, width).
+type Automaton struct {
+	width int
+	// next is the full transition table of the automaton, the transition of the state s by the symbol c is stored at
+	// s*width+c.
+	next []int32
+	// out holds the ids of the patterns ending in the state, including the ones reachable by the suffix links.
+	out [][]int
+	// lens holds the lengths of the patterns by their ids.
+	lens []int
+}
+
+// New builds the automaton for the patterns. The ids of the patterns are their indices in the slice. Patterns could
+// repeat, in that case every id is reported.
+func New(patterns [][]int, width int) *Automaton {
+	a := &Automaton{
+		width: width,
+		lens:  make([]int, len(patterns)),
+	}
+	a.addState()
+
+	// build the trie
+	for id, p := range patterns {
+		a.lens[id] = len(p)
+
+		s := 0
+		for _, c := range p {
+			if a.next[s*width+c] == 0 {
+				a.next[s*width+c] = int32(a.addState())
+			}
+			s = int(a.next[s*width+c])
+		}
+
+		a.out[s] = append(a.out[s], id)
+	}
+
+	// turn the trie into the automaton breadth first, so that the suffix links of the parents are ready before the
+	// children are processed
+	fail := make([]int32, len(a.out))
+	queue := make([]int32, 0, len(a.out))
+	for c := 0; c < width; c++ {
+		if child := a.next[c]; child != 0 {
+			queue = append(queue, child)
+		}
+	}
+
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+
+		a.out[s] = append(a.out[s], a.out[fail[s]]...)
+
+		for c := 0; c < width; c++ {
+			child := a.next[int(s)*width+c]
+			if child == 0 {
+				a.next[int(s)*width+c] = a.next[int(fail[s])*width+c]
+				continue
+			}
+
+			fail[child] = a.next[int(fail[s])*width+c]
+			queue = append(queue, child)
+		}
+	}
+
+	return a
+}
+
+// Step returns the state the automaton moves to from the state s by the symbol c. Negative symbols are treated as the
+// ones not met in any pattern and reset the automaton to the initial state.
+func (a *Automaton) Step(s int, c int) int {
+	if c < 0 || c >= a.width {
+		return 0
+	}
+
+	return int(a.next[s*a.width+c])
+}
+
+// Matches returns the ids of the patterns which end at the symbol the automaton moved to the state s by.
+func (a *Automaton) Matches(s int) []int {
+	return a.out[s]
+}
+
+// PatternLen returns the length of the pattern by its id.
+func (a *Automaton) PatternLen(id int) int {
+	return a.lens[id]
+}
+
+func (a *Automaton) addState() int {
+	a.next = append(a.next, make([]int32, a.width)...)
+	a.out = append(a.out, nil)
+	return len(a.out) - 1
+}
@@ -0,0 +1,135 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// occurrence is the pattern found in the text at the start.
+type occurrence struct {
+	start, id int
+}
+
+func TestAutomaton(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		text     string
+		want     []occurrence
+	}{
+		{
+			name:     "overlapping occurrences",
+			patterns: []string{"aa"},
+			text:     "aaaa",
+			want:     []occurrence{{0, 0}, {1, 0}, {2, 0}},
+		},
+		{
+			name:     "overlapping patterns",
+			patterns: []string{"abc", "bcd", "cd"},
+			text:     "abcdbcd",
+			want:     []occurrence{{0, 0}, {1, 1}, {2, 2}, {4, 1}, {5, 2}},
+		},
+		{
+			name:     "suffixes of other patterns",
+			patterns: []string{"c", "abc", "bc"},
+			text:     "abcbc",
+			want:     []occurrence{{0, 1}, {1, 2}, {2, 0}, {3, 2}, {4, 0}},
+		},
+		{
+			name:     "prefixes of other patterns",
+			patterns: []string{"ab", "a", "abcd"},
+			text:     "abcabcd",
+			want:     []occurrence{{0, 0}, {0, 1}, {3, 0}, {3, 1}, {3, 2}},
+		},
+		{
+			name:     "repeated patterns",
+			patterns: []string{"ba", "ba"},
+			text:     "aba",
+			want:     []occurrence{{1, 0}, {1, 1}},
+		},
+		{
+			name:     "symbols out of the alphabet",
+			patterns: []string{"ab"},
+			text:     "a?b ab",
+			want:     []occurrence{{4, 0}},
+		},
+		{
+			name:     "empty text",
+			patterns: []string{"a", "ab"},
+			text:     "",
+		},
+		{
+			name: "no patterns",
+			text: "abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns := make([][]int, len(tt.patterns))
+			for id, p := range tt.patterns {
+				patterns[id] = symbols(p)
+			}
+
+			got := find(New(patterns, 4), symbols(tt.text))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Automaton found %v, want %v", got, tt.want)
+			}
+			if naive := findNaive(patterns, symbols(tt.text)); !reflect.DeepEqual(got, naive) {
+				t.Errorf("Automaton found %v, naive search %v", got, naive)
+			}
+		})
+	}
+}
+
+// symbols returns the symbols of the text over the alphabet "abcd", the other characters are -1.
+func symbols(s string) []int {
+	syms := make([]int, len(s))
+	for i, c := range []byte(s) {
+		syms[i] = -1
+		if c >= 'a' && c <= 'd' {
+			syms[i] = int(c - 'a')
+		}
+	}
+
+	return syms
+}
+
+// find returns the occurrences of the patterns of the automaton in the text ordered by the start and the id.
+func find(a *Automaton, text []int) []occurrence {
+	var found []occurrence
+	s := 0
+	for i, c := range text {
+		s = a.Step(s, c)
+		for _, id := range a.Matches(s) {
+			found = append(found, occurrence{start: i + 1 - a.PatternLen(id), id: id})
+		}
+	}
+	sortOccurrences(found)
+
+	return found
+}
+
+// findNaive returns the occurrences of the patterns in the text found by comparing them at every position.
+func findNaive(patterns [][]int, text []int) []occurrence {
+	var found []occurrence
+	for id, p := range patterns {
+		for start := 0; start+len(p) <= len(text); start++ {
+			if reflect.DeepEqual(text[start:start+len(p)], p) {
+				found = append(found, occurrence{start: start, id: id})
+			}
+		}
+	}
+	sortOccurrences(found)
+
+	return found
+}
+
+func sortOccurrences(o []occurrence) {
+	sort.Slice(o, func(i, j int) bool {
+		if o[i].start != o[j].start {
+			return o[i].start < o[j].start
+		}
+		return o[i].id < o[j].id
+	})
+}
@@ -1,15 +1,66 @@
 package alphabet
 
-import "github.com/samber/lo"
+import (
+	"strings"
+)
 
-type Alphabet []byte
+// Alphabet is the ordered set of symbols.
+type Alphabet struct {
+	symbols  []Symbol
+	encoding Encoding
+	index    map[Symbol]int
+}
+
+// New splits the raw bytes into symbols with the encoding. Repeated symbols are taken into account once.
+func New(raw []byte, encoding Encoding) *Alphabet {
+	a := &Alphabet{
+		encoding: encoding,
+		index:    make(map[Symbol]int),
+	}
+
+	for _, s := range encoding.Split(raw) {
+		if _, ok := a.index[s]; ok {
+			continue
+		}
+
+		a.index[s] = len(a.symbols)
+		a.symbols = append(a.symbols, s)
+	}
+
+	return a
+}
+
+func (a *Alphabet) Symbols() []Symbol {
+	return a.symbols
+}
 
-func New(raw []byte, symbolSize int) []Symbol {
-	return lo.Map(lo.Chunk(raw, symbolSize), func(chunk []byte, _ int) Symbol {
-		return Symbol(chunk)
-	})
+func (a *Alphabet) Encoding() Encoding {
+	return a.encoding
 }
 
-func (a Alphabet) String() string {
-	return string(a)
+func (a *Alphabet) Len() int {
+	return len(a.symbols)
+}
+
+// Index returns the index of the symbol in the alphabet or -1 if the symbol is absent.
+func (a *Alphabet) Index(s Symbol) int {
+	if i, ok := a.index[s]; ok {
+		return i
+	}
+
+	return -1
+}
+
+// Split splits the raw bytes into symbols with the encoding of the alphabet.
+func (a *Alphabet) Split(raw []byte) []Symbol {
+	return a.encoding.Split(raw)
+}
+
+func (a *Alphabet) String() string {
+	b := strings.Builder{}
+	for _, s := range a.symbols {
+		b.WriteString(string(s))
+	}
+
+	return b.String()
 }
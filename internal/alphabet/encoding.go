@@ -0,0 +1,70 @@
+package alphabet
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Encoding defines how raw bytes are split into symbols. Positive values are the width of the symbol in bytes, UTF8
+// stands for utf-8 encoded runes.
+type Encoding int
+
+const (
+	UTF8  Encoding = 0
+	Bytes Encoding = 1
+)
+
+func (e Encoding) String() string {
+	switch {
+	case e == UTF8:
+		return "utf8"
+	case e == Bytes:
+		return "bytes"
+	case e > 0:
+		return strconv.Itoa(int(e))
+	}
+	return "unknown"
+}
+
+// ParseEncoding returns the encoding by its name: utf8, bytes or the width of the symbol in bytes.
+func ParseEncoding(s string) (Encoding, error) {
+	switch s {
+	case UTF8.String():
+		return UTF8, nil
+	case Bytes.String():
+		return Bytes, nil
+	}
+
+	width, err := strconv.Atoi(s)
+	if err != nil || width <= 0 {
+		return 0, fmt.Errorf("unknown encoding: %s", s)
+	}
+
+	return Encoding(width), nil
+}
+
+// Tokenize splits the raw bytes into symbols and calls fn for each of them with its byte offset. Invalid utf-8 bytes
+// and the incomplete trailing symbol of fixed width are passed as they are, so they could not match any valid symbol.
+func (e Encoding) Tokenize(raw []byte, fn func(s []byte, byteOffset int)) {
+	for i := 0; i < len(raw); {
+		width := int(e)
+		if e == UTF8 {
+			_, width = utf8.DecodeRune(raw[i:])
+		}
+		width = min(width, len(raw)-i)
+
+		fn(raw[i:i+width], i)
+		i += width
+	}
+}
+
+// Split splits the raw bytes into symbols.
+func (e Encoding) Split(raw []byte) []Symbol {
+	var symbols []Symbol
+	e.Tokenize(raw, func(s []byte, _ int) {
+		symbols = append(symbols, Symbol(s))
+	})
+
+	return symbols
+}
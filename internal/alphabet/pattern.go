@@ -1,17 +1,34 @@
 package alphabet
 
+import "strings"
+
 type Pattern struct {
-	value []byte
+	symbols []Symbol
 }
 
-func NewPattern(v []byte) *Pattern {
-	return &Pattern{value: v}
+func NewPattern(symbols []Symbol) *Pattern {
+	return &Pattern{symbols: symbols}
 }
 
 func (p *Pattern) String() string {
-	return string(p.value)
+	b := strings.Builder{}
+	for _, s := range p.symbols {
+		b.WriteString(string(s))
+	}
+
+	return b.String()
 }
 
+// Value returns the bytes of the pattern.
 func (p *Pattern) Value() []byte {
-	return p.value
-}
\ No newline at end of file
+	return []byte(p.String())
+}
+
+func (p *Pattern) Symbols() []Symbol {
+	return p.symbols
+}
+
+// Len returns the length of the pattern in symbols.
+func (p *Pattern) Len() int {
+	return len(p.symbols)
+}
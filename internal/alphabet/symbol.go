@@ -1,3 +1,9 @@
 package alphabet
 
-type Symbol []byte
\ No newline at end of file
+// Symbol is the unit of the alphabet and of the text. Depending on the encoding it is either an utf-8 encoded rune or
+// a fixed number of bytes.
+type Symbol string
+
+func (s Symbol) String() string {
+	return string(s)
+}
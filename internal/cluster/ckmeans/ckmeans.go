@@ -0,0 +1,195 @@
+package ckmeans
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// Ckmeans is the exact 1-D k-means clusterer in the manner of Ckmeans.1d.dp. The clusters of the optimal partition of
+// 1-D points are intervals of the sorted points, so the partition minimizing the within-cluster sum of squares is
+// found by dynamic programming over the sorted points, the result is deterministic and globally optimal. The table of
+// the dynamic programming for k clusters holds the optimal partitions into every smaller number of the clusters too,
+// so it is filled once up to the largest k asked for and every clustering only traces the partition back.
+type Ckmeans struct {
+	clustersNum int
+	params      params.Space
+	// sorted holds the data sorted by value and order holds the indices of the sorted points in the data.
+	sorted []float64
+	order  []int
+	// sums and sqSums are the prefix sums of the sorted points and their squares, the points are shifted by the median
+	// to keep the precision of the sums of squares.
+	sums   []float64
+	sqSums []float64
+	shift  float64
+	table  *Table
+}
+
+// Table is the table of the dynamic programming over the sorted points, it is filled row by row up to the largest
+// number of the clusters asked for and could be shared by the clusterers of the same data.
+type Table struct {
+	mu sync.Mutex
+	// first[q][i] is the index of the first point of the last cluster of the optimal split of the first i+1 points
+	// into q+1 clusters, the rows take 4·n bytes each.
+	first [][]int32
+	// cost[i] is the lowest sum of squares of the first i+1 points split into len(first) clusters.
+	cost []float64
+}
+
+func (c *Ckmeans) Init(ctx context.Context, set *points.Set) error {
+	if set.Dim() != 1 {
+		return fmt.Errorf("ckmeans clusters 1-D points only, got %d-D", set.Dim())
+	}
+	data := set.Flat()
+
+	c.order = make([]int, len(data))
+	for i := range c.order {
+		c.order[i] = i
+	}
+	sort.SliceStable(c.order, func(i, j int) bool { return data[c.order[i]] < data[c.order[j]] })
+
+	c.sorted = make([]float64, len(data))
+	for i, idx := range c.order {
+		c.sorted[i] = data[idx]
+	}
+
+	c.shift = 0
+	if len(data) > 0 {
+		c.shift = c.sorted[len(data)/2]
+	}
+
+	c.sums = make([]float64, len(data)+1)
+	c.sqSums = make([]float64, len(data)+1)
+	for i, p := range c.sorted {
+		c.sums[i+1] = c.sums[i] + (p - c.shift)
+		c.sqSums[i+1] = c.sqSums[i] + (p-c.shift)*(p-c.shift)
+	}
+
+	c.params = params.Space{
+		{Name: "k", Kind: params.Int, Min: 1, Max: float64(max(1, len(data)/2)), Default: 1, Tunable: true},
+	}
+	c.table = &Table{}
+
+	return c.SetParams(ctx, c.params.Defaults())
+}
+
+// Shared returns the table of the dynamic programming over the data the clusterer is initialized with, the *Table.
+func (c *Ckmeans) Shared() any {
+	return c.table
+}
+
+// Share makes the clusterer use the table of another ckmeans clusterer initialized with the same data instead of
+// filling its own one, the clusterers sharing it are safe to use concurrently.
+func (c *Ckmeans) Share(shared any) {
+	c.table = shared.(*Table)
+}
+
+func (c *Ckmeans) Params() params.Space {
+	return c.params
+}
+
+func (c *Ckmeans) SetParams(ctx context.Context, values params.Values) error {
+	if err := c.params.Validate(values); err != nil {
+		return err
+	}
+
+	c.clustersNum = int(values[0])
+
+	return nil
+}
+
+// Cluster returns the means and the labels of the optimal partition, the clusters are ordered by their means.
+func (c *Ckmeans) Cluster(ctx context.Context) (*points.Set, []int) {
+	ctx, span := otel.Tracer("").Start(ctx, "Ckmeans")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Tracef("clustering %d points into %d clusters", len(c.sorted), c.clustersNum)
+
+	n, k := len(c.sorted), min(c.clustersNum, len(c.sorted))
+	if n == 0 {
+		return points.New(1), nil
+	}
+
+	first := c.rows(k)
+
+	centroids := make([]float64, k)
+	sortedLabels := make([]int, n)
+	end := n - 1
+	for q := k - 1; q >= 0; q-- {
+		start := int(first[q][end])
+		for i := start; i <= end; i++ {
+			sortedLabels[i] = q
+		}
+		centroids[q] = c.shift + (c.sums[end+1]-c.sums[start])/float64(end-start+1)
+		end = start - 1
+	}
+
+	labels := make([]int, n)
+	for i, idx := range c.order {
+		labels[idx] = sortedLabels[i]
+	}
+
+	return points.FromValues(centroids), labels
+}
+
+// rows returns the first k rows of the first points of the last clusters of the table, filling the missing ones.
+func (c *Ckmeans) rows(k int) [][]int32 {
+	t := c.table
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := len(c.sorted)
+	if len(t.first) == 0 {
+		t.first = [][]int32{make([]int32, n)}
+		t.cost = make([]float64, n)
+		for i := range t.cost {
+			t.cost[i] = c.sse(0, i)
+		}
+	}
+
+	for q := len(t.first); q < k; q++ {
+		cost, first := make([]float64, n), make([]int32, n)
+		c.fillRow(t.cost, cost, first, q, n-1, q, n-1)
+		t.first, t.cost = append(t.first, first), cost
+	}
+
+	return t.first[:k]
+}
+
+// fillRow fills the costs of the points from lo to hi split into q+1 clusters knowing that the first point of the last
+// cluster is between from and to. The first point of the last cluster does not decrease with the number of the
+// points, so the row is filled by divide and conquer in O(n log n).
+func (c *Ckmeans) fillRow(prev, cost []float64, first []int32, lo, hi, from, to int) {
+	if lo > hi {
+		return
+	}
+
+	mid := lo + (hi-lo)/2
+	best, bestCost := -1, math.Inf(1)
+	// the first of the equally good splits is taken, so that the result is deterministic
+	for j := max(from, 1); j <= min(to, mid); j++ {
+		if v := prev[j-1] + c.sse(j, mid); v < bestCost {
+			best, bestCost = j, v
+		}
+	}
+
+	cost[mid], first[mid] = bestCost, int32(best)
+
+	c.fillRow(prev, cost, first, lo, mid-1, from, best)
+	c.fillRow(prev, cost, first, mid+1, hi, best, to)
+}
+
+// sse returns the sum of squared distances of the sorted points from i to j to their mean.
+func (c *Ckmeans) sse(i, j int) float64 {
+	sum := c.sums[j+1] - c.sums[i]
+	sqSum := c.sqSums[j+1] - c.sqSums[i]
+
+	return math.Max(sqSum-sum*sum/float64(j-i+1), 0)
+}
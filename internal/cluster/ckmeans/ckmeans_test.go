@@ -0,0 +1,186 @@
+package ckmeans
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+var ctx = logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+func TestCkmeans_Cluster(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []float64
+		clustersNum   int
+		wantCentroids []float64
+		wantLabels    []int
+	}{
+		{
+			name:          "single cluster",
+			data:          []float64{3, 1, 2},
+			clustersNum:   1,
+			wantCentroids: []float64{2},
+			wantLabels:    []int{0, 0, 0},
+		},
+		{
+			name:          "unsorted groups",
+			data:          []float64{100, 1, 51, 2, 99, 50, 3, 101, 49},
+			clustersNum:   3,
+			wantCentroids: []float64{2, 50, 100},
+			wantLabels:    []int{2, 0, 1, 0, 2, 1, 0, 2, 1},
+		},
+		{
+			name:          "outlier",
+			data:          []float64{0, 1, 2, 10, 11, 12, 13, 14, 1000},
+			clustersNum:   3,
+			wantCentroids: []float64{1, 12, 1000},
+			wantLabels:    []int{0, 0, 0, 1, 1, 1, 1, 1, 2},
+		},
+		{
+			name:          "equal points",
+			data:          []float64{5, 5, 7, 7},
+			clustersNum:   2,
+			wantCentroids: []float64{5, 7},
+			wantLabels:    []int{0, 0, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := new(Ckmeans)
+			if err := c.Init(ctx, points.FromValues(tt.data)); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if err := c.SetParams(ctx, params.Values{float64(tt.clustersNum)}); err != nil {
+				t.Fatalf("SetParams() error = %v", err)
+			}
+
+			centroids, labels := c.Cluster(ctx)
+			if !reflect.DeepEqual(centroids.Flat(), tt.wantCentroids) {
+				t.Errorf("Cluster() centroids = %v, want %v", centroids, tt.wantCentroids)
+			}
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("Cluster() labels = %v, want %v", labels, tt.wantLabels)
+			}
+		})
+	}
+}
+
+func TestCkmeans_ClusterIsOptimal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for it := 0; it < 50; it++ {
+		data := make([]float64, 4+rnd.Intn(8))
+		for i := range data {
+			data[i] = float64(rnd.Intn(100))
+		}
+
+		c := new(Ckmeans)
+		if err := c.Init(ctx, points.FromValues(data)); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		for k := 1; k <= len(data)/2; k++ {
+			if err := c.SetParams(ctx, params.Values{float64(k)}); err != nil {
+				t.Fatalf("SetParams() error = %v", err)
+			}
+
+			_, labels := c.Cluster(ctx)
+			if got, want := sse(data, labels), bruteForceSSE(c.sorted, k); math.Abs(got-want) > 1e-6 {
+				t.Errorf("Cluster(%v, %d) sum of squares = %v, want %v", data, k, got, want)
+			}
+		}
+	}
+}
+
+func TestCkmeans_Share(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]float64, 40)
+	for i := range data {
+		data[i] = float64(rnd.Intn(100))
+	}
+	set := points.FromValues(data)
+
+	// the clusterers sharing the table fill it concurrently in any order of k
+	shared := make([]*Ckmeans, len(data)/2)
+	labels := make([][]int, len(shared))
+	wg := sync.WaitGroup{}
+	for i := range shared {
+		shared[i] = new(Ckmeans)
+		if err := shared[i].Init(ctx, set); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		if i > 0 {
+			shared[i].Share(shared[0].Shared())
+		}
+		if err := shared[i].SetParams(ctx, params.Values{float64(len(shared) - i)}); err != nil {
+			t.Fatalf("SetParams() error = %v", err)
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			_, labels[i] = shared[i].Cluster(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, c := range shared {
+		k := len(shared) - i
+		fresh := new(Ckmeans)
+		if err := fresh.Init(ctx, set); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		if err := fresh.SetParams(ctx, params.Values{float64(k)}); err != nil {
+			t.Fatalf("SetParams() error = %v", err)
+		}
+
+		if _, want := fresh.Cluster(ctx); !reflect.DeepEqual(labels[i], want) {
+			t.Errorf("Cluster() of shared table labels for k = %d: %v, want %v", k, labels[i], want)
+		}
+		if c.table != shared[0].table {
+			t.Errorf("clusterer %d fills its own table", i)
+		}
+	}
+	if got := len(shared[0].table.first); got != len(shared) {
+		t.Errorf("table has %d rows, want %d", got, len(shared))
+	}
+}
+
+func sse(data []float64, labels []int) float64 {
+	sums, counts := make(map[int]float64), make(map[int]float64)
+	for i, l := range labels {
+		sums[l] += data[i]
+		counts[l]++
+	}
+
+	total := 0.0
+	for i, l := range labels {
+		d := data[i] - sums[l]/counts[l]
+		total += d * d
+	}
+
+	return total
+}
+
+// bruteForceSSE returns the lowest sum of squares of the sorted points split into k intervals.
+func bruteForceSSE(sorted []float64, k int) float64 {
+	if k == 1 {
+		labels := make([]int, len(sorted))
+		return sse(sorted, labels)
+	}
+
+	best := math.Inf(1)
+	for j := k - 1; j < len(sorted); j++ {
+		head := bruteForceSSE(sorted[:j], k-1)
+		best = math.Min(best, head+sse(sorted[j:], make([]int, len(sorted)-j)))
+	}
+
+	return best
+}
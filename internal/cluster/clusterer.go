@@ -1,11 +1,114 @@
 package cluster
 
-import "context"
+import (
+	"context"
+
+	"github.com/boson-research/patterns/internal/cluster/dbscan"
+	"github.com/boson-research/patterns/internal/cluster/hierarchical"
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/samber/lo"
+)
+
+// Noise is the label of the points the clusterer left out of any cluster, like the sparse points of DBSCAN. Noise
+// points are scored as a cluster of their own by the quality estimation.
+const Noise = dbscan.Noise
 
 type Clusterer interface {
-	Init(ctx context.Context, data []float64)
-	GetOptimizationParams(ctx context.Context) []int
-	SetOptimizationParams(ctx context.Context, params []int) error
-	Cluster(ctx context.Context) (clusters []float64, labels []int)
-	ValidateOptimizationParams(params []int) error
+	// Init initializes the clusterer with the data, it fails if the clusterer does not support the data, e.g. its
+	// dimension.
+	Init(ctx context.Context, data *points.Set) error
+	// Params returns the space of the params of the clusterer, its bounds depend on the data it is initialized with.
+	Params() params.Space
+	// SetParams sets the values of the params, it fails if they are not valid for the space.
+	SetParams(ctx context.Context, values params.Values) error
+	Cluster(ctx context.Context) (clusters *points.Set, labels []int)
+}
+
+// MetricClusterer is the clusterer measuring the distances between the points with a configurable metric. The metric
+// is set before Init.
+type MetricClusterer interface {
+	Clusterer
+	SetMetric(metric points.Metric)
+}
+
+// SeededClusterer is the clusterer drawing random numbers, they are derived from the seed only, so that the
+// clusterings are reproducible. The seed is set before Init.
+type SeededClusterer interface {
+	Clusterer
+	SetSeed(seed int64)
+}
+
+// MedoidClusterer is the clusterer whose centers of the clusters are the points of the data.
+type MedoidClusterer interface {
+	Clusterer
+	// Medoids returns the indices of the points the centers of the clusters of the last clustering are, ordered by
+	// the labels.
+	Medoids(ctx context.Context) []int
+}
+
+// HierarchicalClusterer is the clusterer building the tree of the nested clusters, the flat clusters are its cut.
+type HierarchicalClusterer interface {
+	Clusterer
+	// Dendrogram returns the tree the last clustering is the cut of.
+	Dendrogram(ctx context.Context) *hierarchical.Dendrogram
+}
+
+// SharingClusterer is the clusterer whose structures built from the data, like the trees of the merges of the
+// agglomerative clusterer, could be shared by the clusterers of the same data, so that the workers of the optimization
+// build them once.
+type SharingClusterer interface {
+	Clusterer
+	// Shared returns the structures built from the data the clusterer is initialized with.
+	Shared() any
+	// Share makes the clusterer use the structures of another clusterer of the same type initialized with the same
+	// data.
+	Share(shared any)
+}
+
+// SoftClusterer is the clusterer assigning every point to every cluster with some probability, the labels are the most
+// probable clusters.
+type SoftClusterer interface {
+	Clusterer
+	// Probabilities returns the probabilities of the points to belong to the clusters of the last clustering by their
+	// labels.
+	Probabilities(ctx context.Context) [][]float64
+}
+
+// MixtureClusterer is the soft clusterer fitting the mixture of gaussians with diagonal covariances centered at the
+// centroids, the points belong to the components with the probabilities given by the mixture.
+type MixtureClusterer interface {
+	SoftClusterer
+	// Mixture returns the weights and the variances of the components of the last clustering by their labels.
+	Mixture(ctx context.Context) (weights []float64, variances *points.Set)
+}
+
+// ModelClusterer is the clusterer fitting the probabilistic model of the data. The information criteria are
+// calculated from the likelihood of the fitted model instead of the one estimated from the labels.
+type ModelClusterer interface {
+	Clusterer
+	// LogLikelihood returns the log-likelihood of the data under the model fitted by the last clustering and the number
+	// of the free parameters of the model.
+	LogLikelihood(ctx context.Context) (float64, int)
+}
+
+// countClusters returns the number of the distinct labels except noise.
+func countClusters(labels []int) int {
+	return len(lo.Without(lo.Uniq(labels), Noise))
+}
+
+// noiseAsCluster returns the labels with the noise points labeled as a cluster of their own, so that the quality
+// estimation does not reward leaving the points out of the clusters.
+func noiseAsCluster(labels []int) []int {
+	if !lo.Contains(labels, Noise) {
+		return labels
+	}
+
+	noise := lo.Max(labels) + 1
+	relabeled := make([]int, len(labels))
+	for i, l := range labels {
+		relabeled[i] = lo.Ternary(l == Noise, noise, l)
+	}
+
+	return relabeled
 }
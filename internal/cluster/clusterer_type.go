@@ -1,26 +1,117 @@
 package cluster
 
-import "github.com/boson-research/patterns/internal/cluster/kmeans"
+import (
+	"context"
+	"fmt"
+
+	"github.com/boson-research/patterns/internal/cluster/ckmeans"
+	"github.com/boson-research/patterns/internal/cluster/dbscan"
+	"github.com/boson-research/patterns/internal/cluster/gmm"
+	"github.com/boson-research/patterns/internal/cluster/hierarchical"
+	"github.com/boson-research/patterns/internal/cluster/kmeans"
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/registry"
+)
 
 type ClustererType int
 
 const (
 	KMeans ClustererType = iota
+	DBSCAN
+	Ckmeans
+	Agglomerative
+	GMM
+	KMedians
+	KMedoids
+	MiniBatchKMeans
 )
 
+// ClustererInfo describes the registered clusterer.
+type ClustererInfo struct {
+	Type        ClustererType
+	Name        string
+	Description string
+	// New returns the new clusterer, every goroutine of the optimization works with its own one.
+	New func() Clusterer
+}
+
+// Params returns the params the clusterer declares for n evenly spaced 1-D points, as the bounds of some params depend
+// on the data.
+func (i ClustererInfo) Params(ctx context.Context, n int) (params.Space, error) {
+	values := make([]float64, n)
+	for j := range values {
+		values[j] = float64(j)
+	}
+
+	clusterer := i.New()
+	if err := clusterer.Init(ctx, points.FromValues(values)); err != nil {
+		return nil, fmt.Errorf("initialize %s clusterer: %w", i.Name, err)
+	}
+
+	return clusterer.Params(), nil
+}
+
+// clusterers holds the registered clusterers, the ids are their types.
+var clusterers registry.Registry[ClustererInfo]
+
+func init() {
+	mustRegisterClusterer(KMeans, "kmeans", "k-means with random or k-means++ initialization and restarts", func() Clusterer { return new(kmeans.KMeans) })
+	mustRegisterClusterer(DBSCAN, "dbscan", "density-based clustering leaving the entries of the sparse regions as noise", func() Clusterer { return new(dbscan.DBSCAN) })
+	mustRegisterClusterer(Ckmeans, "ckmeans", "optimal 1-D k-means by dynamic programming", func() Clusterer { return new(ckmeans.Ckmeans) })
+	mustRegisterClusterer(Agglomerative, "agglomerative", "hierarchical clustering merging the closest clusters into the tree cut into k clusters", func() Clusterer { return new(hierarchical.Agglomerative) })
+	mustRegisterClusterer(GMM, "gmm", "gaussian mixture fitted by expectation maximization, the entries belong to the clusters with probabilities", func() Clusterer { return new(gmm.GMM) })
+	mustRegisterClusterer(KMedians, "kmedians", "k-means with the centroids at the coordinate-wise medians", func() Clusterer { return new(kmeans.KMedians) })
+	mustRegisterClusterer(KMedoids, "kmedoids", "partitioning around medoids, the centers of the clusters are the entries", func() Clusterer { return new(kmeans.KMedoids) })
+	mustRegisterClusterer(MiniBatchKMeans, "minibatch", "k-means updating the centroids from the random batches of the entries", func() Clusterer { return new(kmeans.MiniBatchKMeans) })
+}
+
+// RegisterClusterer registers the clusterer by its name and returns its type, so that it could be chosen by the name
+// like the built-in ones. It fails if the name is already registered.
+func RegisterClusterer(name, description string, newClusterer func() Clusterer) (ClustererType, error) {
+	id, err := clusterers.Register(name, ClustererInfo{Name: name, Description: description, New: newClusterer})
+	if err != nil {
+		return 0, fmt.Errorf("register clusterer: %w", err)
+	}
+
+	return ClustererType(id), nil
+}
+
+func mustRegisterClusterer(t ClustererType, name, description string, newClusterer func() Clusterer) {
+	if registered, err := RegisterClusterer(name, description, newClusterer); err != nil || registered != t {
+		panic(fmt.Sprintf("register built-in clusterer %s: %v", name, err))
+	}
+}
+
+// Clusterers returns all the registered clusterers.
+func Clusterers() []ClustererInfo {
+	infos := clusterers.Entries()
+	for i := range infos {
+		infos[i].Type = ClustererType(i)
+	}
+
+	return infos
+}
+
 func (t ClustererType) String() string {
-	switch t {
-	case KMeans:
-		return "kmeans"
-	default:
-		return "unknown"
+	if name := clusterers.Name(int(t)); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseClustererType returns the clusterer type by its name.
+func ParseClustererType(s string) (ClustererType, error) {
+	if id, ok := clusterers.Lookup(s); ok {
+		return ClustererType(id), nil
 	}
+	return 0, fmt.Errorf("unknown clusterer type: %s", s)
 }
 
 func getClusterer(t ClustererType) Clusterer {
-	switch t {
-	case KMeans:
-		return new(kmeans.KMeans)
+	info, ok := clusterers.Get(int(t))
+	if !ok {
+		return nil
 	}
-	return nil
+	return info.New()
 }
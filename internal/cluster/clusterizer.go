@@ -4,99 +4,499 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"runtime"
+	"sync"
+	"time"
 
+	"github.com/boson-research/patterns/internal/cluster/hierarchical"
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
 	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"github.com/samber/lo"
 )
 
 type Clusterizer struct {
-	clusterer        Clusterer
-	qualityEstimator qualityEstimator
+	clustererType           ClustererType
+	qualityEstimationMethod QualityEstimationMethod
+	// reportedMethods are the quality estimation methods the clusterings are scored with besides the chosen one.
+	reportedMethods []QualityEstimationMethod
+	concurrency     int
+	metric          points.Metric
+	searchStrategy  SearchStrategy
+	// maxEvaluations and timeout are the budget of the search, unlimited if not positive.
+	maxEvaluations int
+	timeout        time.Duration
+	// params are the values of the clusterer params assigned by their names, they are not tuned by the search.
+	params map[string]string
+	// seed is the seed of the random numbers of the clusterers and the search.
+	seed int64
+	// silhouetteSample is the number of the points the silhouette is estimated on, all of them if not positive.
+	silhouetteSample int
+	// resampling, resamples and sampleFraction set how the stability of the clusterings is measured.
+	resampling     ResamplingMethod
+	resamples      int
+	sampleFraction float64
 }
 
 func New(clusterer ClustererType, qualityEstimator QualityEstimationMethod) *Clusterizer {
 	return &Clusterizer{
-		clusterer:        getClusterer(clusterer),
-		qualityEstimator: getQualityEstimator(qualityEstimator),
+		clustererType:           clusterer,
+		qualityEstimationMethod: qualityEstimator,
+		concurrency:             runtime.GOMAXPROCS(0),
+		metric:                  points.Euclidean,
+		resamples:               DefaultResamples,
+		sampleFraction:          DefaultSampleFraction,
 	}
 }
 
-func (c *Clusterizer) Clusterize(ctx context.Context, data []float64) ([]float64, []int) {
-	c.clusterer.Init(ctx, data)
-	return c.optimize(ctx, data)
+// WithMetric sets the distance between the points used by the clusterers supporting it and by the quality estimation
+// methods based on distances, like silhouette. The methods based on sums of squares are euclidean whatever the metric
+// is.
+func (c *Clusterizer) WithMetric(metric points.Metric) *Clusterizer {
+	c.metric = metric
+	return c
 }
 
-func (c *Clusterizer) optimize(ctx context.Context, data []float64) ([]float64, []int) {
-	if len(data) == 1 {
+// WithSearchStrategy sets the strategy choosing which of the params variations are evaluated.
+func (c *Clusterizer) WithSearchStrategy(strategy SearchStrategy) *Clusterizer {
+	c.searchStrategy = strategy
+	return c
+}
+
+// WithBudget limits the number of the clusterings evaluated by the search and its wall-clock time, non-positive values
+// stand for no limit. The best of the clusterings evaluated within the budget is chosen. The comparing quality
+// estimation methods, like gap, evaluate the found clusterings after the search regardless of the budget.
+func (c *Clusterizer) WithBudget(maxEvaluations int, timeout time.Duration) *Clusterizer {
+	c.maxEvaluations = maxEvaluations
+	c.timeout = timeout
+	return c
+}
+
+// WithParams fixes the values of the clusterer params by their names, the rest of the tunable params are searched
+// over. The values are validated once the clusterer is initialized with the data.
+func (c *Clusterizer) WithParams(assignments map[string]string) *Clusterizer {
+	c.params = assignments
+	return c
+}
+
+// WithSeed sets the seed the random numbers of the seeded clusterers and of the search are derived from, the
+// clusterings are reproducible with the same seed.
+func (c *Clusterizer) WithSeed(seed int64) *Clusterizer {
+	c.seed = seed
+	return c
+}
+
+// WithSilhouetteSample sets the number of the random points the silhouette is estimated on, all the points are scored
+// if it is not positive. The 1-D points with the absolute difference distance are always scored exactly, as it takes
+// O(n log n + nk) time at most.
+func (c *Clusterizer) WithSilhouetteSample(size int) *Clusterizer {
+	c.silhouetteSample = size
+	return c
+}
+
+// WithConcurrency sets the number of the optimization params variations evaluated in parallel. Non-positive values
+// stand for GOMAXPROCS.
+func (c *Clusterizer) WithConcurrency(n int) *Clusterizer {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+
+	c.concurrency = n
+	return c
+}
+
+// WithReportedMethods sets the quality estimation methods the clusterings are scored with besides the one used to
+// choose the best clustering, so that the methods could be compared side by side.
+func (c *Clusterizer) WithReportedMethods(methods ...QualityEstimationMethod) *Clusterizer {
+	c.reportedMethods = methods
+	return c
+}
+
+// Result is the clustering chosen by the quality estimation.
+type Result struct {
+	Centroids *points.Set
+	Labels    []int
+	Params    params.Values
+	// Space is the space of the params of the clusterer, it names and formats the params.
+	Space params.Space
+	// Seed is the seed of the random numbers the clustering was made with.
+	Seed int64
+	// ClustersNum is the number of the non-empty clusters, noise is not counted.
+	ClustersNum int
+	// Score is the quality score of the clustering, NaN if the optimization was skipped.
+	Score float64
+	// Scores holds the scores by every estimated quality method, including the chosen one.
+	Scores map[QualityEstimationMethod]float64
+	// Probabilities holds the probabilities of the points to belong to the clusters by their labels, set by the soft
+	// clusterers only.
+	Probabilities [][]float64
+	// Dendrogram is the tree of the nested clusters the clustering is the cut of, set by the hierarchical clusterers
+	// only.
+	Dendrogram *hierarchical.Dendrogram
+	// Silhouettes holds the silhouettes of the points, NaN for the noise and the points left out of the sample, set if
+	// the clusterings are scored with the silhouette.
+	Silhouettes []float64
+	// Medoids holds the indices of the points the centers of the clusters are by their labels, set by the medoid
+	// clusterers only.
+	Medoids []int
+	// Model assigns the new points to the clusters by their labels.
+	Model *Model
+	// Candidates holds the results of all the evaluated params variations in the order of evaluation. Centroids,
+	// labels, probabilities, dendrograms, medoids and silhouettes of the candidates are dropped.
+	Candidates []*Result
+}
+
+// estimator is the quality estimator with the method it implements.
+type estimator struct {
+	method QualityEstimationMethod
+	*qualityEstimator
+}
+
+func (c *Clusterizer) Clusterize(ctx context.Context, data *points.Set) (*Result, error) {
+	var estimators []estimator
+	for _, m := range lo.Uniq(append([]QualityEstimationMethod{c.qualityEstimationMethod}, c.reportedMethods...)) {
+		e := c.qualityEstimator(m)
+		if e == nil {
+			return nil, fmt.Errorf("unknown quality estimation method: %s", m)
+		}
+
+		estimators = append(estimators, estimator{method: m, qualityEstimator: e})
+	}
+
+	ctx = context.WithValue(ctx, sharedKey{}, &shared{byData: make(map[*points.Set]any)})
+
+	clusterer, err := c.newClusterer(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.optimize(ctx, clusterer, data, estimators)
+}
+
+// sharedKey is the context key of the structures shared by the clusterers of a clusterization.
+type sharedKey struct{}
+
+// shared holds the structures built from the data sets clustered during a clusterization by the sets.
+type shared struct {
+	mu     sync.Mutex
+	byData map[*points.Set]any
+}
+
+// share makes the clusterer use the structures of the first clusterer of the same data.
+func (s *shared) share(data *points.Set, clusterer SharingClusterer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d, ok := s.byData[data]; ok {
+		clusterer.Share(d)
+		return
+	}
+
+	s.byData[data] = clusterer.Shared()
+}
+
+// newClusterer returns the new clusterer initialized with the data. Every goroutine of the optimization works with
+// its own clusterer, as setting the params mutates it, the structures the sharing clusterers build from the same
+// data, like the trees of the merges, are shared by them within a clusterization.
+func (c *Clusterizer) newClusterer(ctx context.Context, data *points.Set) (Clusterer, error) {
+	clusterer := getClusterer(c.clustererType)
+	if clusterer == nil {
+		return nil, fmt.Errorf("unknown clusterer type: %s", c.clustererType)
+	}
+
+	if mc, ok := clusterer.(MetricClusterer); ok {
+		mc.SetMetric(c.metric)
+	}
+
+	if sc, ok := clusterer.(SeededClusterer); ok {
+		sc.SetSeed(c.seed)
+	}
+
+	if err := clusterer.Init(ctx, data); err != nil {
+		return nil, fmt.Errorf("initialize %s clusterer: %w", c.clustererType, err)
+	}
+
+	if sc, ok := clusterer.(SharingClusterer); ok {
+		if s, ok := ctx.Value(sharedKey{}).(*shared); ok {
+			s.share(data, sc)
+		}
+	}
+
+	return clusterer, nil
+}
+
+// optimize evaluates the params variations and chooses the best clustering by the first of the estimators.
+func (c *Clusterizer) optimize(ctx context.Context, clusterer Clusterer, data *points.Set, estimators []estimator) (*Result, error) {
+	space, err := clusterer.Params().Fix(c.params)
+	if err != nil {
+		return nil, fmt.Errorf("set %s params: %w", c.clustererType, err)
+	}
+
+	if data.Len() == 1 {
 		logger.MustFromContext(ctx).Debug("skipping optimization for number of clusters")
 
-		return c.clusterer.Cluster(ctx)
+		if err := clusterer.SetParams(ctx, space.Defaults()); err != nil {
+			return nil, fmt.Errorf("set %s params: %w", c.clustererType, err)
+		}
+
+		centroids, labels := clusterer.Cluster(ctx)
+		res := &Result{
+			Centroids:   centroids,
+			Labels:      labels,
+			Params:      space.Defaults(),
+			Space:       space,
+			Seed:        c.seed,
+			ClustersNum: countClusters(labels),
+			Score:       math.NaN(),
+			Scores:      map[QualityEstimationMethod]float64{},
+			Dendrogram:  dendrogram(ctx, clusterer),
+		}
+		if sc, ok := clusterer.(SoftClusterer); ok {
+			res.Probabilities = sc.Probabilities(ctx)
+		}
+		if mc, ok := clusterer.(MedoidClusterer); ok {
+			res.Medoids = mc.Medoids(ctx)
+		}
+		res.Model = newModel(ctx, clusterer, data, labels, centroids, c.metric)
+		res.Candidates = []*Result{res.candidate()}
+
+		return res, nil
 	}
 
-	optimizationParams := c.clusterer.GetOptimizationParams(ctx)
+	variations := space.Grid()
 
-	var bestCentroids []float64
-	var bestLabels []int
-	var bestParams []int
-	bestScore := math.Inf(-1)
+	logger.MustFromContext(ctx).Debugf("evaluating %d params variations with concurrency %d", len(variations), c.concurrency)
 
-	// TODO: parallelize
-	for _, params := range generateOptimizationParamsVariations(optimizationParams, c.clusterer.ValidateOptimizationParams) {
-		if err := c.clusterer.SetOptimizationParams(ctx, params); err != nil {
-			logger.MustFromContext(ctx).Errorf("failed to set optimization params: %v", err)
-			continue
+	searched, err := c.search(ctx, data, variations, estimators)
+	if err != nil {
+		return nil, err
+	}
+
+	// the comparing estimators get the evaluated variations only
+	var evaluated []params.Values
+	var results []*Result
+	for i, res := range searched {
+		if res != nil {
+			evaluated = append(evaluated, variations[i])
+			results = append(results, res)
 		}
+	}
+
+	bestIdx, err := c.choose(ctx, data, evaluated, results, estimators)
+	if err != nil {
+		return nil, err
+	}
 
-		centroids, labels := c.clusterer.Cluster(ctx)
-		score := c.qualityEstimator(data, labels)
+	if bestIdx < 0 {
+		return nil, fmt.Errorf("no valid params variations among %d", len(variations))
+	}
 
-		fmt.Printf("quality score for %v params: %.2f\n", params, score)
+	best := results[bestIdx]
+	best.Score = best.Scores[c.qualityEstimationMethod]
+	for _, res := range results {
+		if res != nil {
+			res.Score = res.Scores[c.qualityEstimationMethod]
+			best.Candidates = append(best.Candidates, res.candidate())
+		}
+	}
+
+	for _, e := range estimators {
+		if e.method == Silhouette {
+			best.Silhouettes = silhouettes(data, best.Labels, c.metric, silhouetteSample(data, c.metric, c.silhouetteSample, c.seed))
+		}
+	}
 
-		if score > bestScore {
-			bestScore = score
-			bestCentroids = centroids
-			bestLabels = labels
-			bestParams = params
+	// the probabilities take n·k values per variation, so the soft clusterers refit the best one for them only
+	if sc, ok := clusterer.(SoftClusterer); ok {
+		if err := sc.SetParams(ctx, best.Params); err != nil {
+			return nil, fmt.Errorf("set %s params: %w", c.clustererType, err)
 		}
 
-		logger.MustFromContext(ctx).Tracef("quality score for %v params: %.2f", params, score)
+		sc.Cluster(ctx)
+		best.Probabilities = sc.Probabilities(ctx)
 	}
 
-	logger.MustFromContext(ctx).Debugf("found optimal score for %v params: %.2f", bestParams, bestScore)
+	best.Model = newModel(ctx, clusterer, data, best.Labels, best.Centroids, c.metric)
 
-	fmt.Printf("found optimal score for %v params: %.2f\n", bestParams, bestScore)
+	logger.MustFromContext(ctx).Debugf("found optimal score for %s: %.2f", space.Format(best.Params), best.Score)
 
-	return bestCentroids, bestLabels
+	return best, nil
 }
 
-func generateOptimizationParamsVariations(startingParams []int, validator func(params []int) error) [][]int {
-	var variations [][]int
+// qualityEstimator returns the estimator of the quality estimation method configured by the clusterizer, nil if the
+// method is unknown.
+func (c *Clusterizer) qualityEstimator(m QualityEstimationMethod) *qualityEstimator {
+	e := getQualityEstimator(m)
+	if m == Silhouette && c.silhouetteSample > 0 {
+		e.score = sampledSilhouette(c.silhouetteSample, c.seed)
+	}
+
+	return e
+}
+
+// choose scores the results of the variations by the comparing estimators and returns the index of the best result by
+// the chosen quality estimation method, -1 if there are no results.
+func (c *Clusterizer) choose(ctx context.Context, data *points.Set, variations []params.Values, results []*Result, estimators []estimator) (int, error) {
+	bestIdx := -1
+	for _, e := range estimators {
+		if e.compare == nil {
+			continue
+		}
+
+		scores, best, err := e.compare(ctx, &evaluation{
+			data:    data,
+			results: results,
+			evaluate: func(ctx context.Context, data *points.Set) ([]*Result, error) {
+				return c.evaluate(ctx, data, variations, nil)
+			},
+		})
+		if err != nil {
+			return -1, fmt.Errorf("estimate quality by %s: %w", e.method, err)
+		}
 
-	// create a copy of startingParams to avoid modifying the original slice
-	params := make([]int, len(startingParams))
-	copy(params, startingParams)
+		for i, score := range scores {
+			if results[i] != nil {
+				results[i].Scores[e.method] = score
+			}
+		}
 
-	var generate func(int)
-	generate = func(idx int) {
-		if idx == len(params) {
-			if err := validator(params); err == nil {
-				// make a copy of params to avoid modifying the slice later
-				validParams := make([]int, len(params))
-				copy(validParams, params)
-				variations = append(variations, validParams)
+		if e.method == c.qualityEstimationMethod {
+			bestIdx = best
+		}
+	}
+
+	// the first of the equally scored variations wins, so that the result does not depend on scheduling
+	if bestIdx < 0 {
+		for i, res := range results {
+			if res != nil && (bestIdx < 0 || isBetterScore(res.Scores[c.qualityEstimationMethod], results[bestIdx].Scores[c.qualityEstimationMethod])) {
+				bestIdx = i
 			}
-			return
 		}
+	}
 
-		for {
-			generate(idx + 1)
-			params[idx]++
-			if err := validator(params); err != nil {
-				params[idx] = startingParams[idx]
-				break
+	return bestIdx, nil
+}
+
+// candidate returns the copy of the result without centroids and labels.
+func (r *Result) candidate() *Result {
+	return &Result{
+		Params:      r.Params,
+		Space:       r.Space,
+		Seed:        r.Seed,
+		ClustersNum: r.ClustersNum,
+		Score:       r.Score,
+		Scores:      r.Scores,
+	}
+}
+
+// evaluate clusters the data with every params variation on a pool of workers and scores the clusterings with the
+// estimators scoring every clustering on its own. The results are ordered as the variations, the ones failed to be
+// set are nil.
+func (c *Clusterizer) evaluate(ctx context.Context, data *points.Set, variations []params.Values, estimators []estimator) ([]*Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*Result, len(variations))
+	jobs := make(chan int)
+	errs := make(chan error, c.concurrency)
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < min(c.concurrency, len(variations)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			clusterer, err := c.newClusterer(ctx, data)
+			if err != nil {
+				errs <- err
+				cancel()
+				return
+			}
+
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				values := variations[i]
+				if err := clusterer.SetParams(ctx, values); err != nil {
+					// the variations are generated valid for the clustered data, the bounds of the params of other
+					// data, like the gap statistic references, could be narrower
+					logger.MustFromContext(ctx).Debugf("failed to set optimization params: %v", err)
+					continue
+				}
+
+				centroids, labels := clusterer.Cluster(ctx)
+
+				res := &Result{
+					Centroids:   centroids,
+					Labels:      labels,
+					Params:      values,
+					Space:       clusterer.Params(),
+					Seed:        c.seed,
+					ClustersNum: countClusters(labels),
+					Scores:      make(map[QualityEstimationMethod]float64, len(estimators)),
+					Dendrogram:  dendrogram(ctx, clusterer),
+				}
+				if mc, ok := clusterer.(MedoidClusterer); ok {
+					res.Medoids = mc.Medoids(ctx)
+				}
+
+				scoredLabels := noiseAsCluster(labels)
+				for _, e := range estimators {
+					// the models do not know the weights, the weighted points are scored by the labels
+					switch mc, ok := clusterer.(ModelClusterer); {
+					case ok && e.scoreModel != nil && !data.Weighted():
+						ll, paramsNum := mc.LogLikelihood(ctx)
+						res.Scores[e.method] = e.scoreModel(ll, paramsNum, data.TotalWeight())
+					case e.score != nil:
+						res.Scores[e.method] = e.score(data, scoredLabels, c.metric)
+					default:
+						continue
+					}
+
+					logger.MustFromContext(ctx).Tracef("%s quality score for %s: %.2f", e.method, clusterer.Params().Format(values), res.Scores[e.method])
+				}
+
+				results[i] = res
 			}
+		}()
+	}
+
+feed:
+	for i := range variations {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	// the results evaluated before the cancellation are returned along with the error
+	if err := ctx.Err(); err != nil {
+		return results, fmt.Errorf("evaluate params variations: %w", err)
+	}
+
+	return results, nil
+}
+
+// dendrogram returns the dendrogram of the last clustering of the hierarchical clusterer, nil for the others.
+func dendrogram(ctx context.Context, clusterer Clusterer) *hierarchical.Dendrogram {
+	if h, ok := clusterer.(HierarchicalClusterer); ok {
+		return h.Dendrogram(ctx)
+	}
+
+	return nil
+}
 
-	generate(0)
-	return variations
+// isBetterScore reports whether the score a is better than b. NaN scores are worse than any other.
+func isBetterScore(a, b float64) bool {
+	return a > b || math.IsNaN(b) && !math.IsNaN(a)
 }
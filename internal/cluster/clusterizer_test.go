@@ -1,48 +1,76 @@
 package cluster
 
 import (
-	"fmt"
-	"reflect"
+	"context"
+	"errors"
 	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"github.com/samber/lo"
 )
 
-func Test_generateOptimizationParamsVariations(t *testing.T) {
-	type args struct {
-		startingParams []int
-		validator      func(params []int) error
-	}
+func TestClusterizer_Clusterize_params(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+	data := points.FromValues([]float64{1, 2, 3, 10, 11, 12, 20, 21, 22})
+
 	tests := []struct {
-		name string
-		args args
-		want [][]int
+		name       string
+		params     map[string]string
+		wantParams string
+		wantErr    bool
 	}{
-		{
-			name: "test 1",
-			args: args{
-				startingParams: []int{1, 0},
-				validator: func(params []int) error {
-					if params[0] >= 3 || params[1] >= 3 {
-						return fmt.Errorf("params must be less than 3")
-					}
-
-					return nil
-				},
-			},
-			want: [][]int{
-				{1, 0},
-				{1, 1},
-				{1, 2},
-				{2, 0},
-				{2, 1},
-				{2, 2},
-			},
-		},
+		{name: "tuned", wantParams: "k=3 linkage=single"},
+		{name: "fixed", params: map[string]string{"k": "2", "linkage": "ward"}, wantParams: "k=2 linkage=ward"},
+		{name: "unknown param", params: map[string]string{"eps": "2"}, wantErr: true},
+		{name: "out of bounds", params: map[string]string{"k": "5"}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := generateOptimizationParamsVariations(tt.args.startingParams, tt.args.validator); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("generateOptimizationParamsVariations() = %v, want %v", got, tt.want)
+			res, err := New(Agglomerative, Silhouette).WithParams(tt.params).Clusterize(ctx, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Clusterizer.Clusterize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := res.Space.Format(res.Params); got != tt.wantParams {
+				t.Errorf("Clusterizer.Clusterize() params = %s, want %s", got, tt.wantParams)
 			}
 		})
 	}
 }
+
+func TestClusterizer_Clusterize_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(logger.InjectIntoContext(context.Background(), logger.MustCreate()))
+	cancel()
+
+	if _, err := New(KMeans, Silhouette).WithConcurrency(2).Clusterize(ctx, points.FromValues([]float64{1, 2, 3, 10, 11, 12})); !errors.Is(err, context.Canceled) {
+		t.Errorf("Clusterizer.Clusterize() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClusterizer_Clusterize_probabilities(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+	data := points.FromValues([]float64{1, 2, 3, 10, 11, 12, 20, 21, 22})
+
+	res, err := New(GMM, BIC).Clusterize(ctx, data)
+	if err != nil {
+		t.Fatalf("Clusterizer.Clusterize() error = %v", err)
+	}
+
+	if len(res.Probabilities) != data.Len() {
+		t.Fatalf("Clusterizer.Clusterize() probabilities of %d points, want %d", len(res.Probabilities), data.Len())
+	}
+	for i, p := range res.Probabilities {
+		if l := lo.IndexOf(p, lo.Max(p)); l != res.Labels[i] {
+			t.Errorf("Clusterizer.Clusterize() most probable cluster of point %d = %d, want label %d", i, l, res.Labels[i])
+		}
+	}
+	for _, c := range res.Candidates {
+		if c.Probabilities != nil {
+			t.Errorf("Clusterizer.Clusterize() kept the probabilities of the candidate %s", c.Space.Format(c.Params))
+		}
+	}
+}
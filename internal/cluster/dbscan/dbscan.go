@@ -0,0 +1,164 @@
+package dbscan
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// Noise is the label of the points not belonging to any cluster.
+const Noise = -1
+
+// maxMinPts is the upper bound of the minimal number of points in the neighbourhood of a core point.
+const maxMinPts = 10
+
+// DBSCAN is the density-based clusterer. Points having at least minPts points (including themselves) within eps are
+// core points, core points within eps of each other form clusters, the rest of the points within eps of a core point
+// join the cluster of the nearest one, others are noise.
+type DBSCAN struct {
+	eps    float64
+	minPts int
+	params params.Space
+	data   []float64
+	// order holds the indices of the data sorted by value.
+	order []int
+}
+
+func (d *DBSCAN) Init(ctx context.Context, set *points.Set) error {
+	if set.Dim() != 1 {
+		return fmt.Errorf("dbscan clusters 1-D points only, got %d-D", set.Dim())
+	}
+	data := set.Flat()
+
+	d.data = data
+	d.order = make([]int, len(data))
+	for i := range d.order {
+		d.order[i] = i
+	}
+	sort.SliceStable(d.order, func(i, j int) bool { return data[d.order[i]] < data[d.order[j]] })
+
+	// smaller eps than the smallest gap leaves the points apart, larger than the largest one joins them all into a
+	// single cluster, so eps is searched between them whatever the scale of the data
+	minGap, maxGap := math.Inf(1), 0.0
+	for i := 1; i < len(d.order); i++ {
+		if gap := data[d.order[i]] - data[d.order[i-1]]; gap > 0 {
+			minGap, maxGap = math.Min(minGap, gap), math.Max(maxGap, gap)
+		}
+	}
+	if maxGap == 0 {
+		minGap, maxGap = 1, 1
+	}
+
+	d.params = params.Space{
+		{Name: "eps", Kind: params.Float, Min: minGap, Max: maxGap, Default: minGap, LogScale: true, Tunable: true},
+		{Name: "min-pts", Kind: params.Int, Min: 2, Max: float64(max(2, min(maxMinPts, len(data)))), Default: 2, Tunable: true},
+	}
+
+	return d.SetParams(ctx, d.params.Defaults())
+}
+
+func (d *DBSCAN) Params() params.Space {
+	return d.params
+}
+
+func (d *DBSCAN) SetParams(ctx context.Context, values params.Values) error {
+	if err := d.params.Validate(values); err != nil {
+		return err
+	}
+
+	d.eps = values[0]
+	d.minPts = int(values[1])
+
+	return nil
+}
+
+// Cluster returns the means of the clusters and the labels of the points, noise points are labeled with Noise.
+func (d *DBSCAN) Cluster(ctx context.Context) (*points.Set, []int) {
+	ctx, span := otel.Tracer("").Start(ctx, "DBSCAN")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Tracef("clustering %d points with eps %g and min points %d", len(d.data), d.eps, d.minPts)
+
+	eps := d.eps
+	sorted := make([]float64, len(d.order))
+	for i, idx := range d.order {
+		sorted[i] = d.data[idx]
+	}
+
+	// count the neighbours of every point with the window sliding over the sorted points
+	core := make([]bool, len(sorted))
+	lo, hi := 0, 0
+	for i, p := range sorted {
+		for sorted[lo] < p-eps {
+			lo++
+		}
+		for hi < len(sorted) && sorted[hi] <= p+eps {
+			hi++
+		}
+
+		core[i] = hi-lo >= d.minPts
+	}
+
+	// consecutive core points within eps belong to the same cluster, as no other core point lies between them
+	sortedLabels := make([]int, len(sorted))
+	clustersNum, prevCore := 0, -1
+	for i := range sorted {
+		sortedLabels[i] = Noise
+		if !core[i] {
+			continue
+		}
+
+		if prevCore < 0 || sorted[i]-sorted[prevCore] > eps {
+			clustersNum++
+		}
+
+		sortedLabels[i] = clustersNum - 1
+		prevCore = i
+	}
+
+	// border points join the cluster of the nearest core point within eps
+	prevCore = -1
+	for i := range sorted {
+		if core[i] {
+			prevCore = i
+			continue
+		}
+
+		nextCore := i + 1
+		for nextCore < len(sorted) && !core[nextCore] {
+			nextCore++
+		}
+
+		bestDist := math.Inf(1)
+		if prevCore >= 0 && sorted[i]-sorted[prevCore] <= eps {
+			bestDist = sorted[i] - sorted[prevCore]
+			sortedLabels[i] = sortedLabels[prevCore]
+		}
+		if nextCore < len(sorted) && sorted[nextCore]-sorted[i] <= eps && sorted[nextCore]-sorted[i] < bestDist {
+			sortedLabels[i] = sortedLabels[nextCore]
+		}
+	}
+
+	labels := make([]int, len(d.data))
+	centroids := make([]float64, clustersNum)
+	counts := make([]int, clustersNum)
+	for i, idx := range d.order {
+		labels[idx] = sortedLabels[i]
+		if sortedLabels[i] != Noise {
+			centroids[sortedLabels[i]] += sorted[i]
+			counts[sortedLabels[i]]++
+		}
+	}
+
+	for i := range centroids {
+		centroids[i] /= float64(counts[i])
+	}
+
+	return points.FromValues(centroids), labels
+}
@@ -0,0 +1,104 @@
+package dbscan
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+var ctx = logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+func TestDBSCAN_Cluster(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []float64
+		params        params.Values
+		wantCentroids []float64
+		wantLabels    []int
+	}{
+		{
+			name:          "two dense groups and noise",
+			data:          []float64{1, 2, 3, 20, 40, 41, 42},
+			params:        params.Values{1, 3},
+			wantCentroids: []float64{2, 41},
+			wantLabels:    []int{0, 0, 0, Noise, 1, 1, 1},
+		},
+		{
+			name:          "unsorted data",
+			data:          []float64{41, 2, 20, 40, 1, 42, 3},
+			params:        params.Values{1, 3},
+			wantCentroids: []float64{2, 41},
+			wantLabels:    []int{1, 0, Noise, 1, 0, 1, 0},
+		},
+		{
+			name:          "border point joins the nearest core point",
+			data:          []float64{-3, -3, -3, 0, 3, 5, 8, 8, 8},
+			params:        params.Values{3, 4},
+			wantCentroids: []float64{-2.25, 6.4},
+			wantLabels:    []int{0, 0, 0, 0, 1, 1, 1, 1, 1},
+		},
+		{
+			name:          "core points chain into a single cluster",
+			data:          []float64{0, 2, 4, 6, 8},
+			params:        params.Values{2, 2},
+			wantCentroids: []float64{4},
+			wantLabels:    []int{0, 0, 0, 0, 0},
+		},
+		{
+			name:          "all noise",
+			data:          []float64{0, 10, 25},
+			params:        params.Values{10, 3},
+			wantCentroids: []float64{},
+			wantLabels:    []int{Noise, Noise, Noise},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := new(DBSCAN)
+			if err := d.Init(ctx, points.FromValues(tt.data)); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if err := d.SetParams(ctx, tt.params); err != nil {
+				t.Fatalf("SetParams() error = %v", err)
+			}
+
+			centroids, labels := d.Cluster(ctx)
+			if !reflect.DeepEqual(centroids.Flat(), tt.wantCentroids) {
+				t.Errorf("Cluster() centroids = %v, want %v", centroids, tt.wantCentroids)
+			}
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("Cluster() labels = %v, want %v", labels, tt.wantLabels)
+			}
+		})
+	}
+}
+
+func TestDBSCAN_Params(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []float64
+		wantMin float64
+		wantMax float64
+	}{
+		{name: "locations", data: []float64{1, 2, 4, 20}, wantMin: 1, wantMax: 16},
+		{name: "standardized", data: []float64{-0.5, 0.25, 0, 0.25, 1.5}, wantMin: 0.25, wantMax: 1.25},
+		{name: "coinciding points", data: []float64{3, 3, 3}, wantMin: 1, wantMax: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := new(DBSCAN)
+			if err := d.Init(ctx, points.FromValues(tt.data)); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+
+			eps := d.Params()[0]
+			if eps.Min != tt.wantMin || eps.Max != tt.wantMax {
+				t.Errorf("Params() eps bounds = [%v, %v], want [%v, %v]", eps.Min, eps.Max, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
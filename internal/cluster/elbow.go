@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/samber/lo"
+)
+
+// calcElbow scores the clusterings by their distance to the knee of the curve of the within-cluster sum of squares
+// (WCSS) over the number of clusters. The curve is built from the lowest WCSS for every number of clusters, the knee
+// is found Kneedle-style: the curve is normalized to the unit square and the knee is the point farthest below the
+// straight line from the first point to the last one. Every clustering is scored with its own WCSS, so the best score
+// is at the knee of the curve.
+func calcElbow(_ context.Context, e *evaluation) ([]float64, int, error) {
+	scores := calcElbowScores(e.data, e.results)
+	return scores, argmax(scores), nil
+}
+
+func calcElbowScores(data *points.Set, results []*Result) []float64 {
+	ks := make([]float64, len(results))
+	wcss := make([]float64, len(results))
+	curve := make(map[float64]float64)
+	for i, res := range results {
+		if res == nil {
+			continue
+		}
+
+		ks[i] = float64(countClusters(res.Labels))
+		wcss[i] = calcWCSS(data, res.Labels)
+
+		if best, ok := curve[ks[i]]; !ok || wcss[i] < best {
+			curve[ks[i]] = wcss[i]
+		}
+	}
+
+	curveKs := lo.Keys(curve)
+	sort.Float64s(curveKs)
+
+	scores := make([]float64, len(results))
+	for i := range scores {
+		scores[i] = math.NaN()
+	}
+
+	if len(curveKs) == 0 {
+		return scores
+	}
+
+	kMin, kMax := curveKs[0], curveKs[len(curveKs)-1]
+	wcssMin, wcssMax := math.Inf(1), math.Inf(-1)
+	for _, w := range curve {
+		wcssMin, wcssMax = math.Min(wcssMin, w), math.Max(wcssMax, w)
+	}
+
+	for i, res := range results {
+		if res == nil {
+			continue
+		}
+
+		// degenerate curves have no knee, the lowest WCSS wins
+		if kMax == kMin || wcssMax == wcssMin {
+			scores[i] = -wcss[i]
+			continue
+		}
+
+		// the decreasing convex curve is flipped into the increasing concave one, then the knee is the point farthest
+		// above the diagonal
+		x := (ks[i] - kMin) / (kMax - kMin)
+		y := 1 - (wcss[i]-wcssMin)/(wcssMax-wcssMin)
+		scores[i] = y - x
+	}
+
+	return scores
+}
+
+// calcWCSS calculates the within-cluster sum of the squared euclidean distances to the cluster means weighted by the
+// weights of the points, noise points make up a cluster of their own.
+func calcWCSS(data *points.Set, labels []int) float64 {
+	labels = noiseAsCluster(labels)
+
+	s := calcClusterStats(data, labels)
+	wcss := 0.0
+	for i, label := range labels {
+		wcss += data.Weight(i) * points.SquaredEuclidean(data.At(i), s.means[label])
+	}
+
+	return wcss
+}
@@ -0,0 +1,33 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+)
+
+func Test_calcElbow(t *testing.T) {
+	data := points.FromValues([]float64{1, 2, 3, 4, 5, 50, 51, 52, 53, 54, 100, 101, 102, 103, 104})
+	results := []*Result{
+		{Labels: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{Labels: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1}},
+		// worse clustering with the same number of clusters as the knee
+		{Labels: []int{0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 2, 2, 2}},
+		{Labels: []int{0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2}},
+		nil,
+	}
+	// split the clusters further point by point
+	labels := results[3].Labels
+	for k := 4; k <= 8; k++ {
+		labels = append([]int(nil), labels...)
+		labels[len(labels)-1-2*(k-4)] = k - 1
+		results = append(results, &Result{Labels: labels})
+	}
+
+	scores, best, _ := calcElbow(context.Background(), &evaluation{data: data, results: results})
+
+	if best != 3 {
+		t.Errorf("calcElbow() best = %d, want 3, scores %v", best, scores)
+	}
+}
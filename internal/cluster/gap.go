@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/samber/lo"
+)
+
+// gapReferencesNum is the number of the reference datasets of the gap statistic.
+const gapReferencesNum = 10
+
+// calcGap scores the clusterings with the gap statistic: the difference between the expected log of the within-cluster
+// sum of squares (WCSS) of the reference data, uniformly distributed over the bounding box of the data and clustered
+// with the same params, and the log WCSS of the data. The reference points take the weights of the data points, so
+// that the WCSS of both are of the same total weight. The best is the clustering with the smallest number of clusters
+// k, whose gap is not less than the gap of the next evaluated k minus its standard error. Among the clusterings with
+// the same k the one with the highest gap is taken.
+func calcGap(ctx context.Context, e *evaluation) ([]float64, int, error) {
+	low, high := e.data.Bounds()
+
+	refLogWCSS := make([][]float64, gapReferencesNum)
+	for b := range refLogWCSS {
+		// the references are seeded by their index, so that the statistic is reproducible
+		rnd := rand.New(rand.NewSource(int64(b)))
+		ref := points.NewWithSize(e.data.Dim(), e.data.Len())
+		p := make([]float64, e.data.Dim())
+		for i := 0; i < e.data.Len(); i++ {
+			for j := range p {
+				p[j] = low[j] + rnd.Float64()*(high[j]-low[j])
+			}
+			ref.AddWeighted(p, e.data.Weight(i))
+		}
+
+		refResults, err := e.evaluate(ctx, ref)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		refLogWCSS[b] = make([]float64, len(e.results))
+		for i, res := range refResults {
+			refLogWCSS[b][i] = math.NaN()
+			if res != nil {
+				refLogWCSS[b][i] = logWCSS(ref, res.Labels)
+			}
+		}
+	}
+
+	gaps := make([]float64, len(e.results))
+	errs := make([]float64, len(e.results))
+	for i, res := range e.results {
+		gaps[i], errs[i] = math.NaN(), math.NaN()
+		if res == nil {
+			continue
+		}
+
+		mean, sq := 0.0, 0.0
+		for b := range refLogWCSS {
+			mean += refLogWCSS[b][i]
+		}
+		mean /= gapReferencesNum
+		for b := range refLogWCSS {
+			sq += (refLogWCSS[b][i] - mean) * (refLogWCSS[b][i] - mean)
+		}
+
+		gaps[i] = mean - logWCSS(e.data, res.Labels)
+		errs[i] = math.Sqrt(sq/gapReferencesNum) * math.Sqrt(1+1.0/gapReferencesNum)
+	}
+
+	// the best clustering for every number of clusters
+	bestByK := make(map[int]int)
+	for i, res := range e.results {
+		if res == nil || math.IsNaN(gaps[i]) {
+			continue
+		}
+
+		if best, ok := bestByK[res.ClustersNum]; !ok || isBetterScore(gaps[i], gaps[best]) {
+			bestByK[res.ClustersNum] = i
+		}
+	}
+
+	ks := lo.Keys(bestByK)
+	sort.Ints(ks)
+	for j := 0; j+1 < len(ks); j++ {
+		cur, next := bestByK[ks[j]], bestByK[ks[j+1]]
+		if gaps[cur] >= gaps[next]-errs[next] {
+			return gaps, cur, nil
+		}
+	}
+
+	return gaps, argmax(gaps), nil
+}
+
+// logWCSS returns the log of the within-cluster sum of squares, floored to keep the clusterings of equal points
+// finite.
+func logWCSS(data *points.Set, labels []int) float64 {
+	return math.Log(math.Max(calcWCSS(data, labels), minVariance(data)))
+}
@@ -0,0 +1,256 @@
+package gmm
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// defaultTolerance is the default relative increase of the log-likelihood the expectation maximization stops
+	// below.
+	defaultTolerance = 1e-6
+	// defaultMaxIterations is the default limit of the iterations of the expectation maximization.
+	defaultMaxIterations = 100
+)
+
+// GMM is the gaussian mixture model with diagonal covariances fitted by expectation maximization. Every point
+// belongs to every component with some probability, the label of the point is its most probable component.
+type GMM struct {
+	clustersNum   int
+	maxIterations int
+	tolerance     float64
+	params        params.Space
+	data          *points.Set
+	// minVariances are the lowest variances of the components by the coordinates, see points.Set.MinVariances.
+	minVariances []float64
+
+	// the model fitted by the last clustering
+	weights       []float64
+	means         *points.Set
+	variances     *points.Set
+	probabilities [][]float64
+	logLikelihood float64
+}
+
+func (g *GMM) Init(ctx context.Context, data *points.Set) error {
+	g.data = data
+	g.minVariances = data.MinVariances()
+	g.params = params.Space{
+		{Name: "k", Kind: params.Int, Min: 1, Max: float64(max(1, data.Len()/2)), Default: 1, Tunable: true},
+		{Name: "max-iterations", Kind: params.Int, Min: 1, Max: math.MaxInt32, Default: defaultMaxIterations},
+		{Name: "tolerance", Kind: params.Float, Min: 1e-12, Max: 1, Default: defaultTolerance, LogScale: true},
+	}
+
+	return g.SetParams(ctx, g.params.Defaults())
+}
+
+func (g *GMM) Params() params.Space {
+	return g.params
+}
+
+func (g *GMM) SetParams(ctx context.Context, values params.Values) error {
+	if err := g.params.Validate(values); err != nil {
+		return err
+	}
+
+	g.clustersNum = int(values[0])
+	g.maxIterations = int(values[1])
+	g.tolerance = values[2]
+
+	return nil
+}
+
+// Cluster fits the mixture and returns the means of the components and the most probable components of the points.
+func (g *GMM) Cluster(ctx context.Context) (*points.Set, []int) {
+	ctx, span := otel.Tracer("").Start(ctx, "GMM")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Tracef("fitting mixture of %d gaussians to %d points", g.clustersNum, g.data.Len())
+
+	g.initComponents()
+
+	g.probabilities = make([][]float64, g.data.Len())
+	for i := range g.probabilities {
+		g.probabilities[i] = make([]float64, g.clustersNum)
+	}
+
+	g.logLikelihood = math.Inf(-1)
+	for it := 0; it < g.maxIterations; it++ {
+		ll := g.expect()
+		g.maximize()
+
+		if ll-g.logLikelihood <= g.tolerance*math.Abs(ll) {
+			logger.MustFromContext(ctx).Tracef("converged after %d iterations", it+1)
+
+			g.logLikelihood = ll
+			break
+		}
+
+		g.logLikelihood = ll
+	}
+
+	// the probabilities and the likelihood match the final components
+	g.logLikelihood = g.expect()
+
+	labels := make([]int, g.data.Len())
+	for i, p := range g.probabilities {
+		for c := range p {
+			if p[c] > p[labels[i]] {
+				labels[i] = c
+			}
+		}
+	}
+
+	return g.means, labels
+}
+
+// Probabilities returns the probabilities of the points to belong to every component fitted by the last clustering.
+func (g *GMM) Probabilities(ctx context.Context) [][]float64 {
+	return g.probabilities
+}
+
+// LogLikelihood returns the log-likelihood of the data under the mixture fitted by the last clustering and the number
+// of the free parameters of the mixture.
+func (g *GMM) LogLikelihood(ctx context.Context) (float64, int) {
+	// k-1 weights and k means and variances of d coordinates
+	return g.logLikelihood, g.clustersNum - 1 + 2*g.clustersNum*g.data.Dim()
+}
+
+// initComponents places the components deterministically: the points are sorted by the first coordinate and split
+// into equal parts, the means of the parts are the initial means, the variances are the variances of the data.
+func (g *GMM) initComponents() {
+	n, k, dim := g.data.Len(), g.clustersNum, g.data.Dim()
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return g.data.At(order[i])[0] < g.data.At(order[j])[0] })
+
+	means := make([]float64, k*dim)
+	for c := 0; c < k; c++ {
+		from, to := c*n/k, (c+1)*n/k
+		for _, i := range order[from:to] {
+			for j, v := range g.data.At(i) {
+				means[c*dim+j] += v / float64(to-from)
+			}
+		}
+	}
+
+	total := make([]float64, dim)
+	sqTotal := make([]float64, dim)
+	for i := 0; i < n; i++ {
+		for j, v := range g.data.At(i) {
+			total[j] += v
+			sqTotal[j] += v * v
+		}
+	}
+
+	variances := make([]float64, k*dim)
+	for j := range total {
+		mean := total[j] / float64(n)
+		variance := math.Max(sqTotal[j]/float64(n)-mean*mean, g.minVariances[j])
+		for c := 0; c < k; c++ {
+			variances[c*dim+j] = variance
+		}
+	}
+
+	g.weights = make([]float64, k)
+	for c := range g.weights {
+		g.weights[c] = 1 / float64(k)
+	}
+	g.means, _ = points.FromFlat(dim, means)
+	g.variances, _ = points.FromFlat(dim, variances)
+}
+
+// expect updates the probabilities of the points to belong to the components and returns the log-likelihood.
+func (g *GMM) expect() float64 {
+	ll := 0.0
+	logDensities := make([]float64, g.clustersNum)
+	for i, p := range g.probabilities {
+		x := g.data.At(i)
+
+		maxLog := math.Inf(-1)
+		for c := range logDensities {
+			logDensities[c] = math.Log(g.weights[c]) + LogGaussian(x, g.means.At(c), g.variances.At(c))
+			maxLog = math.Max(maxLog, logDensities[c])
+		}
+
+		// log-sum-exp keeps the far points from underflowing
+		sum := 0.0
+		for c, l := range logDensities {
+			p[c] = math.Exp(l - maxLog)
+			sum += p[c]
+		}
+		for c := range p {
+			p[c] /= sum
+		}
+
+		ll += maxLog + math.Log(sum)
+	}
+
+	return ll
+}
+
+// maximize updates the components by the probabilities of the points.
+func (g *GMM) maximize() {
+	mean := make([]float64, g.data.Dim())
+	for c := range g.weights {
+		total := 0.0
+		for j := range mean {
+			mean[j] = 0
+		}
+		for i, p := range g.probabilities {
+			total += p[c]
+			for j, v := range g.data.At(i) {
+				mean[j] += p[c] * v
+			}
+		}
+
+		// the component nobody belongs to keeps its place
+		if total == 0 {
+			g.weights[c] = 0
+			continue
+		}
+
+		variance := g.variances.At(c)
+		for j := range mean {
+			mean[j] /= total
+			variance[j] = 0
+		}
+		for i, p := range g.probabilities {
+			for j, v := range g.data.At(i) {
+				variance[j] += p[c] * (v - mean[j]) * (v - mean[j])
+			}
+		}
+		for j := range variance {
+			variance[j] = math.Max(variance[j]/total, g.minVariances[j])
+		}
+
+		copy(g.means.At(c), mean)
+		g.weights[c] = total / float64(g.data.Len())
+	}
+}
+
+// Mixture returns the weights and the variances of the components fitted by the last clustering, the means are the
+// centroids returned by it.
+func (g *GMM) Mixture(ctx context.Context) ([]float64, *points.Set) {
+	return g.weights, g.variances
+}
+
+// LogGaussian returns the log-density of the gaussian of the mean and the diagonal covariance of the variances at x.
+func LogGaussian(x, mean, variance []float64) float64 {
+	l := 0.0
+	for j := range x {
+		d := x[j] - mean[j]
+		l -= 0.5 * (math.Log(2*math.Pi*variance[j]) + d*d/variance[j])
+	}
+
+	return l
+}
@@ -0,0 +1,88 @@
+package gmm
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+var ctx = logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+func TestGMM_Cluster(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []float64
+		clustersNum   int
+		wantLabels    []int
+		wantCentroids []float64
+		// wantProbabilities are the probabilities of the points to belong to the first component
+		wantProbabilities []float64
+	}{
+		{
+			name:              "single component",
+			data:              []float64{1, 2, 3},
+			clustersNum:       1,
+			wantLabels:        []int{0, 0, 0},
+			wantCentroids:     []float64{2},
+			wantProbabilities: []float64{1, 1, 1},
+		},
+		{
+			name:              "separated components",
+			data:              []float64{100, 1, 101, 2, 102, 3},
+			clustersNum:       2,
+			wantLabels:        []int{1, 0, 1, 0, 1, 0},
+			wantCentroids:     []float64{2, 101},
+			wantProbabilities: []float64{0, 1, 0, 1, 0, 1},
+		},
+		{
+			name:              "overlapping components",
+			data:              []float64{0, 1, 1, 2, 2, 2, 3, 3, 4, 6, 7, 7, 8, 8, 8, 9, 9, 10},
+			clustersNum:       2,
+			wantLabels:        []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+			wantCentroids:     []float64{2.0027, 7.9973},
+			wantProbabilities: []float64{1, 1, 1, 1, 1, 1, 0.9999, 0.9999, 0.9884, 0.0116, 0.0001, 0.0001, 0, 0, 0, 0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := new(GMM)
+			if err := g.Init(ctx, points.FromValues(tt.data)); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if err := g.SetParams(ctx, params.Values{float64(tt.clustersNum), defaultMaxIterations, defaultTolerance}); err != nil {
+				t.Fatalf("SetParams() error = %v", err)
+			}
+
+			centroids, labels := g.Cluster(ctx)
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("Cluster() labels = %v, want %v", labels, tt.wantLabels)
+			}
+			for i, c := range tt.wantCentroids {
+				if math.Abs(centroids.Flat()[i]-c) > 1e-3 {
+					t.Errorf("Cluster() centroids = %v, want %v", centroids.Flat(), tt.wantCentroids)
+				}
+			}
+			for i, p := range g.Probabilities(ctx) {
+				sum := 0.0
+				for _, v := range p {
+					sum += v
+				}
+				if math.Abs(sum-1) > 1e-9 {
+					t.Errorf("Probabilities() of point %v sum to %v, want 1", tt.data[i], sum)
+				}
+				if math.Abs(p[0]-tt.wantProbabilities[i]) > 1e-3 {
+					t.Errorf("Probabilities() of point %v = %v, want %v", tt.data[i], p[0], tt.wantProbabilities[i])
+				}
+			}
+
+			if ll, _ := g.LogLikelihood(ctx); math.IsNaN(ll) || math.IsInf(ll, 0) {
+				t.Errorf("LogLikelihood() = %v, want finite", ll)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package hierarchical
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// MaxPoints is the largest number of the points the agglomerative clusterer is initialized with, the distances
+// between them take 8·n²/2 bytes, 400 MB for MaxPoints. Init fails with points.ErrTooManyPoints for more points.
+const MaxPoints = 10000
+
+// Agglomerative is the hierarchical clusterer merging the closest clusters one by one starting from the single points.
+// The full tree of the merges is built once per linkage and is cut into the number of clusters set by the params.
+// It keeps the distances between all the points, so it takes O(n²) memory.
+type Agglomerative struct {
+	clustersNum int
+	linkage     Linkage
+	params      params.Space
+	metric      points.Metric
+	data        *points.Set
+	dendrograms *Dendrograms
+}
+
+// Dendrograms holds the trees of the merges of the data by the linkages, they could be shared by the clusterers of the
+// same data. Every tree is built once by the first clusterer needing it and is read only by all of them.
+type Dendrograms struct {
+	mu    sync.Mutex
+	trees map[Linkage]*lazyDendrogram
+}
+
+type lazyDendrogram struct {
+	once sync.Once
+	tree *Dendrogram
+}
+
+func (a *Agglomerative) Init(ctx context.Context, data *points.Set) error {
+	if data.Len() > MaxPoints {
+		return fmt.Errorf("agglomerative clustering of %d points, at most %d are supported: %w", data.Len(), MaxPoints, points.ErrTooManyPoints)
+	}
+
+	a.data = data
+	a.params = params.Space{
+		{Name: "k", Kind: params.Int, Min: 1, Max: float64(max(1, data.Len()/2)), Default: 1, Tunable: true},
+		{Name: "linkage", Kind: params.Choice, Choices: linkageNames(), Default: float64(SingleLinkage), Tunable: true},
+	}
+	a.dendrograms = &Dendrograms{trees: make(map[Linkage]*lazyDendrogram)}
+	if a.metric == nil {
+		a.metric = points.Euclidean
+	}
+
+	return a.SetParams(ctx, a.params.Defaults())
+}
+
+// Shared returns the trees of the merges of the data the clusterer is initialized with, the *Dendrograms.
+func (a *Agglomerative) Shared() any {
+	return a.dendrograms
+}
+
+// Share makes the clusterer use the trees of another agglomerative clusterer initialized with the same data and
+// metric instead of building its own ones, the clusterers sharing them are safe to use concurrently.
+func (a *Agglomerative) Share(shared any) {
+	a.dendrograms = shared.(*Dendrograms)
+}
+
+// SetMetric sets the distance between the points, Ward linkage is euclidean whatever the metric is.
+func (a *Agglomerative) SetMetric(metric points.Metric) {
+	a.metric = metric
+}
+
+func (a *Agglomerative) Params() params.Space {
+	return a.params
+}
+
+func (a *Agglomerative) SetParams(ctx context.Context, values params.Values) error {
+	if err := a.params.Validate(values); err != nil {
+		return err
+	}
+
+	a.clustersNum = int(values[0])
+	a.linkage = Linkage(values[1])
+
+	return nil
+}
+
+// Cluster returns the means and the labels of the clusters the tree of the merges is cut into.
+func (a *Agglomerative) Cluster(ctx context.Context) (*points.Set, []int) {
+	ctx, span := otel.Tracer("").Start(ctx, "Agglomerative")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Tracef("clustering %d points into %d clusters with %s linkage", a.data.Len(), a.clustersNum, a.linkage)
+
+	labels := a.Dendrogram(ctx).Cut(a.clustersNum)
+
+	k := 0
+	for _, l := range labels {
+		k = max(k, l+1)
+	}
+
+	sums := make([]float64, k*a.data.Dim())
+	counts := make([]int, k)
+	for i, l := range labels {
+		for j, v := range a.data.At(i) {
+			sums[l*a.data.Dim()+j] += v
+		}
+		counts[l]++
+	}
+	for i := range sums {
+		sums[i] /= float64(counts[i/a.data.Dim()])
+	}
+
+	centroids, _ := points.FromFlat(a.data.Dim(), sums)
+
+	return centroids, labels
+}
+
+// Dendrogram returns the tree of the merges for the current linkage.
+func (a *Agglomerative) Dendrogram(ctx context.Context) *Dendrogram {
+	a.dendrograms.mu.Lock()
+	d, ok := a.dendrograms.trees[a.linkage]
+	if !ok {
+		d = new(lazyDendrogram)
+		a.dendrograms.trees[a.linkage] = d
+	}
+	a.dendrograms.mu.Unlock()
+
+	d.once.Do(func() {
+		logger.MustFromContext(ctx).Tracef("building dendrogram of %d points with %s linkage", a.data.Len(), a.linkage)
+
+		d.tree = buildDendrogram(a.data, a.linkage, a.metric)
+	})
+
+	return d.tree
+}
+
+// buildDendrogram builds the tree of the merges of the points by the metric, Ward linkage by the squared euclidean
+// distance.
+func buildDendrogram(data *points.Set, linkage Linkage, metric points.Metric) *Dendrogram {
+	distance := metric.Distance
+	if linkage == WardLinkage {
+		distance = points.SquaredEuclidean
+	}
+
+	return NewDendrogram(data.Len(), func(i, j int) float64 { return distance(data.At(i), data.At(j)) }, linkage)
+}
+
+// NewDendrogram builds the tree of the merges of n points by the distances between them, the distances of Ward
+// linkage must be the squared euclidean ones. The clusters are merged with the nearest-neighbour chain algorithm: the
+// chain of the nearest neighbours is followed until two clusters are the nearest to each other, then they are merged.
+// All the linkages are reducible, so the merges are the same as of the naive algorithm merging the globally closest
+// pair, though found in the other order, so they are sorted by height afterwards.
+func NewDendrogram(n int, distance func(i, j int) float64, linkage Linkage) *Dendrogram {
+	if n == 0 {
+		return &Dendrogram{}
+	}
+
+	// dist holds the distances between the clusters by the indices of their first points, condensed to the upper
+	// triangle
+	dist := make([]float64, n*(n-1)/2)
+	idx := func(i, j int) int {
+		if i > j {
+			i, j = j, i
+		}
+		return n*i - i*(i+1)/2 + j - i - 1
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			dist[idx(i, j)] = distance(i, j)
+		}
+	}
+
+	active := make([]bool, n)
+	sizes := make([]int, n)
+	for i := range active {
+		active[i], sizes[i] = true, 1
+	}
+
+	// merges hold the clusters by any of their points until they are relabeled
+	merges := make([]Merge, 0, n-1)
+	chain := make([]int, 0, n)
+	for len(merges) < n-1 {
+		if len(chain) == 0 {
+			for i := range active {
+				if active[i] {
+					chain = append(chain, i)
+					break
+				}
+			}
+		}
+
+		var a, b int
+		for {
+			a = chain[len(chain)-1]
+
+			// the previous cluster of the chain wins the ties, so that the chain does not cycle
+			best := math.Inf(1)
+			b = -1
+			if len(chain) > 1 {
+				b = chain[len(chain)-2]
+				best = dist[idx(a, b)]
+			}
+			for k := range active {
+				if active[k] && k != a {
+					if d := dist[idx(a, k)]; d < best {
+						b, best = k, d
+					}
+				}
+			}
+
+			if len(chain) > 1 && b == chain[len(chain)-2] {
+				break
+			}
+
+			chain = append(chain, b)
+		}
+
+		chain = chain[:len(chain)-2]
+
+		// the merged cluster takes the place of the cluster with the lower index
+		if a > b {
+			a, b = b, a
+		}
+		dab := dist[idx(a, b)]
+		merges = append(merges, Merge{Left: a, Right: b, Height: dab})
+
+		for k := range active {
+			if active[k] && k != a && k != b {
+				dist[idx(a, k)] = linkage.update(dist[idx(a, k)], dist[idx(b, k)], dab, sizes[a], sizes[b], sizes[k])
+			}
+		}
+
+		active[b] = false
+		sizes[a] += sizes[b]
+	}
+
+	if linkage == WardLinkage {
+		for i := range merges {
+			merges[i].Height = math.Sqrt(math.Max(merges[i].Height, 0))
+		}
+	}
+
+	sort.SliceStable(merges, func(i, j int) bool { return merges[i].Height < merges[j].Height })
+
+	// relabel the clusters from their points to the ids of the merges
+	idByPoint := make([]int, n)
+	for i := range idByPoint {
+		idByPoint[i] = i
+	}
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	for i, m := range merges {
+		left, right := find(m.Left), find(m.Right)
+		merges[i].Left, merges[i].Right = idByPoint[left], idByPoint[right]
+
+		parent[right] = left
+		idByPoint[left] = n + i
+	}
+
+	d := &Dendrogram{leaves: n, merges: merges}
+	for i := range d.merges {
+		d.merges[i].Size = d.size(d.merges[i].Left) + d.size(d.merges[i].Right)
+	}
+
+	return d
+}
@@ -0,0 +1,216 @@
+package hierarchical
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+var ctx = logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+func TestAgglomerative_Cluster(t *testing.T) {
+	data := points.FromValues([]float64{1, 2, 3, 20, 21, 22, 60, 61, 100})
+	tests := []struct {
+		name          string
+		params        params.Values
+		wantCentroids []float64
+		wantLabels    []int
+	}{
+		{
+			name:          "single linkage",
+			params:        params.Values{3, float64(SingleLinkage)},
+			wantCentroids: []float64{11.5, 60.5, 100},
+			wantLabels:    []int{0, 0, 0, 0, 0, 0, 1, 1, 2},
+		},
+		{
+			name:          "complete linkage",
+			params:        params.Values{4, float64(CompleteLinkage)},
+			wantCentroids: []float64{2, 21, 60.5, 100},
+			wantLabels:    []int{0, 0, 0, 1, 1, 1, 2, 2, 3},
+		},
+		{
+			name:          "ward linkage",
+			params:        params.Values{2, float64(WardLinkage)},
+			wantCentroids: []float64{11.5, 73.66666666666667},
+			wantLabels:    []int{0, 0, 0, 0, 0, 0, 1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := new(Agglomerative)
+			if err := a.Init(ctx, data); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if err := a.SetParams(ctx, tt.params); err != nil {
+				t.Fatalf("SetParams() error = %v", err)
+			}
+
+			centroids, labels := a.Cluster(ctx)
+			if !reflect.DeepEqual(centroids.Flat(), tt.wantCentroids) {
+				t.Errorf("Cluster() centroids = %v, want %v", centroids.Flat(), tt.wantCentroids)
+			}
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("Cluster() labels = %v, want %v", labels, tt.wantLabels)
+			}
+		})
+	}
+}
+
+func TestAgglomerative_Share(t *testing.T) {
+	data := points.FromValues([]float64{1, 2, 3, 20, 21, 22, 60, 61, 100})
+
+	clusterers := make([]*Agglomerative, 4)
+	for i := range clusterers {
+		clusterers[i] = new(Agglomerative)
+		if err := clusterers[i].Init(ctx, data); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		if err := clusterers[i].SetParams(ctx, params.Values{float64(i + 1), float64(AverageLinkage)}); err != nil {
+			t.Fatalf("SetParams() error = %v", err)
+		}
+		if i > 0 {
+			clusterers[i].Share(clusterers[0].Shared())
+		}
+	}
+
+	trees := make([]*Dendrogram, len(clusterers))
+	wg := sync.WaitGroup{}
+	for i, a := range clusterers {
+		wg.Add(1)
+		go func(i int, a *Agglomerative) {
+			defer wg.Done()
+
+			a.Cluster(ctx)
+			trees[i] = a.Dendrogram(ctx)
+		}(i, a)
+	}
+	wg.Wait()
+
+	for i, tree := range trees {
+		if tree != trees[0] {
+			t.Errorf("Dendrogram() of clusterer %d is built again", i)
+		}
+	}
+}
+
+func TestAgglomerative_Init_tooManyPoints(t *testing.T) {
+	if err := new(Agglomerative).Init(ctx, points.FromValues(make([]float64, MaxPoints+1))); err == nil {
+		t.Error("Init() error = nil, want the number of the points rejected")
+	}
+}
+
+func Test_buildDendrogram(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for it := 0; it < 20; it++ {
+		flat := make([]float64, 2*(2+rnd.Intn(15)))
+		for i := range flat {
+			flat[i] = rnd.Float64() * 100
+		}
+		data, _ := points.FromFlat(2, flat)
+
+		for _, linkage := range []Linkage{SingleLinkage, CompleteLinkage, AverageLinkage, WardLinkage} {
+			t.Run(linkage.String(), func(t *testing.T) {
+				d := buildDendrogram(data, linkage, points.Euclidean)
+				want := naiveHeights(data, linkage)
+				if len(d.Merges()) != len(want) {
+					t.Fatalf("buildDendrogram() merges = %d, want %d", len(d.Merges()), len(want))
+				}
+
+				for i, m := range d.Merges() {
+					if math.Abs(m.Height-want[i]) > 1e-9 {
+						t.Fatalf("buildDendrogram() merge %d height = %v, want %v", i, m.Height, want[i])
+					}
+				}
+
+				if root := d.Merges()[len(d.Merges())-1]; root.Size != data.Len() {
+					t.Errorf("buildDendrogram() root size = %d, want %d", root.Size, data.Len())
+				}
+			})
+		}
+	}
+}
+
+// naiveHeights merges the globally closest clusters one by one computing the linkages from the points.
+func naiveHeights(data *points.Set, linkage Linkage) []float64 {
+	clusters := make([][]int, data.Len())
+	for i := range clusters {
+		clusters[i] = []int{i}
+	}
+
+	var heights []float64
+	for len(clusters) > 1 {
+		bi, bj, best := 0, 0, math.Inf(1)
+		for i := range clusters {
+			for j := i + 1; j < len(clusters); j++ {
+				if d := naiveLinkage(data, clusters[i], clusters[j], linkage); d < best {
+					bi, bj, best = i, j, d
+				}
+			}
+		}
+
+		heights = append(heights, best)
+		clusters[bi] = append(clusters[bi], clusters[bj]...)
+		clusters = append(clusters[:bj], clusters[bj+1:]...)
+	}
+
+	return heights
+}
+
+func naiveLinkage(data *points.Set, a, b []int, linkage Linkage) float64 {
+	switch linkage {
+	case SingleLinkage, CompleteLinkage, AverageLinkage:
+		res := 0.0
+		if linkage == SingleLinkage {
+			res = math.Inf(1)
+		}
+		for _, i := range a {
+			for _, j := range b {
+				d := points.Euclidean.Distance(data.At(i), data.At(j))
+				switch linkage {
+				case SingleLinkage:
+					res = math.Min(res, d)
+				case CompleteLinkage:
+					res = math.Max(res, d)
+				default:
+					res += d / float64(len(a)*len(b))
+				}
+			}
+		}
+		return res
+	default:
+		// the ward height is the square root of the doubled increase of the sum of squares
+		mean := func(c []int) []float64 {
+			m := make([]float64, data.Dim())
+			for _, i := range c {
+				for j, v := range data.At(i) {
+					m[j] += v / float64(len(c))
+				}
+			}
+			return m
+		}
+		na, nb := float64(len(a)), float64(len(b))
+		return math.Sqrt(2 * na * nb / (na + nb) * points.SquaredEuclidean(mean(a), mean(b)))
+	}
+}
+
+func TestDendrogram_WriteNewick(t *testing.T) {
+	d := buildDendrogram(points.FromValues([]float64{0, 1, 5}), SingleLinkage, points.Euclidean)
+
+	b := bytes.Buffer{}
+	if err := d.WriteNewick(&b, func(leaf int) string { return "p" + strconv.Itoa(leaf) }, "root"); err != nil {
+		t.Fatalf("WriteNewick() error = %v", err)
+	}
+
+	if want := "((p0:1,p1:1):3,p2:4)root;\n"; b.String() != want {
+		t.Errorf("WriteNewick() = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,162 @@
+package hierarchical
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Merge is the merge of two clusters. The clusters are identified as in SciPy linkage matrices: the ids less than the
+// number of the leaves are the leaves, i.e. the points, the id leaves+i is the cluster made by the i-th merge.
+type Merge struct {
+	Left   int
+	Right  int
+	Height float64
+	// Size is the number of the leaves of the merged cluster.
+	Size int
+}
+
+// Dendrogram is the tree of the merges of the agglomerative clustering in the ascending order of their heights.
+type Dendrogram struct {
+	leaves int
+	merges []Merge
+}
+
+// Leaves returns the number of the clustered points.
+func (d *Dendrogram) Leaves() int {
+	return d.leaves
+}
+
+func (d *Dendrogram) Merges() []Merge {
+	return d.merges
+}
+
+// Cut returns the labels of the points split into k clusters by undoing the k-1 highest merges. The clusters are
+// labeled in the order of their first points.
+func (d *Dendrogram) Cut(k int) []int {
+	k = max(1, min(k, d.leaves))
+
+	parent := make([]int, d.leaves)
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	// any leaf of the cluster stands for it
+	leafOf := make([]int, d.leaves+len(d.merges))
+	for i := 0; i < d.leaves; i++ {
+		leafOf[i] = i
+	}
+	for i, m := range d.merges {
+		leafOf[d.leaves+i] = leafOf[m.Left]
+		if i < d.leaves-k {
+			parent[find(leafOf[m.Right])] = find(leafOf[m.Left])
+		}
+	}
+
+	labels := make([]int, d.leaves)
+	labelByRoot := make(map[int]int)
+	for i := range labels {
+		root := find(i)
+		label, ok := labelByRoot[root]
+		if !ok {
+			label = len(labelByRoot)
+			labelByRoot[root] = label
+		}
+
+		labels[i] = label
+	}
+
+	return labels
+}
+
+// Root returns the id of the root cluster.
+func (d *Dendrogram) Root() int {
+	return d.leaves + len(d.merges) - 1
+}
+
+// Height returns the height of the cluster, zero for the leaves.
+func (d *Dendrogram) Height(id int) float64 {
+	if id < d.leaves {
+		return 0
+	}
+
+	return d.merges[id-d.leaves].Height
+}
+
+// WriteNewick writes the tree in the Newick format labeling the leaves with leafLabel and the root with rootLabel.
+// Branch lengths are the differences of the heights of the clusters.
+func (d *Dendrogram) WriteNewick(w io.Writer, leafLabel func(leaf int) string, rootLabel string) error {
+	if d.leaves == 0 {
+		_, err := fmt.Fprintf(w, "%s;\n", newickLabel(rootLabel))
+		return err
+	}
+
+	var write func(id int) error
+	write = func(id int) error {
+		if id < d.leaves {
+			_, err := io.WriteString(w, newickLabel(leafLabel(id)))
+			return err
+		}
+
+		m := d.merges[id-d.leaves]
+		if _, err := io.WriteString(w, "("); err != nil {
+			return err
+		}
+		for i, child := range []int{m.Left, m.Right} {
+			if i > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+
+			if err := write(child); err != nil {
+				return err
+			}
+
+			length := strconv.FormatFloat(m.Height-d.Height(child), 'g', -1, 64)
+			if _, err := io.WriteString(w, ":"+length); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, ")")
+		return err
+	}
+
+	if err := write(d.Root()); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%s;\n", newickLabel(rootLabel))
+	return err
+}
+
+// newickLabel quotes the label if it has the symbols reserved by the Newick format.
+func newickLabel(s string) string {
+	for _, r := range s {
+		switch r {
+		case '(', ')', '[', ']', ',', ':', ';', '\'', ' ', '\t', '\n':
+			return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+		}
+	}
+
+	return s
+}
+
+// size returns the number of the leaves of the cluster.
+func (d *Dendrogram) size(id int) int {
+	if id < d.leaves {
+		return 1
+	}
+
+	return d.merges[id-d.leaves].Size
+}
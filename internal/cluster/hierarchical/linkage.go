@@ -0,0 +1,58 @@
+package hierarchical
+
+// Linkage defines the distance between the clusters by the distances between their points.
+type Linkage int
+
+const (
+	// SingleLinkage is the distance between the closest points of the clusters.
+	SingleLinkage Linkage = iota
+	// CompleteLinkage is the distance between the farthest points of the clusters.
+	CompleteLinkage
+	// AverageLinkage is the average distance between the points of the clusters.
+	AverageLinkage
+	// WardLinkage is the increase of the within-cluster sum of squares caused by the merge, it is euclidean whatever
+	// the metric is.
+	WardLinkage
+)
+
+// Linkages lists all the linkages.
+var Linkages = []Linkage{SingleLinkage, CompleteLinkage, AverageLinkage, WardLinkage}
+
+func (l Linkage) String() string {
+	switch l {
+	case SingleLinkage:
+		return "single"
+	case CompleteLinkage:
+		return "complete"
+	case AverageLinkage:
+		return "average"
+	case WardLinkage:
+		return "ward"
+	}
+	return "unknown"
+}
+
+func linkageNames() []string {
+	names := make([]string, len(Linkages))
+	for i, l := range Linkages {
+		names[i] = l.String()
+	}
+
+	return names
+}
+
+// update returns the distance from the merge of the clusters i and j to the cluster k by the Lance-Williams formula.
+// Ward distances are squared.
+func (l Linkage) update(dik, djk, dij float64, ni, nj, nk int) float64 {
+	switch l {
+	case SingleLinkage:
+		return min(dik, djk)
+	case CompleteLinkage:
+		return max(dik, djk)
+	case AverageLinkage:
+		return (float64(ni)*dik + float64(nj)*djk) / float64(ni+nj)
+	case WardLinkage:
+		return (float64(ni+nk)*dik + float64(nj+nk)*djk - float64(nk)*dij) / float64(ni+nj+nk)
+	}
+	return 0
+}
@@ -1,8 +1,12 @@
 package kmeans
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/registry"
 )
 
 type CentroidsIniterType int
@@ -12,77 +16,133 @@ const (
 	PlusPlusCentroidsIniter
 )
 
+// CentroidsIniter selects k initial centroids from the data drawing the random numbers from rnd only, so that the
+// initialization is reproducible.
+type CentroidsIniter func(data *points.Set, k int, metric points.Metric, rnd *rand.Rand) *points.Set
+
+// CentroidsIniterInfo describes the registered centroids initer.
+type CentroidsIniterInfo struct {
+	Type        CentroidsIniterType
+	Name        string
+	Description string
+	Initer      CentroidsIniter
+}
+
+// centroidsIniters holds the registered centroids initers, the ids are their types.
+var centroidsIniters registry.Registry[CentroidsIniterInfo]
+
+func init() {
+	mustRegisterCentroidsIniter(RandomCentroidsIniter, "random", "k distinct random points", initializeCentroidsRandom)
+	mustRegisterCentroidsIniter(PlusPlusCentroidsIniter, "plusplus", "k-means++: every next point is drawn with the probability proportional to the squared distance to the nearest chosen one", initializeCentroidsKMeansPlusPlus)
+}
+
+// RegisterCentroidsIniter registers the centroids initer by its name and returns its type, the initer becomes one of
+// the choices of the initer param of the k-means clusterers initialized afterwards. It fails if the name is already
+// registered.
+func RegisterCentroidsIniter(name, description string, initer CentroidsIniter) (CentroidsIniterType, error) {
+	id, err := centroidsIniters.Register(name, CentroidsIniterInfo{Name: name, Description: description, Initer: initer})
+	if err != nil {
+		return 0, fmt.Errorf("register centroids initer: %w", err)
+	}
+
+	return CentroidsIniterType(id), nil
+}
+
+func mustRegisterCentroidsIniter(t CentroidsIniterType, name, description string, initer CentroidsIniter) {
+	if registered, err := RegisterCentroidsIniter(name, description, initer); err != nil || registered != t {
+		panic(fmt.Sprintf("register built-in centroids initer %s: %v", name, err))
+	}
+}
+
+// CentroidsIniters returns all the registered centroids initers.
+func CentroidsIniters() []CentroidsIniterInfo {
+	infos := centroidsIniters.Entries()
+	for i := range infos {
+		infos[i].Type = CentroidsIniterType(i)
+	}
+
+	return infos
+}
+
 func (t CentroidsIniterType) String() string {
-	switch t {
-	case RandomCentroidsIniter:
-		return "random"
-	case PlusPlusCentroidsIniter:
-		return "plusplus"
+	if name := centroidsIniters.Name(int(t)); name != "" {
+		return name
 	}
 	return "unknown"
 }
 
-type centroidsIniter func(data []float64, k int) []float64
+func centroidsIniterNames() []string {
+	return centroidsIniters.Names()
+}
 
-func getCentroidsIniter(t CentroidsIniterType) centroidsIniter {
-	switch t {
-	case RandomCentroidsIniter:
-		return initializeCentroidsRandom
-	case PlusPlusCentroidsIniter:
-		return initializeCentroidsKMeansPlusPlus
+func getCentroidsIniter(t CentroidsIniterType) CentroidsIniter {
+	info, ok := centroidsIniters.Get(int(t))
+	if !ok {
+		return nil
 	}
-	return nil
+	return info.Initer
 }
 
 // initializeCentroidsRandom selects k unique random points from the data as the initial centroids.
-func initializeCentroidsRandom(data []float64, k int) []float64 {
-	centroids := make([]float64, k)
-	perm := rand.Perm(len(data))
+func initializeCentroidsRandom(data *points.Set, k int, _ points.Metric, rnd *rand.Rand) *points.Set {
+	centroids := points.NewWithSize(data.Dim(), k)
+	perm := rnd.Perm(data.Len())
 	for i := 0; i < k; i++ {
-		centroids[i] = data[perm[i]]
+		centroids.Add(data.At(perm[i]))
 	}
 	return centroids
 }
 
-// initializeCentroidsKMeansPlusPlus selects k unique centroids using the k-means++ algorithm.
-func initializeCentroidsKMeansPlusPlus(data []float64, k int) []float64 {
-	if len(data) == 0 || k <= 0 {
+// initializeCentroidsKMeansPlusPlus selects k unique centroids using the k-means++ algorithm. The probabilities of the
+// weighted points are proportional to their weights.
+func initializeCentroidsKMeansPlusPlus(data *points.Set, k int, metric points.Metric, rnd *rand.Rand) *points.Set {
+	if data.Len() == 0 || k <= 0 {
 		return nil // handle edge cases
 	}
 
-	centroids := make([]float64, 0, k)
+	centroids := points.NewWithSize(data.Dim(), k)
 	// randomly select the first centroid from the data points.
-	firstCentroidIndex := rand.Intn(len(data))
-	centroids = append(centroids, data[firstCentroidIndex])
+	firstCentroidIndex := rnd.Intn(data.Len())
+	if data.Weighted() {
+		firstCentroidIndex = drawWeighted(data.Weights(), data.TotalWeight(), rnd)
+	}
+	centroids.Add(data.At(firstCentroidIndex))
 
 	// repeat until we have k centroids
-	for len(centroids) < k {
-		distances := make([]float64, len(data))
+	for centroids.Len() < k {
+		distances := make([]float64, data.Len())
 		totalDistance := 0.0
 
 		// for each data point, compute the distance to the nearest centroid
-		for i, point := range data {
+		for i := range distances {
 			minDist := math.Inf(1)
-			for _, centroid := range centroids {
-				dist := math.Abs(point - centroid)
+			for j := 0; j < centroids.Len(); j++ {
+				dist := metric.Distance(data.At(i), centroids.At(j))
 				if dist < minDist {
 					minDist = dist
 				}
 			}
-			distances[i] = minDist * minDist // square the distance to increase probability for farther points
+			distances[i] = data.Weight(i) * minDist * minDist // square the distance to increase probability for farther points
 			totalDistance += distances[i]
 		}
 
 		// select the next centroid
-		randomPoint := rand.Float64() * totalDistance
-		for i, d := range distances {
-			randomPoint -= d
-			if randomPoint <= 0 {
-				centroids = append(centroids, data[i])
-				break
-			}
-		}
+		centroids.Add(data.At(drawWeighted(distances, totalDistance, rnd)))
 	}
 
 	return centroids
 }
+
+// drawWeighted returns the random index drawn with the probability proportional to its weight, the total is the sum of
+// the weights.
+func drawWeighted(weights []float64, total float64, rnd *rand.Rand) int {
+	r := rnd.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r <= 0 {
+			return i
+		}
+	}
+
+	return len(weights) - 1
+}
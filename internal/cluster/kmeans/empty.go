@@ -0,0 +1,107 @@
+package kmeans
+
+import (
+	"github.com/boson-research/patterns/internal/cluster/points"
+)
+
+// EmptyClusterStrategy defines what happens to the centroid no points are assigned to.
+type EmptyClusterStrategy int
+
+const (
+	// ReseedEmptyCluster moves the centroid to the point farthest from its own centroid.
+	ReseedEmptyCluster EmptyClusterStrategy = iota
+	// DropEmptyCluster removes the centroid, so the clustering could have fewer clusters than requested.
+	DropEmptyCluster
+	// SplitLargestCluster moves the centroid to the point of the largest cluster farthest from its centroid, so that
+	// the largest cluster is split in two.
+	SplitLargestCluster
+)
+
+// EmptyClusterStrategies lists all the empty cluster strategies.
+var EmptyClusterStrategies = []EmptyClusterStrategy{ReseedEmptyCluster, DropEmptyCluster, SplitLargestCluster}
+
+func (s EmptyClusterStrategy) String() string {
+	switch s {
+	case ReseedEmptyCluster:
+		return "reseed"
+	case DropEmptyCluster:
+		return "drop"
+	case SplitLargestCluster:
+		return "split"
+	}
+	return "unknown"
+}
+
+func emptyClusterStrategyNames() []string {
+	names := make([]string, len(EmptyClusterStrategies))
+	for i, s := range EmptyClusterStrategies {
+		names[i] = s.String()
+	}
+
+	return names
+}
+
+// fixEmptyClusters applies the strategy to the centroids of the clusters no points are assigned to by the labels, the
+// counts are the sizes of the clusters, both the centroids and the counts are modified. The points the centroids are
+// moved to are chosen deterministically.
+func fixEmptyClusters(s EmptyClusterStrategy, data *points.Set, labels []int, centroids *points.Set, counts []int, metric points.Metric) *points.Set {
+	var empty []int
+	for c, count := range counts {
+		if count == 0 {
+			empty = append(empty, c)
+		}
+	}
+	if len(empty) == 0 {
+		return centroids
+	}
+
+	if s == DropEmptyCluster {
+		kept := make([]int, 0, centroids.Len()-len(empty))
+		for c, count := range counts {
+			if count > 0 {
+				kept = append(kept, c)
+			}
+		}
+
+		return centroids.Subset(kept)
+	}
+
+	// distances of the points to their centroids, the points taken as new centroids are not taken again
+	distances := make([]float64, data.Len())
+	for i, l := range labels {
+		distances[i] = metric.Distance(data.At(i), centroids.At(l))
+	}
+
+	flat := centroids.Flat()
+	for _, c := range empty {
+		from := -1
+		if s == SplitLargestCluster {
+			from = 0
+			for other, count := range counts {
+				if count > counts[from] {
+					from = other
+				}
+			}
+		}
+
+		farthest := -1
+		for i, d := range distances {
+			if (from < 0 || labels[i] == from) && (farthest < 0 || d > distances[farthest]) {
+				farthest = i
+			}
+		}
+		if farthest < 0 {
+			continue
+		}
+
+		copy(flat[c*data.Dim():(c+1)*data.Dim()], data.At(farthest))
+		counts[c] = 1
+		distances[farthest] = -1
+		if from >= 0 {
+			counts[c] = max(1, counts[from]/2)
+			counts[from] -= counts[c]
+		}
+	}
+
+	return centroids
+}
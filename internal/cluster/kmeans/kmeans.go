@@ -2,66 +2,139 @@ package kmeans
 
 import (
 	"context"
-	"fmt"
 	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
 
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
 	"github.com/boson-research/patterns/internal/telemetry/logger"
 	"go.opentelemetry.io/otel"
 )
 
+const (
+	// defaultMaxIterations is the default limit of the iterations of the centroids update.
+	defaultMaxIterations = 100
+	// maxRestarts is the upper bound of the number of the runs from different initial centroids.
+	maxRestarts = 1000
+)
+
 type KMeans struct {
 	clustersNum         int
 	maxIterations       int
-	clustersNumBounds   [2]int
 	centroidsIniterType CentroidsIniterType
-	data                []float64
+	restarts            int
+	empty               EmptyClusterStrategy
+	seed                int64
+	params              params.Space
+	// medians places the centroids at the coordinate-wise medians of the clusters instead of the means.
+	medians bool
+	// metric is the distance the points are assigned to the nearest centroids by, euclidean if not set.
+	metric points.Metric
+	data   *points.Set
 }
 
-func (k *KMeans) Init(ctx context.Context, data []float64) {
+func (k *KMeans) Init(ctx context.Context, data *points.Set) error {
 	k.data = data
-	k.centroidsIniterType = RandomCentroidsIniter
-	k.clustersNumBounds = [2]int{1, len(data) / 2}
-	k.maxIterations = 100
-}
+	k.params = params.Space{
+		{Name: "k", Kind: params.Int, Min: 1, Max: float64(max(1, data.Len()/2)), Default: 1, Tunable: true},
+		{Name: "initer", Kind: params.Choice, Choices: centroidsIniterNames(), Default: float64(RandomCentroidsIniter), Tunable: true},
+		{Name: "max-iterations", Kind: params.Int, Min: 1, Max: math.MaxInt32, Default: defaultMaxIterations},
+		{Name: "restarts", Kind: params.Int, Min: 1, Max: maxRestarts, Default: 1},
+		{Name: "empty", Kind: params.Choice, Choices: emptyClusterStrategyNames(), Default: float64(ReseedEmptyCluster)},
+	}
+	if k.metric == nil {
+		k.metric = points.Euclidean
+	}
 
-func (k *KMeans) GetOptimizationParams(ctx context.Context) []int {
-	return []int{k.clustersNum, int(k.centroidsIniterType)}
+	return k.SetParams(ctx, k.params.Defaults())
 }
 
-func (k *KMeans) SetOptimizationParams(ctx context.Context, params []int) error {
-	if err := k.ValidateOptimizationParams(params); err != nil {
-		return err
-	}
+// SetMetric sets the distance the points are assigned to the nearest centroids by. The centroids are the means (the
+// medians for k-medians) of the clusters whatever the metric is.
+func (k *KMeans) SetMetric(metric points.Metric) {
+	k.metric = metric
+}
 
-	k.clustersNum = params[0]
-	k.maxIterations = params[1]
+// SetSeed sets the seed the random numbers of the initialization of the centroids are derived from.
+func (k *KMeans) SetSeed(seed int64) {
+	k.seed = seed
+}
 
-	return nil
+func (k *KMeans) Params() params.Space {
+	return k.params
 }
 
-func (k *KMeans) ValidateOptimizationParams(params []int) error {
-	if params[0] < k.clustersNumBounds[0] || params[0] > k.clustersNumBounds[1] {
-		return fmt.Errorf("number of clusters must be between %d and %d", k.clustersNumBounds[0], k.clustersNumBounds[1])
+func (k *KMeans) SetParams(ctx context.Context, values params.Values) error {
+	if err := k.params.Validate(values); err != nil {
+		return err
 	}
 
-	if CentroidsIniterType(params[1]).String() == "unknown" {
-		return fmt.Errorf("unknown centroids initer type")
-	}
+	k.clustersNum = int(values[0])
+	k.centroidsIniterType = CentroidsIniterType(values[1])
+	k.maxIterations = int(values[2])
+	k.restarts = int(values[3])
+	k.empty = EmptyClusterStrategy(values[4])
 
 	return nil
 }
 
-func (k *KMeans) Cluster(ctx context.Context) ([]float64, []int) {
+// Cluster runs k-means from every restart and returns the clustering with the lowest inertia, the weighted sum of the
+// squared distances from the points to their centroids (of the plain distances for k-medians). Every restart draws the random
+// numbers from its own stream derived from the seed, so the restarts run concurrently and the result does not depend
+// on their scheduling.
+func (k *KMeans) Cluster(ctx context.Context) (*points.Set, []int) {
 	ctx, span := otel.Tracer("").Start(ctx, "KMeans")
 	defer span.End()
 
-	logger.MustFromContext(ctx).Tracef("clustering %d points into %d clusters", len(k.data), k.clustersNum)
+	logger.MustFromContext(ctx).Tracef("clustering %d points into %d clusters with %d restarts", k.data.Len(), k.clustersNum, k.restarts)
+
+	centroids := make([]*points.Set, k.restarts)
+	labels := make([][]int, k.restarts)
+	inertias := make([]float64, k.restarts)
+
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	wg := sync.WaitGroup{}
+	for r := 0; r < k.restarts; r++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			centroids[r], labels[r] = k.run(ctx, rand.New(restartSource(k.seed, r)))
+			inertias[r] = inertia(k.data, centroids[r], labels[r], k.metric, !k.medians)
+		}(r)
+	}
+	wg.Wait()
 
-	centroids := getCentroidsIniter(k.centroidsIniterType)(k.data, k.clustersNum)
+	// the first of the equal inertias wins
+	best := 0
+	for r, in := range inertias {
+		if in < inertias[best] {
+			best = r
+		}
+	}
+
+	logger.MustFromContext(ctx).Tracef("restart %d has the lowest inertia %.2f", best, inertias[best])
+
+	return centroids[best], labels[best]
+}
+
+// run runs k-means once from the centroids initialized with the random numbers of rnd.
+func (k *KMeans) run(ctx context.Context, rnd *rand.Rand) (*points.Set, []int) {
+	centroids := getCentroidsIniter(k.centroidsIniterType)(k.data, k.clustersNum, k.metric, rnd)
 	for i := 0; i < k.maxIterations; i++ {
-		labels := assignPointsToCentroids(k.data, centroids)
-		newCentroids := updateCentroids(k.data, labels, k.clustersNum)
-		if checkConvergence(centroids, newCentroids, 1e-5) {
+		labels := assignPointsToCentroids(k.data, centroids, k.metric)
+		update := updateCentroids
+		if k.medians {
+			update = updateMedians
+		}
+		newCentroids, counts := update(k.data, labels, centroids)
+		newCentroids = fixEmptyClusters(k.empty, k.data, labels, newCentroids, counts, k.metric)
+		if newCentroids.Len() == centroids.Len() && checkConvergence(centroids, newCentroids, 1e-5) {
 			logger.MustFromContext(ctx).Tracef("converged after %d iterations", i+1)
 
 			return newCentroids, labels
@@ -70,16 +143,39 @@ func (k *KMeans) Cluster(ctx context.Context) ([]float64, []int) {
 		centroids = newCentroids
 	}
 
-	return centroids, assignPointsToCentroids(k.data, centroids)
+	return centroids, assignPointsToCentroids(k.data, centroids, k.metric)
+}
+
+// inertia returns the weighted sum of the distances from the points to their centroids, squared if squared is set.
+func inertia(data *points.Set, centroids *points.Set, labels []int, metric points.Metric, squared bool) float64 {
+	sum := 0.0
+	for i, l := range labels {
+		d := metric.Distance(data.At(i), centroids.At(l))
+		if squared {
+			d *= d
+		}
+		sum += data.Weight(i) * d
+	}
+
+	return sum
+}
+
+// restartSource returns the source of the random numbers of the restart. The seed and the restart are mixed by
+// splitmix64, so that the streams of the neighbouring seeds do not repeat each other shifted by a restart.
+func restartSource(seed int64, restart int) rand.Source {
+	z := uint64(seed) + uint64(restart+1)*0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return rand.NewSource(int64(z ^ z>>31))
 }
 
 // assignPointsToCentroids assigns each data point to the nearest centroid and returns the labels.
-func assignPointsToCentroids(data []float64, centroids []float64) []int {
-	labels := make([]int, len(data))
-	for i, point := range data {
+func assignPointsToCentroids(data *points.Set, centroids *points.Set, metric points.Metric) []int {
+	labels := make([]int, data.Len())
+	for i := range labels {
 		minDist := math.MaxFloat64
-		for j, centroid := range centroids {
-			dist := math.Abs(point - centroid)
+		for j := 0; j < centroids.Len(); j++ {
+			dist := metric.Distance(data.At(i), centroids.At(j))
 			if dist < minDist {
 				minDist = dist
 				labels[i] = j
@@ -89,26 +185,110 @@ func assignPointsToCentroids(data []float64, centroids []float64) []int {
 	return labels
 }
 
-// updateCentroids recalculates the centroids based on the assigned points.
-func updateCentroids(data []float64, labels []int, k int) []float64 {
-	sums := make([]float64, k)
-	counts := make([]int, k)
+// updateCentroids returns the centroids moved to the weighted means of the assigned points and the sizes of the
+// clusters. The centroids of the empty clusters and of the clusters of zero weight are left where they were.
+func updateCentroids(data *points.Set, labels []int, centroids *points.Set) (*points.Set, []int) {
+	sums := make([]float64, centroids.Len()*data.Dim())
+	weights := make([]float64, centroids.Len())
+	counts := make([]int, centroids.Len())
 	for i, label := range labels {
-		sums[label] += data[i]
+		w := data.Weight(i)
+		for j, v := range data.At(i) {
+			sums[label*data.Dim()+j] += w * v
+		}
+		weights[label] += w
 		counts[label]++
 	}
+
+	flat := append([]float64(nil), centroids.Flat()...)
 	for i := range sums {
-		if counts[i] > 0 { // avoid division by zero.
-			sums[i] /= float64(counts[i])
+		if weights[i/data.Dim()] > 0 {
+			flat[i] = sums[i] / weights[i/data.Dim()]
+		}
+	}
+
+	updated, _ := points.FromFlat(data.Dim(), flat)
+	return updated, counts
+}
+
+// updateMedians returns the centroids moved to the coordinate-wise weighted medians of the assigned points and the
+// sizes of the clusters. The centroids of the empty clusters and of the clusters of zero weight are left where they
+// were.
+func updateMedians(data *points.Set, labels []int, centroids *points.Set) (*points.Set, []int) {
+	members := make([][]int, centroids.Len())
+	for i, label := range labels {
+		members[label] = append(members[label], i)
+	}
+
+	counts := make([]int, centroids.Len())
+	flat := append([]float64(nil), centroids.Flat()...)
+	values := make([]weightedValue, 0, data.Len())
+	for c, m := range members {
+		counts[c] = len(m)
+		if len(m) == 0 {
+			continue
+		}
+
+		for j := 0; j < data.Dim(); j++ {
+			values = values[:0]
+			for _, i := range m {
+				values = append(values, weightedValue{value: data.At(i)[j], weight: data.Weight(i)})
+			}
+
+			if median, ok := weightedMedian(values); ok {
+				flat[c*data.Dim()+j] = median
+			}
+		}
+	}
+
+	updated, _ := points.FromFlat(data.Dim(), flat)
+	return updated, counts
+}
+
+type weightedValue struct {
+	value, weight float64
+}
+
+// weightedMedian returns the value the weights below and above which are at most the half of the total weight, the
+// midpoint of the two values if the weight splits between them exactly, as the plain median of the equal weights. The
+// values are sorted in place, ok is false if the total weight is zero.
+func weightedMedian(values []weightedValue) (float64, bool) {
+	sort.Slice(values, func(a, b int) bool { return values[a].value < values[b].value })
+
+	total := 0.0
+	for _, v := range values {
+		total += v.weight
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	below := 0.0
+	for i, v := range values {
+		below += v.weight
+		if below*2 < total {
+			continue
 		}
+		if below*2 > total || i+1 == len(values) {
+			return v.value, true
+		}
+
+		// the weight splits exactly after the value, the median lies between it and the next value of non-zero weight
+		for _, next := range values[i+1:] {
+			if next.weight > 0 {
+				return (v.value + next.value) / 2, true
+			}
+		}
+		return v.value, true
 	}
-	return sums
+
+	return values[len(values)-1].value, true
 }
 
 // checkConvergence tests if the centroids have changed significantly.
-func checkConvergence(oldCentroids, newCentroids []float64, threshold float64) bool {
-	for i := range oldCentroids {
-		if math.Abs(oldCentroids[i]-newCentroids[i]) > threshold {
+func checkConvergence(oldCentroids, newCentroids *points.Set, threshold float64) bool {
+	for i, v := range oldCentroids.Flat() {
+		if math.Abs(v-newCentroids.Flat()[i]) > threshold {
 			return false
 		}
 	}
@@ -1,5 +1,212 @@
 package kmeans
 
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+func TestKMeans_SetParams(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	k := new(KMeans)
+	if err := k.Init(ctx, points.FromValues([]float64{1, 2, 3, 10, 11, 12})); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	if err := k.SetParams(ctx, params.Values{2, float64(PlusPlusCentroidsIniter), 50, 1, 0}); err != nil {
+		t.Fatalf("SetParams() error = %v", err)
+	}
+	if k.clustersNum != 2 || k.centroidsIniterType != PlusPlusCentroidsIniter || k.maxIterations != 50 {
+		t.Errorf("SetParams() set k = %d, initer = %s, max iterations = %d", k.clustersNum, k.centroidsIniterType, k.maxIterations)
+	}
+
+	if err := k.SetParams(ctx, params.Values{4, 0, 100, 1, 0}); err == nil {
+		t.Error("SetParams() error = nil for k out of bounds")
+	}
+}
+
+func TestKMeans_Cluster_restarts(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 200)
+	for i := range values {
+		values[i] = float64(i%5*100) + r.NormFloat64()*20
+	}
+	data := points.FromValues(values)
+
+	cluster := func(seed int64, restarts int) (*points.Set, []int) {
+		k := new(KMeans)
+		k.SetSeed(seed)
+		if err := k.Init(ctx, data); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		if err := k.SetParams(ctx, params.Values{5, float64(RandomCentroidsIniter), defaultMaxIterations, float64(restarts), float64(ReseedEmptyCluster)}); err != nil {
+			t.Fatalf("SetParams() error = %v", err)
+		}
+
+		return k.Cluster(ctx)
+	}
+
+	centroids, labels := cluster(7, 1)
+	for i := 0; i < 3; i++ {
+		if c, l := cluster(7, 1); !reflect.DeepEqual(c, centroids) || !reflect.DeepEqual(l, labels) {
+			t.Fatal("Cluster() is not reproducible with the same seed")
+		}
+	}
+
+	// the first restart runs the same stream as the single run, so the restarts could only lower the inertia
+	single := inertia(data, centroids, labels, points.Euclidean, true)
+	c, l := cluster(7, 10)
+	if restarted := inertia(data, c, l, points.Euclidean, true); restarted > single {
+		t.Errorf("Cluster() inertia with restarts = %v, greater than %v without", restarted, single)
+	}
+}
+
+func TestRegisterCentroidsIniter(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	// the first k points are far from the optimum, so a single iteration shows they were the initial centroids
+	first, err := RegisterCentroidsIniter("test-first", "first k points", func(data *points.Set, k int, _ points.Metric, _ *rand.Rand) *points.Set {
+		return data.Subset([]int{0, 1}[:k])
+	})
+	if err != nil {
+		t.Fatalf("RegisterCentroidsIniter() error = %v", err)
+	}
+	if _, err := RegisterCentroidsIniter("random", "", nil); err == nil {
+		t.Error("RegisterCentroidsIniter() error = nil for the built-in name")
+	}
+
+	k := new(KMeans)
+	if err := k.Init(ctx, points.FromValues([]float64{1, 2, 3, 10, 11, 12})); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	space, err := k.Params().Fix(map[string]string{"k": "2", "initer": "test-first", "max-iterations": "1"})
+	if err != nil {
+		t.Fatalf("Params().Fix() error = %v", err)
+	}
+	if err := k.SetParams(ctx, space.Defaults()); err != nil {
+		t.Fatalf("SetParams() error = %v", err)
+	}
+	if k.centroidsIniterType != first || first.String() != "test-first" {
+		t.Errorf("SetParams() set initer = %s, want %s", k.centroidsIniterType, first)
+	}
+
+	if centroids, _ := k.Cluster(ctx); !reflect.DeepEqual(centroids.Flat(), []float64{1, 7.6}) {
+		t.Errorf("Cluster() = %v, want [1 7.6]", centroids.Flat())
+	}
+}
+
+func Test_updateCentroids_weighted(t *testing.T) {
+	data, _ := points.FromValues([]float64{0, 1, 2, 10, 20}).WithWeights([]float64{1, 2, 1, 0, 3})
+	labels := []int{0, 0, 0, 1, 1}
+	centroids := points.FromValues([]float64{5, 5, 5})
+
+	tests := []struct {
+		name   string
+		update func(data *points.Set, labels []int, centroids *points.Set) (*points.Set, []int)
+		want   []float64
+	}{
+		{name: "means", update: updateCentroids, want: []float64{1, 20, 5}},
+		{name: "medians", update: updateMedians, want: []float64{1, 20, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, counts := tt.update(data, labels, centroids)
+			if !reflect.DeepEqual(got.Flat(), tt.want) {
+				t.Errorf("update() = %v, want %v", got.Flat(), tt.want)
+			}
+			if !reflect.DeepEqual(counts, []int{3, 2, 0}) {
+				t.Errorf("update() counts = %v, want [3 2 0]", counts)
+			}
+		})
+	}
+}
+
+func Test_weightedMedian(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []weightedValue
+		want   float64
+		wantOk bool
+	}{
+		{name: "odd", values: []weightedValue{{3, 1}, {1, 1}, {2, 1}}, want: 2, wantOk: true},
+		{name: "even", values: []weightedValue{{4, 1}, {1, 1}, {2, 1}, {3, 1}}, want: 2.5, wantOk: true},
+		{name: "heavy", values: []weightedValue{{1, 1}, {2, 1}, {10, 3}}, want: 10, wantOk: true},
+		{name: "zero weight skipped", values: []weightedValue{{1, 1}, {2, 0}, {3, 1}}, want: 2, wantOk: true},
+		{name: "zero total", values: []weightedValue{{1, 0}}, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := weightedMedian(tt.values)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("weightedMedian() = %v, %t, want %v, %t", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_initializeCentroidsKMeansPlusPlus_weighted(t *testing.T) {
+	// the points of zero weight are never drawn
+	data, _ := points.FromValues([]float64{0, 1, 100, 101}).WithWeights([]float64{1, 0, 0, 1})
+	for seed := int64(0); seed < 20; seed++ {
+		got := initializeCentroidsKMeansPlusPlus(data, 2, points.Euclidean, rand.New(rand.NewSource(seed)))
+		if got.At(0)[0] == 1 || got.At(0)[0] == 100 || got.At(1)[0] == 1 || got.At(1)[0] == 100 {
+			t.Fatalf("initializeCentroidsKMeansPlusPlus() with seed %d = %v", seed, got.Flat())
+		}
+	}
+}
+
+func Test_fixEmptyClusters(t *testing.T) {
+	data := points.FromValues([]float64{0, 1, 2, 10, 11, 30})
+	labels := []int{0, 0, 0, 1, 1, 1}
+
+	tests := []struct {
+		strategy   EmptyClusterStrategy
+		want       []float64
+		wantCounts []int
+	}{
+		{strategy: ReseedEmptyCluster, want: []float64{1, 17, 30}, wantCounts: []int{3, 3, 1}},
+		{strategy: DropEmptyCluster, want: []float64{1, 17}, wantCounts: []int{3, 3, 0}},
+		{strategy: SplitLargestCluster, want: []float64{1, 17, 0}, wantCounts: []int{2, 3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			counts := []int{3, 3, 0}
+			got := fixEmptyClusters(tt.strategy, data, labels, points.FromValues([]float64{1, 17, 100}), counts, points.Euclidean)
+			if !reflect.DeepEqual(got.Flat(), tt.want) {
+				t.Errorf("fixEmptyClusters() = %v, want %v", got.Flat(), tt.want)
+			}
+			if !reflect.DeepEqual(counts, tt.wantCounts) {
+				t.Errorf("fixEmptyClusters() counts = %v, want %v", counts, tt.wantCounts)
+			}
+		})
+	}
+}
+
+func TestKMedians_Cluster(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	k := new(KMedians)
+	if err := k.Init(ctx, points.FromValues([]float64{1, 2, 3, 100})); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if err := k.SetParams(ctx, params.Values{1, float64(RandomCentroidsIniter), defaultMaxIterations, 1, float64(ReseedEmptyCluster)}); err != nil {
+		t.Fatalf("SetParams() error = %v", err)
+	}
+
+	// the outlier does not pull the median as it pulls the mean
+	if centroids, _ := k.Cluster(ctx); !reflect.DeepEqual(centroids.Flat(), []float64{2.5}) {
+		t.Errorf("Cluster() = %v, want [2.5]", centroids.Flat())
+	}
+}
+
 // import (
 // 	pkgContext "context"
 // 	"github.com/boson-research/patterns/internal/context"
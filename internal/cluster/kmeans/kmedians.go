@@ -0,0 +1,18 @@
+package kmeans
+
+import (
+	"context"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+)
+
+// KMedians is k-means with the centroids at the coordinate-wise medians of the clusters. It minimizes the sum of the
+// distances instead of the squared ones, so the outliers pull the centroids less. The manhattan metric suits it best.
+type KMedians struct {
+	KMeans
+}
+
+func (k *KMedians) Init(ctx context.Context, data *points.Set) error {
+	k.medians = true
+	return k.KMeans.Init(ctx, data)
+}
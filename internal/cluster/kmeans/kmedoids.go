@@ -0,0 +1,216 @@
+package kmeans
+
+import (
+	"context"
+	"math"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// KMedoids is the partitioning around medoids (PAM): the centers of the clusters are the points of the data minimizing
+// the sum of the distances to the points of their clusters weighted by the weights of the points. The medoids are
+// chosen greedily by the BUILD step and improved by the SWAP step, the best swap of a medoid with a non-medoid point is
+// found in O(n²) per iteration by FastPAM1. It is deterministic and works with any metric.
+type KMedoids struct {
+	clustersNum   int
+	maxIterations int
+	params        params.Space
+	metric        points.Metric
+	data          *points.Set
+	// medoids are the indices of the medoids of the last clustering ordered by label.
+	medoids []int
+}
+
+func (k *KMedoids) Init(ctx context.Context, data *points.Set) error {
+	k.data = data
+	k.params = params.Space{
+		{Name: "k", Kind: params.Int, Min: 1, Max: float64(max(1, data.Len()/2)), Default: 1, Tunable: true},
+		{Name: "max-iterations", Kind: params.Int, Min: 1, Max: math.MaxInt32, Default: defaultMaxIterations},
+	}
+	if k.metric == nil {
+		k.metric = points.Euclidean
+	}
+
+	return k.SetParams(ctx, k.params.Defaults())
+}
+
+// SetMetric sets the distance between the points.
+func (k *KMedoids) SetMetric(metric points.Metric) {
+	k.metric = metric
+}
+
+func (k *KMedoids) Params() params.Space {
+	return k.params
+}
+
+func (k *KMedoids) SetParams(ctx context.Context, values params.Values) error {
+	if err := k.params.Validate(values); err != nil {
+		return err
+	}
+
+	k.clustersNum = int(values[0])
+	k.maxIterations = int(values[1])
+
+	return nil
+}
+
+// Medoids returns the indices of the points which are the centers of the clusters of the last clustering, ordered by
+// the labels.
+func (k *KMedoids) Medoids(ctx context.Context) []int {
+	return k.medoids
+}
+
+// Cluster returns the medoids and the labels of the points, the label of a point is its nearest medoid.
+func (k *KMedoids) Cluster(ctx context.Context) (*points.Set, []int) {
+	ctx, span := otel.Tracer("").Start(ctx, "KMedoids")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Tracef("clustering %d points around %d medoids", k.data.Len(), k.clustersNum)
+
+	n := k.data.Len()
+	if n == 0 {
+		k.medoids = nil
+		return points.New(k.data.Dim()), nil
+	}
+
+	medoids := k.build(min(k.clustersNum, n))
+	nearest := newNearestMedoids(k.data, medoids, k.metric)
+	for it := 0; it < k.maxIterations; it++ {
+		m, h, delta := k.bestSwap(medoids, nearest)
+		if delta >= -1e-12*math.Max(1, nearest.cost(k.data)) {
+			logger.MustFromContext(ctx).Tracef("converged after %d swaps", it)
+			break
+		}
+
+		medoids[m] = h
+		nearest = newNearestMedoids(k.data, medoids, k.metric)
+	}
+
+	k.medoids = medoids
+	return k.data.Subset(medoids), nearest.label
+}
+
+// build chooses k medoids greedily: every next medoid is the point reducing the weighted sum of the distances the
+// most.
+func (k *KMedoids) build(clustersNum int) []int {
+	n := k.data.Len()
+	dist := make([]float64, n)
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+
+	isMedoid := make([]bool, n)
+	medoids := make([]int, 0, clustersNum)
+	for len(medoids) < clustersNum {
+		best, bestCost := -1, math.Inf(1)
+		for h := 0; h < n; h++ {
+			if isMedoid[h] {
+				continue
+			}
+
+			cost := 0.0
+			for j := 0; j < n; j++ {
+				cost += k.data.Weight(j) * math.Min(dist[j], k.metric.Distance(k.data.At(j), k.data.At(h)))
+			}
+			if cost < bestCost {
+				best, bestCost = h, cost
+			}
+		}
+
+		medoids = append(medoids, best)
+		isMedoid[best] = true
+		for j := range dist {
+			dist[j] = math.Min(dist[j], k.metric.Distance(k.data.At(j), k.data.At(best)))
+		}
+	}
+
+	return medoids
+}
+
+// bestSwap returns the index of the medoid, the point to replace it with and the change of the weighted sum of the
+// distances of the best swap.
+func (k *KMedoids) bestSwap(medoids []int, nearest *nearestMedoids) (int, int, float64) {
+	n := k.data.Len()
+
+	isMedoid := make([]bool, n)
+	for _, m := range medoids {
+		isMedoid[m] = true
+	}
+
+	bestM, bestH, bestDelta := -1, -1, math.Inf(1)
+	delta := make([]float64, len(medoids))
+	for h := 0; h < n; h++ {
+		if isMedoid[h] {
+			continue
+		}
+
+		// shared is the change of the sum whichever medoid is replaced, delta[m] is the extra change when it is m
+		for m := range delta {
+			delta[m] = 0
+		}
+		shared := 0.0
+		for j := 0; j < n; j++ {
+			d, w := k.metric.Distance(k.data.At(j), k.data.At(h)), k.data.Weight(j)
+			if d < nearest.first[j] {
+				// the point goes to h whichever medoid is replaced
+				shared += w * (d - nearest.first[j])
+			} else {
+				// the point goes to h or to its second nearest medoid if its nearest one is replaced
+				delta[nearest.label[j]] += w * (math.Min(d, nearest.second[j]) - nearest.first[j])
+			}
+		}
+
+		for m := range delta {
+			if d := delta[m] + shared; d < bestDelta {
+				bestM, bestH, bestDelta = m, h, d
+			}
+		}
+	}
+
+	return bestM, bestH, bestDelta
+}
+
+// nearestMedoids holds the labels of the nearest medoids of the points, the distances to them and to the second
+// nearest medoids, infinite if there is a single medoid.
+type nearestMedoids struct {
+	label  []int
+	first  []float64
+	second []float64
+}
+
+func newNearestMedoids(data *points.Set, medoids []int, metric points.Metric) *nearestMedoids {
+	nm := &nearestMedoids{
+		label:  make([]int, data.Len()),
+		first:  make([]float64, data.Len()),
+		second: make([]float64, data.Len()),
+	}
+
+	for j := 0; j < data.Len(); j++ {
+		nm.first[j], nm.second[j] = math.Inf(1), math.Inf(1)
+		for m, idx := range medoids {
+			d := metric.Distance(data.At(j), data.At(idx))
+			switch {
+			case d < nm.first[j]:
+				nm.label[j], nm.first[j], nm.second[j] = m, d, nm.first[j]
+			case d < nm.second[j]:
+				nm.second[j] = d
+			}
+		}
+	}
+
+	return nm
+}
+
+// cost returns the sum of the distances of the points of the data to their nearest medoids weighted by the weights of
+// the points.
+func (nm *nearestMedoids) cost(data *points.Set) float64 {
+	sum := 0.0
+	for j, d := range nm.first {
+		sum += data.Weight(j) * d
+	}
+
+	return sum
+}
@@ -0,0 +1,86 @@
+package kmeans
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+func TestKMedoids_Cluster(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	r := rand.New(rand.NewSource(1))
+	values := make([][]float64, 12)
+	for i := range values {
+		values[i] = []float64{float64(i%3*50) + r.Float64()*10, float64(i%3*20) + r.Float64()*10}
+	}
+	unweighted := points.New(2)
+	for _, v := range values {
+		unweighted.Add(v)
+	}
+	weights := make([]float64, len(values))
+	for i := range weights {
+		weights[i] = float64(r.Intn(4) * r.Intn(4))
+	}
+	weighted, err := unweighted.WithWeights(weights)
+	if err != nil {
+		t.Fatalf("WithWeights() error = %v", err)
+	}
+
+	for _, data := range []*points.Set{unweighted, weighted} {
+		for _, metric := range []points.Metric{points.Euclidean, points.Manhattan} {
+			for _, clustersNum := range []int{1, 2, 3} {
+				k := new(KMedoids)
+				k.SetMetric(metric)
+				if err := k.Init(ctx, data); err != nil {
+					t.Fatalf("Init() error = %v", err)
+				}
+				if err := k.SetParams(ctx, params.Values{float64(clustersNum), defaultMaxIterations}); err != nil {
+					t.Fatalf("SetParams() error = %v", err)
+				}
+
+				centroids, labels := k.Cluster(ctx)
+				medoids := k.Medoids(ctx)
+				if !reflect.DeepEqual(centroids, data.Subset(medoids)) {
+					t.Errorf("Cluster() centroids = %v, want the points %v", centroids.Flat(), medoids)
+				}
+
+				if got, want := medoidsCost(data, medoids, metric), bruteForceCost(data, clustersNum, metric); math.Abs(got-want) > 1e-9 {
+					t.Errorf("Cluster() k = %d cost = %v, want the optimal %v", clustersNum, got, want)
+				}
+				if got := inertia(data, centroids, labels, metric, false); math.Abs(got-medoidsCost(data, medoids, metric)) > 1e-9 {
+					t.Errorf("Cluster() k = %d labels are not the nearest medoids", clustersNum)
+				}
+			}
+		}
+	}
+}
+
+// medoidsCost returns the weighted sum of the distances of the points to their nearest medoids.
+func medoidsCost(data *points.Set, medoids []int, metric points.Metric) float64 {
+	return newNearestMedoids(data, medoids, metric).cost(data)
+}
+
+// bruteForceCost returns the lowest cost of all the combinations of k medoids.
+func bruteForceCost(data *points.Set, k int, metric points.Metric) float64 {
+	best := math.Inf(1)
+	var choose func(from int, medoids []int)
+	choose = func(from int, medoids []int) {
+		if len(medoids) == k {
+			best = math.Min(best, medoidsCost(data, medoids, metric))
+			return
+		}
+		for i := from; i < data.Len(); i++ {
+			choose(i+1, append(medoids, i))
+		}
+	}
+	choose(0, nil)
+
+	return best
+}
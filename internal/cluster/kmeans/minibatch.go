@@ -0,0 +1,228 @@
+package kmeans
+
+import (
+	"context"
+	"math"
+	"math/rand"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// defaultBatchSize is the default number of the points of a mini-batch.
+	defaultBatchSize = 1024
+	// defaultTolerance is the default movement of the centroids per batch, relative to the variance of the points,
+	// the centroids are considered converged below.
+	defaultTolerance = 1e-4
+	// defaultPatience is the default number of the batches the smoothed inertia could not improve for before the
+	// centroids are considered converged.
+	defaultPatience = 10
+)
+
+// MiniBatchKMeans is k-means updating the centroids from the random mini-batches of the points instead of all of them,
+// every centroid moves towards the points of the batch assigned to it with the learning rate decreasing as the number
+// of the points it has seen grows. The time of an iteration depends on the batch size, not on the number of the points,
+// only the final labeling scans all of them.
+type MiniBatchKMeans struct {
+	clustersNum         int
+	centroidsIniterType CentroidsIniterType
+	batchSize           int
+	maxIterations       int
+	tolerance           float64
+	patience            int
+	seed                int64
+	params              params.Space
+	metric              points.Metric
+	data                *points.Set
+}
+
+func (k *MiniBatchKMeans) Init(ctx context.Context, data *points.Set) error {
+	k.data = data
+	k.params = params.Space{
+		{Name: "k", Kind: params.Int, Min: 1, Max: float64(max(1, data.Len()/2)), Default: 1, Tunable: true},
+		{Name: "initer", Kind: params.Choice, Choices: centroidsIniterNames(), Default: float64(PlusPlusCentroidsIniter)},
+		{Name: "batch-size", Kind: params.Int, Min: 1, Max: math.MaxInt32, Default: defaultBatchSize},
+		{Name: "max-iterations", Kind: params.Int, Min: 1, Max: math.MaxInt32, Default: defaultMaxIterations},
+		{Name: "tolerance", Kind: params.Float, Min: 0, Max: 1, Default: defaultTolerance},
+		{Name: "patience", Kind: params.Int, Min: 1, Max: math.MaxInt32, Default: defaultPatience},
+	}
+	if k.metric == nil {
+		k.metric = points.Euclidean
+	}
+
+	return k.SetParams(ctx, k.params.Defaults())
+}
+
+// SetMetric sets the distance the points are assigned to the nearest centroids by.
+func (k *MiniBatchKMeans) SetMetric(metric points.Metric) {
+	k.metric = metric
+}
+
+// SetSeed sets the seed the random numbers of the initialization and of the batches are derived from.
+func (k *MiniBatchKMeans) SetSeed(seed int64) {
+	k.seed = seed
+}
+
+func (k *MiniBatchKMeans) Params() params.Space {
+	return k.params
+}
+
+func (k *MiniBatchKMeans) SetParams(ctx context.Context, values params.Values) error {
+	if err := k.params.Validate(values); err != nil {
+		return err
+	}
+
+	k.clustersNum = int(values[0])
+	k.centroidsIniterType = CentroidsIniterType(values[1])
+	k.batchSize = int(values[2])
+	k.maxIterations = int(values[3])
+	k.tolerance = values[4]
+	k.patience = int(values[5])
+
+	return nil
+}
+
+// Cluster initializes the centroids on a random sample of the points, updates them from the random batches until they
+// converge or the iterations are over and labels all the points by the nearest centroids.
+func (k *MiniBatchKMeans) Cluster(ctx context.Context) (*points.Set, []int) {
+	ctx, span := otel.Tracer("").Start(ctx, "MiniBatchKMeans")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Tracef("clustering %d points into %d clusters by batches of %d", k.data.Len(), k.clustersNum, k.batchSize)
+
+	n := k.data.Len()
+	if n == 0 {
+		return points.New(k.data.Dim()), nil
+	}
+
+	rnd := rand.New(restartSource(k.seed, 0))
+	sample := func(size int) *points.Set {
+		s := points.NewWithSize(k.data.Dim(), size)
+		for i := 0; i < size; i++ {
+			i := rnd.Intn(n)
+			s.AddWeighted(k.data.At(i), k.data.Weight(i))
+		}
+
+		return s
+	}
+
+	// the points are drawn with replacement, so the initial sample is larger than the batch and k to hold k distinct
+	// points with high probability
+	initial := k.data
+	if size := max(3*k.batchSize, 3*k.clustersNum); size < n {
+		initial = sample(size)
+	}
+
+	mb := newMiniBatch(getCentroidsIniter(k.centroidsIniterType)(initial, min(k.clustersNum, initial.Len()), k.metric, rnd), initial, k.metric, k.tolerance, k.patience)
+	for i := 0; i < k.maxIterations; i++ {
+		if mb.step(sample(min(k.batchSize, n))) {
+			logger.MustFromContext(ctx).Tracef("converged after %d batches", i+1)
+			break
+		}
+	}
+
+	return mb.centroids, assignPointsToCentroids(k.data, mb.centroids, k.metric)
+}
+
+// miniBatch holds the state of the mini-batch updates of the centroids.
+type miniBatch struct {
+	centroids *points.Set
+	// counts are the total weights of the points every centroid has been moved towards.
+	counts []float64
+	metric points.Metric
+	// tolerance is the squared movement of the centroids per batch they are considered converged below.
+	tolerance float64
+	patience  int
+	// inertia is the exponentially weighted average of the mean inertia of the batches, best is its lowest value and
+	// stale is the number of the batches since it.
+	inertia float64
+	best    float64
+	stale   int
+}
+
+// newMiniBatch returns the mini-batch updates of the centroids. The tolerance is relative to the variance of the
+// sample of the points.
+func newMiniBatch(centroids, sample *points.Set, metric points.Metric, tolerance float64, patience int) *miniBatch {
+	return &miniBatch{
+		centroids: centroids,
+		counts:    make([]float64, centroids.Len()),
+		metric:    metric,
+		tolerance: tolerance * variance(sample),
+		patience:  patience,
+		inertia:   math.NaN(),
+		best:      math.Inf(1),
+	}
+}
+
+// step moves the centroids towards the points of the batch assigned to them and reports whether the centroids have
+// converged, i.e. moved less than the tolerance or not improved the smoothed inertia for the patience batches. The
+// weighted points move the centroids as that many points would.
+func (mb *miniBatch) step(batch *points.Set) bool {
+	total := batch.TotalWeight()
+	if total == 0 {
+		return false
+	}
+
+	labels := assignPointsToCentroids(batch, mb.centroids, mb.metric)
+	batchInertia := inertia(batch, mb.centroids, labels, mb.metric, true) / total
+
+	old := append([]float64(nil), mb.centroids.Flat()...)
+	for i, l := range labels {
+		w := batch.Weight(i)
+		if w == 0 {
+			continue
+		}
+
+		mb.counts[l] += w
+		eta := w / mb.counts[l]
+		c := mb.centroids.At(l)
+		for j, v := range batch.At(i) {
+			c[j] += eta * (v - c[j])
+		}
+	}
+
+	shift := 0.0
+	for i, v := range mb.centroids.Flat() {
+		shift += (v - old[i]) * (v - old[i])
+	}
+	if shift <= mb.tolerance {
+		return true
+	}
+
+	// the average spans about the patience batches
+	if alpha := 2 / float64(mb.patience+1); math.IsNaN(mb.inertia) {
+		mb.inertia = batchInertia
+	} else {
+		mb.inertia = alpha*batchInertia + (1-alpha)*mb.inertia
+	}
+	if mb.inertia < mb.best {
+		mb.best, mb.stale = mb.inertia, 0
+		return false
+	}
+
+	mb.stale++
+	return mb.stale >= mb.patience
+}
+
+// variance returns the mean of the variances of the coordinates of the points.
+func variance(data *points.Set) float64 {
+	if data.Len() == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for j := 0; j < data.Dim(); j++ {
+		mean, sq := 0.0, 0.0
+		for _, v := range data.Column(j) {
+			mean += v
+			sq += v * v
+		}
+		mean /= float64(data.Len())
+		sum += sq/float64(data.Len()) - mean*mean
+	}
+
+	return sum / float64(data.Dim())
+}
@@ -0,0 +1,114 @@
+package kmeans
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+// separated returns n points around 0, 1000 and 2000 in turn.
+func separated(n int) *points.Set {
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(i%3*1000) + r.NormFloat64()*10
+	}
+
+	return points.FromValues(values)
+}
+
+// nearCenters reports whether every centroid is within the tolerance of its own center of the separated points.
+func nearCenters(centroids *points.Set, tolerance float64) bool {
+	got := append([]float64(nil), centroids.Flat()...)
+	sort.Float64s(got)
+	for i, c := range []float64{0, 1000, 2000} {
+		if i >= len(got) || math.Abs(got[i]-c) > tolerance {
+			return false
+		}
+	}
+
+	return len(got) == 3
+}
+
+func TestMiniBatchKMeans_Cluster(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	data := separated(3000)
+	cluster := func() (*points.Set, []int) {
+		k := new(MiniBatchKMeans)
+		k.SetSeed(3)
+		if err := k.Init(ctx, data); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		if err := k.SetParams(ctx, params.Values{3, float64(PlusPlusCentroidsIniter), 30, defaultMaxIterations, defaultTolerance, defaultPatience}); err != nil {
+			t.Fatalf("SetParams() error = %v", err)
+		}
+
+		return k.Cluster(ctx)
+	}
+
+	centroids, labels := cluster()
+	if !nearCenters(centroids, 5) {
+		t.Errorf("Cluster() centroids = %v, want near 0, 1000, 2000", centroids.Flat())
+	}
+	for i, l := range labels {
+		if l != labels[i%3] {
+			t.Fatalf("Cluster() label of point %d = %d, want %d", i, l, labels[i%3])
+		}
+	}
+
+	if c, l := cluster(); !reflect.DeepEqual(c, centroids) || !reflect.DeepEqual(l, labels) {
+		t.Error("Cluster() is not reproducible with the same seed")
+	}
+}
+
+func TestStream_Consume(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	tests := []struct {
+		name          string
+		n             int
+		batchSize     float64
+		wantConverged bool
+	}{
+		{name: "converged before the end", n: 100000, batchSize: 100, wantConverged: true},
+		{name: "points over", n: 50, batchSize: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := separated(tt.n)
+
+			s := NewStream()
+			if err := s.SetParams(ctx, params.Values{3, float64(PlusPlusCentroidsIniter), tt.batchSize, defaultTolerance, defaultPatience}); err != nil {
+				t.Fatalf("SetParams() error = %v", err)
+			}
+			if err := s.Consume(ctx, data.Iterator()); err != nil {
+				t.Fatalf("Consume() error = %v", err)
+			}
+
+			if s.Converged() != tt.wantConverged {
+				t.Errorf("Consume() converged = %t, want %t", s.Converged(), tt.wantConverged)
+			}
+			if tt.wantConverged && s.Consumed() >= tt.n {
+				t.Errorf("Consume() consumed all %d points despite the convergence", tt.n)
+			}
+			if !tt.wantConverged && s.Consumed() != tt.n {
+				t.Errorf("Consume() consumed %d points, want %d", s.Consumed(), tt.n)
+			}
+			if !nearCenters(s.Centroids(), 20) {
+				t.Errorf("Consume() centroids = %v, want near 0, 1000, 2000", s.Centroids().Flat())
+			}
+		})
+	}
+
+	if err := NewStream().Consume(ctx, points.New(1).Iterator()); err == nil {
+		t.Error("Consume() error = nil for no points")
+	}
+}
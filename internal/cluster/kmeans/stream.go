@@ -0,0 +1,177 @@
+package kmeans
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// Stream is the streaming k-means: it consumes the points from the iterator batch by batch, holding a single batch at
+// once, initializes the centroids on the first batch and moves them towards the points of every next batch as the
+// mini-batch k-means does. The consumption stops as soon as the centroids converge, so the iterator should yield the
+// points in an order any prefix of which represents all of them.
+type Stream struct {
+	clustersNum         int
+	centroidsIniterType CentroidsIniterType
+	batchSize           int
+	tolerance           float64
+	patience            int
+	seed                int64
+	params              params.Space
+	values              params.Values
+	metric              points.Metric
+	centroids           *points.Set
+	// consumed is the number of the points consumed, converged is set if the consumption stopped before the points
+	// were over.
+	consumed  int
+	converged bool
+}
+
+// NewStream returns the streaming k-means with the default params.
+func NewStream() *Stream {
+	s := &Stream{
+		params: params.Space{
+			{Name: "k", Kind: params.Int, Min: 1, Max: math.MaxInt32, Default: 2},
+			{Name: "initer", Kind: params.Choice, Choices: centroidsIniterNames(), Default: float64(PlusPlusCentroidsIniter)},
+			{Name: "batch-size", Kind: params.Int, Min: 1, Max: math.MaxInt32, Default: defaultBatchSize},
+			{Name: "tolerance", Kind: params.Float, Min: 0, Max: 1, Default: defaultTolerance},
+			{Name: "patience", Kind: params.Int, Min: 1, Max: math.MaxInt32, Default: defaultPatience},
+		},
+		metric: points.Euclidean,
+	}
+	_ = s.SetParams(context.Background(), s.params.Defaults())
+
+	return s
+}
+
+// SetMetric sets the distance the points are assigned to the nearest centroids by.
+func (s *Stream) SetMetric(metric points.Metric) {
+	s.metric = metric
+}
+
+// Metric returns the distance the points are assigned to the nearest centroids by.
+func (s *Stream) Metric() points.Metric {
+	return s.metric
+}
+
+// SetSeed sets the seed the random numbers of the initialization are derived from.
+func (s *Stream) SetSeed(seed int64) {
+	s.seed = seed
+}
+
+// Seed returns the seed the random numbers of the initialization are derived from.
+func (s *Stream) Seed() int64 {
+	return s.seed
+}
+
+func (s *Stream) Params() params.Space {
+	return s.params
+}
+
+func (s *Stream) SetParams(ctx context.Context, values params.Values) error {
+	if err := s.params.Validate(values); err != nil {
+		return err
+	}
+
+	s.clustersNum = int(values[0])
+	s.centroidsIniterType = CentroidsIniterType(values[1])
+	s.batchSize = int(values[2])
+	s.tolerance = values[3]
+	s.patience = int(values[4])
+	s.values = values
+
+	return nil
+}
+
+// Values returns the values of the params the stream is set with.
+func (s *Stream) Values() params.Values {
+	return s.values
+}
+
+// Consume updates the centroids from the points of the iterator, the centroids of the previous consumption are
+// dropped. It fails if the iterator yields no points.
+func (s *Stream) Consume(ctx context.Context, it points.Iterator) error {
+	ctx, span := otel.Tracer("").Start(ctx, "Stream")
+	defer span.End()
+
+	s.centroids, s.consumed, s.converged = nil, 0, false
+
+	// the first batch holds at least 3 points per cluster for the initialization
+	batch := s.read(it, max(s.batchSize, 3*s.clustersNum))
+	if batch == nil {
+		return errors.New("no points to consume")
+	}
+
+	rnd := rand.New(restartSource(s.seed, 0))
+	mb := newMiniBatch(getCentroidsIniter(s.centroidsIniterType)(batch, min(s.clustersNum, batch.Len()), s.metric, rnd), batch, s.metric, s.tolerance, s.patience)
+	s.centroids = mb.centroids
+	for batch != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if mb.step(batch) {
+			s.converged = true
+			break
+		}
+
+		batch = s.read(it, s.batchSize)
+	}
+
+	logger.MustFromContext(ctx).Tracef("consumed %d points into %d centroids, converged: %t", s.consumed, s.centroids.Len(), s.converged)
+
+	return nil
+}
+
+// read returns the batch of the next size points of the iterator, nil if the points are over.
+func (s *Stream) read(it points.Iterator, size int) *points.Set {
+	var batch *points.Set
+	for batch == nil || batch.Len() < size {
+		p, ok := it.Next()
+		if !ok {
+			break
+		}
+		if batch == nil {
+			batch = points.NewWithSize(len(p), size)
+		}
+
+		batch.Add(p)
+		s.consumed++
+	}
+
+	return batch
+}
+
+// Centroids returns the centroids of the last consumption.
+func (s *Stream) Centroids() *points.Set {
+	return s.centroids
+}
+
+// Consumed returns the number of the points the last consumption took from the iterator.
+func (s *Stream) Consumed() int {
+	return s.consumed
+}
+
+// Converged reports whether the last consumption stopped on the convergence of the centroids before the points were
+// over.
+func (s *Stream) Converged() bool {
+	return s.converged
+}
+
+// Label returns the label of the centroid nearest to the point.
+func (s *Stream) Label(p []float64) int {
+	label, minDist := 0, math.Inf(1)
+	for j := 0; j < s.centroids.Len(); j++ {
+		if d := s.metric.Distance(p, s.centroids.At(j)); d < minDist {
+			label, minDist = j, d
+		}
+	}
+
+	return label
+}
@@ -0,0 +1,177 @@
+package cluster
+
+import (
+	"math"
+	"slices"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+)
+
+// clusterStats holds the sizes, the total weights and the weighted means of the clusters by their labels.
+type clusterStats struct {
+	counts  map[int]int
+	weights map[int]float64
+	means   map[int][]float64
+}
+
+func calcClusterStats(data *points.Set, labels []int) clusterStats {
+	s := clusterStats{counts: make(map[int]int), weights: make(map[int]float64), means: make(map[int][]float64)}
+	for i, label := range labels {
+		if s.means[label] == nil {
+			s.means[label] = make([]float64, data.Dim())
+		}
+
+		w := data.Weight(i)
+		s.counts[label]++
+		s.weights[label] += w
+		for j, v := range data.At(i) {
+			s.means[label][j] += w * v
+		}
+	}
+
+	for label, mean := range s.means {
+		if s.weights[label] == 0 {
+			continue
+		}
+		for j := range mean {
+			mean[j] /= s.weights[label]
+		}
+	}
+
+	return s
+}
+
+// mean returns the weighted mean of all the points.
+func mean(data *points.Set) []float64 {
+	m := make([]float64, data.Dim())
+	for i := 0; i < data.Len(); i++ {
+		for j, v := range data.At(i) {
+			m[j] += data.Weight(i) * v
+		}
+	}
+
+	total := data.TotalWeight()
+	for j := range m {
+		m[j] /= total
+	}
+
+	return m
+}
+
+// calcDaviesBouldin calculates the negated Davies-Bouldin index: the average over the clusters of the highest ratio
+// of the sum of the scatters of two clusters to the distance between their means. The index is lower for compact
+// well separated clusters and is undefined for a single cluster, infinite if the means of two clusters coincide. The
+// scatters and the means are weighted.
+func calcDaviesBouldin(data *points.Set, labels []int, metric points.Metric) float64 {
+	s := calcClusterStats(data, labels)
+	if len(s.counts) < 2 {
+		return math.NaN()
+	}
+
+	scatters := make(map[int]float64, len(s.counts))
+	for i, label := range labels {
+		scatters[label] += data.Weight(i) * metric.Distance(data.At(i), s.means[label])
+	}
+	for label := range scatters {
+		if s.weights[label] > 0 {
+			scatters[label] /= s.weights[label]
+		}
+	}
+
+	total := 0.0
+	for i := range s.means {
+		worst := 0.0
+		for j := range s.means {
+			if i == j {
+				continue
+			}
+
+			// the clusters of coinciding means are not separated at all whatever their scatters are, even of zero
+			d := metric.Distance(s.means[i], s.means[j])
+			if d == 0 {
+				worst = math.Inf(1)
+				break
+			}
+
+			worst = math.Max(worst, (scatters[i]+scatters[j])/d)
+		}
+
+		total += worst
+	}
+
+	return -total / float64(len(s.counts))
+}
+
+// calcCalinskiHarabasz calculates the Calinski-Harabasz index: the ratio of the between-cluster dispersion to the
+// within-cluster dispersion, each divided by its degrees of freedom. The index is higher for dense well separated
+// clusters and is undefined for a single cluster. The weighted points count as that many points.
+func calcCalinskiHarabasz(data *points.Set, labels []int, _ points.Metric) float64 {
+	s := calcClusterStats(data, labels)
+	n, k := data.TotalWeight(), float64(len(s.counts))
+	if k < 2 || k >= n {
+		return math.NaN()
+	}
+
+	m := mean(data)
+
+	between := 0.0
+	for label, clusterMean := range s.means {
+		between += s.weights[label] * points.SquaredEuclidean(clusterMean, m)
+	}
+
+	return (between / (k - 1)) / (calcWCSS(data, labels) / (n - k))
+}
+
+// minVariance returns the lowest variance of the spherical clusters of the data, the lowest of the floors of its
+// coordinates by points.Set.MinVariances.
+func minVariance(data *points.Set) float64 {
+	return slices.Min(data.MinVariances())
+}
+
+// calcLogLikelihood calculates the log-likelihood of the data under the mixture of spherical gaussians centered at the
+// cluster means with the shared variance estimated from the clustering and the weights proportional to the cluster
+// sizes. The weighted points count as that many points. It returns the likelihood and the number of the free
+// parameters of the model.
+func calcLogLikelihood(data *points.Set, labels []int) (float64, int) {
+	s := calcClusterStats(data, labels)
+	n, k, d := data.TotalWeight(), float64(len(s.counts)), float64(data.Dim())
+	if n <= k {
+		return math.NaN(), 0
+	}
+
+	wcss := calcWCSS(data, labels)
+	variance := math.Max(wcss/(d*(n-k)), minVariance(data))
+
+	ll := -n*d/2*math.Log(2*math.Pi*variance) - wcss/(2*variance)
+	for _, w := range s.weights {
+		if w > 0 {
+			ll += w * math.Log(w/n)
+		}
+	}
+
+	// k-1 weights, k means of d coordinates and the shared variance
+	return ll, len(s.counts) * (data.Dim() + 1)
+}
+
+// calcBIC calculates the negated bayesian information criterion of the clustering.
+func calcBIC(data *points.Set, labels []int, _ points.Metric) float64 {
+	ll, params := calcLogLikelihood(data, labels)
+	return bic(ll, params, data.TotalWeight())
+}
+
+// calcAIC calculates the negated Akaike information criterion of the clustering.
+func calcAIC(data *points.Set, labels []int, _ points.Metric) float64 {
+	ll, params := calcLogLikelihood(data, labels)
+	return aic(ll, params, data.TotalWeight())
+}
+
+// bic returns the negated bayesian information criterion of the model with the log-likelihood of the points of the
+// total weight n, the same n the likelihood counts the points by.
+func bic(logLikelihood float64, paramsNum int, n float64) float64 {
+	return -(float64(paramsNum)*math.Log(n) - 2*logLikelihood)
+}
+
+// aic returns the negated Akaike information criterion of the model with the log-likelihood.
+func aic(logLikelihood float64, paramsNum int, _ float64) float64 {
+	return -(2*float64(paramsNum) - 2*logLikelihood)
+}
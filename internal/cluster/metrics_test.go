@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+)
+
+func TestMetrics(t *testing.T) {
+	square, _ := points.FromFlat(2, []float64{1, 1, 2, 1, 1, 2, 2, 2, 50, 50, 51, 50, 50, 51, 51, 51})
+	datasets := map[string]*points.Set{
+		"1-D": points.FromValues([]float64{1, 2, 3, 4, 50, 51, 52, 53}),
+		"2-D": square,
+	}
+	labelings := map[string][]int{
+		"2 clusters":    {0, 0, 0, 0, 1, 1, 1, 1},
+		"wrong split":   {0, 0, 1, 1, 1, 1, 1, 1},
+		"split further": {0, 0, 0, 0, 1, 1, 2, 2},
+	}
+
+	for _, m := range []QualityEstimationMethod{Silhouette, DaviesBouldin, CalinskiHarabasz, BIC, AIC} {
+		for dataName, data := range datasets {
+			for metricName, metric := range points.Metrics {
+				t.Run(m.String()+"/"+dataName+"/"+metricName, func(t *testing.T) {
+					score := getQualityEstimator(m).score
+					want := score(data, labelings["2 clusters"], metric)
+					for name, labels := range labelings {
+						if got := score(data, labels, metric); got > want {
+							t.Errorf("%s score for %s = %v, want less than %v", m, name, got, want)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestMetrics_noise(t *testing.T) {
+	values := make([]float64, 0, 20)
+	for i := 0; i < 10; i++ {
+		values = append(values, float64(i), float64(100+i))
+	}
+	data := points.FromValues(values)
+
+	covered := make([]int, len(values))
+	mostlyNoise := make([]int, len(values))
+	for i, v := range values {
+		covered[i] = int(v) / 100
+		mostlyNoise[i] = Noise
+		if v == 0 || v == 1 || v == 100 || v == 101 {
+			mostlyNoise[i] = int(v) / 100
+		}
+	}
+
+	for _, m := range []QualityEstimationMethod{Silhouette, DaviesBouldin, CalinskiHarabasz, BIC, AIC} {
+		t.Run(m.String(), func(t *testing.T) {
+			score := getQualityEstimator(m).score
+			want := score(data, noiseAsCluster(covered), points.Euclidean)
+			if got := score(data, noiseAsCluster(mostlyNoise), points.Euclidean); got >= want {
+				t.Errorf("%s score of mostly noise = %v, want less than %v of all covered", m, got, want)
+			}
+		})
+	}
+
+	if got, want := calcWCSS(data, mostlyNoise), calcWCSS(data, covered); got <= want {
+		t.Errorf("calcWCSS() of mostly noise = %v, want more than %v of all covered", got, want)
+	}
+}
+
+func Test_calcDaviesBouldin_coincidingMeans(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		labels []int
+	}{
+		{name: "scattered", values: []float64{0, 2, 1, 1, 10}, labels: []int{0, 0, 1, 1, 2}},
+		{name: "equal points", values: []float64{1, 1, 1, 1}, labels: []int{0, 0, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calcDaviesBouldin(points.FromValues(tt.values), tt.labels, points.Euclidean); !math.IsInf(got, -1) {
+				t.Errorf("calcDaviesBouldin() = %v, want -Inf", got)
+			}
+		})
+	}
+}
+
+func TestMetrics_weighted(t *testing.T) {
+	// the points of integer weights are scored as that many copies of them
+	data, _ := points.FromValues([]float64{1, 2, 4, 50, 51, 53}).WithWeights([]float64{1, 2, 1, 3, 1, 2})
+	labels := []int{0, 0, 0, 1, 1, 1}
+	copies := points.FromValues([]float64{1, 2, 2, 4, 50, 50, 50, 51, 53, 53})
+	copiesLabels := []int{0, 0, 0, 0, 1, 1, 1, 1, 1, 1}
+
+	for _, m := range []QualityEstimationMethod{DaviesBouldin, CalinskiHarabasz, BIC, AIC} {
+		t.Run(m.String(), func(t *testing.T) {
+			score := getQualityEstimator(m).score
+			got, want := score(data, labels, points.Euclidean), score(copies, copiesLabels, points.Euclidean)
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("%s score = %v, want %v", m, got, want)
+			}
+		})
+	}
+
+	if got, want := calcWCSS(data, labels), calcWCSS(copies, copiesLabels); math.Abs(got-want) > 1e-9 {
+		t.Errorf("calcWCSS() = %v, want %v", got, want)
+	}
+}
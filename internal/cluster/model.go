@@ -0,0 +1,243 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/boson-research/patterns/internal/cluster/gmm"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+)
+
+// Model is the fitted clustering the new points are assigned to the clusters of without refitting: every point is
+// labeled by the nearest centroid, or by the most probable component of the mixture of the soft clusterers. The
+// clusterers leaving noise are approximated by the balls around the centroids enclosing their clusters and the other
+// clusterers whose clusters are not the nearest centroid ones, like the agglomerative, by the nearest centroid.
+type Model struct {
+	// Centroids holds the centroids of the clusters by their labels.
+	Centroids *points.Set
+	// Metric is the distance the nearest centroid is found by.
+	Metric points.Metric
+	// Radii holds the distances from the centroids to the farthest points of their clusters by the labels, the points
+	// farther from the nearest centroid than its radius are noise. It is nil if the clustering left no noise.
+	Radii []float64
+	// Weights and Variances are the weights and the diagonal variances of the gaussian components of the mixture
+	// centered at the centroids by the labels, nil if the clustering is not a mixture. The points are assigned to the
+	// most probable components whatever the metric is.
+	Weights   []float64
+	Variances *points.Set
+	// Approximate reports whether the model assigns some of the points the clustering was made on to other clusters
+	// than the clustering did.
+	Approximate bool
+	// Scaling is the scaling the points are transformed by before they are assigned, nil if they are assigned as they
+	// are, e.g. the standardization of the features the clustering was made on.
+	Scaling *points.Scaling
+}
+
+// newModel returns the model of the clustering of the data into the clusters of the centroids by the labels, the
+// mixture of the mixture clusterers is taken from the clusterer, so it must have made the clustering last.
+func newModel(ctx context.Context, clusterer Clusterer, data *points.Set, labels []int, centroids *points.Set, metric points.Metric) *Model {
+	m := &Model{Centroids: centroids, Metric: metric}
+	if mc, ok := clusterer.(MixtureClusterer); ok {
+		m.Weights, m.Variances = mc.Mixture(ctx)
+	}
+
+	if lo.Contains(labels, Noise) {
+		m.Radii = make([]float64, centroids.Len())
+		for i, l := range labels {
+			if l != Noise {
+				m.Radii[l] = math.Max(m.Radii[l], metric.Distance(data.At(i), centroids.At(l)))
+			}
+		}
+	}
+
+	m.Approximate = !slices.Equal(m.assign(data), labels)
+
+	return m
+}
+
+// Select returns the model of the clusters of the labels only, the clusters are relabeled by the order of the labels.
+func (m *Model) Select(labels []int) *Model {
+	selected := &Model{
+		Centroids:   points.NewWithSize(m.Centroids.Dim(), len(labels)),
+		Metric:      m.Metric,
+		Approximate: m.Approximate,
+		Scaling:     m.Scaling,
+	}
+	for _, l := range labels {
+		selected.Centroids.Add(m.Centroids.At(l))
+	}
+
+	if m.Radii != nil {
+		selected.Radii = make([]float64, len(labels))
+		for i, l := range labels {
+			selected.Radii[i] = m.Radii[l]
+		}
+	}
+
+	if m.Weights != nil {
+		selected.Weights = make([]float64, len(labels))
+		selected.Variances = points.NewWithSize(m.Variances.Dim(), len(labels))
+		for i, l := range labels {
+			selected.Weights[i] = m.Weights[l]
+			selected.Variances.Add(m.Variances.At(l))
+		}
+	}
+
+	return selected
+}
+
+// Predict returns the labels of the clusters of the model the points are assigned to, Noise for the points out of the
+// radii of their nearest clusters. The points must be of the dimension of the centroids.
+func (m *Model) Predict(ctx context.Context, data *points.Set) ([]int, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "Predict")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debugf("assigning %d points to %d clusters", data.Len(), m.Centroids.Len())
+
+	if data.Dim() != m.Centroids.Dim() {
+		return nil, fmt.Errorf("points of dimension %d assigned to clusters of dimension %d", data.Dim(), m.Centroids.Dim())
+	}
+
+	if m.Scaling != nil {
+		data = m.Scaling.Apply(data)
+	}
+
+	return m.assign(data), nil
+}
+
+// assign returns the labels of the clusters of the scaled points.
+func (m *Model) assign(data *points.Set) []int {
+	labels := make([]int, data.Len())
+	for i := range labels {
+		labels[i] = Noise
+
+		if m.Weights != nil {
+			best := math.Inf(-1)
+			for l, w := range m.Weights {
+				if ll := math.Log(w) + gmm.LogGaussian(data.At(i), m.Centroids.At(l), m.Variances.At(l)); ll > best {
+					labels[i], best = l, ll
+				}
+			}
+			continue
+		}
+
+		nearest := math.Inf(1)
+		for l := 0; l < m.Centroids.Len(); l++ {
+			if d := m.Metric.Distance(data.At(i), m.Centroids.At(l)); d < nearest {
+				labels[i], nearest = l, d
+			}
+		}
+
+		if m.Radii != nil && labels[i] != Noise && nearest > m.Radii[labels[i]] {
+			labels[i] = Noise
+		}
+	}
+
+	return labels
+}
+
+// modelJSON is the persisted form of the model, the metric is stored by its name.
+type modelJSON struct {
+	Metric      string          `json:"metric"`
+	Centroids   [][]float64     `json:"centroids"`
+	Radii       []float64       `json:"radii,omitempty"`
+	Weights     []float64       `json:"weights,omitempty"`
+	Variances   [][]float64     `json:"variances,omitempty"`
+	Approximate bool            `json:"approximate,omitempty"`
+	Scaling     *points.Scaling `json:"scaling,omitempty"`
+}
+
+func (m *Model) MarshalJSON() ([]byte, error) {
+	if !points.IsBuiltIn(m.Metric) {
+		return nil, fmt.Errorf("model of custom metric %s could not be persisted", m.Metric.Name())
+	}
+
+	raw := modelJSON{
+		Metric:      m.Metric.Name(),
+		Centroids:   make([][]float64, m.Centroids.Len()),
+		Radii:       m.Radii,
+		Weights:     m.Weights,
+		Approximate: m.Approximate,
+		Scaling:     m.Scaling,
+	}
+	for l := range raw.Centroids {
+		raw.Centroids[l] = m.Centroids.At(l)
+	}
+	if m.Variances != nil {
+		raw.Variances = make([][]float64, m.Variances.Len())
+		for l := range raw.Variances {
+			raw.Variances[l] = m.Variances.At(l)
+		}
+	}
+
+	return json.Marshal(raw)
+}
+
+func (m *Model) UnmarshalJSON(data []byte) error {
+	var raw modelJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	metric, err := points.ParseMetric(raw.Metric)
+	if err != nil {
+		return err
+	}
+
+	if len(raw.Centroids) == 0 {
+		return errors.New("model has no centroids")
+	}
+	dim := len(raw.Centroids[0])
+	if dim == 0 {
+		return errors.New("centroids must not be empty")
+	}
+
+	centroids := points.NewWithSize(dim, len(raw.Centroids))
+	for _, c := range raw.Centroids {
+		if len(c) != dim {
+			return fmt.Errorf("centroids of dimensions %d and %d", dim, len(c))
+		}
+		centroids.Add(c)
+	}
+
+	if raw.Radii != nil && len(raw.Radii) != len(raw.Centroids) {
+		return fmt.Errorf("%d radii of %d centroids", len(raw.Radii), len(raw.Centroids))
+	}
+	if raw.Scaling != nil && (len(raw.Scaling.Shift) != dim || len(raw.Scaling.Scale) != dim) {
+		return fmt.Errorf("scaling of dimension %d of centroids of dimension %d", len(raw.Scaling.Shift), dim)
+	}
+
+	var variances *points.Set
+	if raw.Weights != nil {
+		if len(raw.Weights) != len(raw.Centroids) || len(raw.Variances) != len(raw.Centroids) {
+			return fmt.Errorf("%d weights and %d variances of %d centroids", len(raw.Weights), len(raw.Variances), len(raw.Centroids))
+		}
+
+		variances = points.NewWithSize(dim, len(raw.Variances))
+		for _, v := range raw.Variances {
+			if len(v) != dim || slices.ContainsFunc(v, func(x float64) bool { return x <= 0 }) {
+				return fmt.Errorf("variances %v of centroids of dimension %d", v, dim)
+			}
+			variances.Add(v)
+		}
+	}
+
+	*m = Model{
+		Centroids:   centroids,
+		Metric:      metric,
+		Radii:       raw.Radii,
+		Weights:     raw.Weights,
+		Variances:   variances,
+		Approximate: raw.Approximate,
+		Scaling:     raw.Scaling,
+	}
+
+	return nil
+}
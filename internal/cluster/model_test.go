@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+func TestModel_Predict(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	data := points.FromValues([]float64{0, 1, 2, 10, 12, 50})
+	centroids := points.FromValues([]float64{1, 11})
+
+	tests := []struct {
+		name   string
+		labels []int
+		want   []int
+	}{
+		{name: "nearest centroid", labels: []int{0, 0, 0, 1, 1, 1}, want: []int{0, 1, 0, 1, 1}},
+		{name: "radii of noise", labels: []int{0, 0, 0, 1, 1, Noise}, want: []int{Noise, Noise, 0, 1, Noise}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newModel(ctx, nil, data, tt.labels, centroids, points.Euclidean)
+
+			got, err := m.Predict(ctx, points.FromValues([]float64{-1, 7, 1.5, 11.5, 40}))
+			if err != nil {
+				t.Fatalf("Predict() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Predict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModel_Predict_fitted(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	// the spreads of the clusters differ, so the most probable components of the mixture are not the nearest ones
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 150)
+	for i := range values {
+		values[i] = float64(i%3*40) + r.NormFloat64()*[]float64{1, 3, 12}[i%3]
+	}
+	data := points.FromValues(values)
+
+	for _, clusterer := range []ClustererType{KMeans, Ckmeans, GMM} {
+		t.Run(clusterer.String(), func(t *testing.T) {
+			res, err := New(clusterer, Silhouette).WithParams(map[string]string{"k": "3"}).Clusterize(ctx, data)
+			if err != nil {
+				t.Fatalf("Clusterize() error = %v", err)
+			}
+
+			got, err := res.Model.Predict(ctx, data)
+			if err != nil {
+				t.Fatalf("Predict() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, res.Labels) || res.Model.Approximate {
+				t.Errorf("Predict() = %v, want the fitted labels %v, approximate %t", got, res.Labels, res.Model.Approximate)
+			}
+		})
+	}
+}
+
+func TestModel_JSON(t *testing.T) {
+	centroids, _ := points.FromFlat(2, []float64{0, 0, 10, 10})
+	variances, _ := points.FromFlat(2, []float64{1, 2, 3, 4})
+	m := &Model{
+		Centroids:   centroids,
+		Metric:      points.Manhattan,
+		Radii:       []float64{1, 2},
+		Weights:     []float64{0.25, 0.75},
+		Variances:   variances,
+		Approximate: true,
+		Scaling:     &points.Scaling{Shift: []float64{5, 5}, Scale: []float64{2, 2}},
+	}
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got Model
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if got.Metric != points.Manhattan {
+		t.Errorf("Unmarshal() metric = %s, want manhattan", got.Metric.Name())
+	}
+	if !reflect.DeepEqual(got.Centroids.Flat(), centroids.Flat()) || !reflect.DeepEqual(got.Radii, m.Radii) || !reflect.DeepEqual(got.Scaling, m.Scaling) {
+		t.Errorf("Unmarshal() = %s, want %s", got.Centroids, centroids)
+	}
+	if !reflect.DeepEqual(got.Weights, m.Weights) || !reflect.DeepEqual(got.Variances.Flat(), variances.Flat()) || !got.Approximate {
+		t.Errorf("Unmarshal() mixture = %v %s, approximate %t", got.Weights, got.Variances, got.Approximate)
+	}
+
+	if err := json.Unmarshal([]byte(`{"metric":"euclidean","centroids":[[1],[1,2]]}`), &got); err == nil {
+		t.Error("Unmarshal() of centroids of different dimensions error = nil")
+	}
+}
@@ -0,0 +1,322 @@
+package params
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of the values of a param.
+type Kind int
+
+const (
+	Int Kind = iota
+	Float
+	// Choice params take one of the named values, the value of the param is the index of the name.
+	Choice
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Choice:
+		return "choice"
+	}
+	return "unknown"
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *Kind) UnmarshalText(text []byte) error {
+	for _, kind := range []Kind{Int, Float, Choice} {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown kind: %s", text)
+}
+
+// gridSteps is the number of the values of a float param the grid takes.
+const gridSteps = 20
+
+// Spec declares a param of a clusterer.
+type Spec struct {
+	Name string `json:"name"`
+	Kind Kind   `json:"kind"`
+	// Min and Max bound the values of the int and float params inclusively.
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+	// Default is the value of the param until it is set.
+	Default float64 `json:"default"`
+	// LogScale spaces the grid values evenly on the log scale, Min must be positive then.
+	LogScale bool `json:"log_scale,omitempty"`
+	// Choices are the names of the values of the choice param.
+	Choices []string `json:"choices,omitempty"`
+	// Tunable params are optimized by the search, the others keep their default values.
+	Tunable bool `json:"tunable,omitempty"`
+}
+
+// Validate checks that the value is valid for the param.
+func (s Spec) Validate(v float64) error {
+	switch s.Kind {
+	case Int:
+		if v != math.Trunc(v) {
+			return fmt.Errorf("%s must be an integer, got %s", s.Name, s.Format(v))
+		}
+	case Float:
+	case Choice:
+		if v != math.Trunc(v) || v < 0 || int(v) >= len(s.Choices) {
+			return fmt.Errorf("%s must be one of %s", s.Name, strings.Join(s.Choices, ", "))
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s is of unknown kind", s.Name)
+	}
+
+	if !(v >= s.Min && v <= s.Max) {
+		return fmt.Errorf("%s must be between %s and %s, got %s", s.Name, s.Format(s.Min), s.Format(s.Max), s.Format(v))
+	}
+
+	return nil
+}
+
+// Parse returns the value of the param by its text representation, the choices are given by their names.
+func (s Spec) Parse(str string) (float64, error) {
+	var v float64
+	switch s.Kind {
+	case Int:
+		i, err := strconv.Atoi(str)
+		if err != nil {
+			return 0, fmt.Errorf("parse %s: %w", s.Name, err)
+		}
+		v = float64(i)
+	case Float:
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse %s: %w", s.Name, err)
+		}
+		v = f
+	case Choice:
+		i := slices.Index(s.Choices, str)
+		if i < 0 {
+			return 0, fmt.Errorf("%s must be one of %s, got %s", s.Name, strings.Join(s.Choices, ", "), str)
+		}
+		v = float64(i)
+	}
+
+	return v, s.Validate(v)
+}
+
+// Format returns the text representation of the value of the param.
+func (s Spec) Format(v float64) string {
+	switch s.Kind {
+	case Int:
+		return strconv.FormatInt(int64(v), 10)
+	case Choice:
+		if v >= 0 && int(v) < len(s.Choices) {
+			return s.Choices[int(v)]
+		}
+	}
+
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// String describes the param: its name, kind, bounds or choices and the default value, e.g.
+// "k int 1..50 default 1 tunable".
+func (s Spec) String() string {
+	b := strings.Builder{}
+	b.WriteString(s.Name + " " + s.Kind.String())
+	if s.Kind == Choice {
+		b.WriteString(" " + strings.Join(s.Choices, "|"))
+	} else {
+		b.WriteString(" " + s.Format(s.Min) + ".." + s.Format(s.Max))
+	}
+	if s.LogScale {
+		b.WriteString(" log")
+	}
+	b.WriteString(" default " + s.Format(s.Default))
+	if s.Tunable {
+		b.WriteString(" tunable")
+	}
+
+	return b.String()
+}
+
+// grid returns the values of the param searched over, the default value only if the param is not tunable.
+func (s Spec) grid() []float64 {
+	if !s.Tunable {
+		return []float64{s.Default}
+	}
+
+	switch s.Kind {
+	case Int:
+		if !s.LogScale {
+			values := make([]float64, 0, int(s.Max-s.Min)+1)
+			for v := s.Min; v <= s.Max; v++ {
+				values = append(values, v)
+			}
+			return values
+		}
+
+		values := s.steps()
+		for i := range values {
+			values[i] = math.Round(values[i])
+		}
+		return slices.Compact(values)
+	case Float:
+		return s.steps()
+	case Choice:
+		values := make([]float64, len(s.Choices))
+		for i := range values {
+			values[i] = float64(i)
+		}
+		return values
+	}
+
+	return nil
+}
+
+// steps returns gridSteps values spaced evenly from Min to Max on the linear or the log scale.
+func (s Spec) steps() []float64 {
+	if s.Min >= s.Max {
+		return []float64{s.Min}
+	}
+
+	values := make([]float64, gridSteps)
+	for i := range values {
+		t := float64(i) / float64(gridSteps-1)
+		if s.LogScale {
+			values[i] = math.Exp(math.Log(s.Min) + t*(math.Log(s.Max)-math.Log(s.Min)))
+		} else {
+			values[i] = s.Min + t*(s.Max-s.Min)
+		}
+	}
+	values[len(values)-1] = s.Max
+
+	return values
+}
+
+// Space is the set of the params of a clusterer, its bounds could depend on the clustered data.
+type Space []Spec
+
+// Values are the values of the params ordered as the specs of the space.
+type Values []float64
+
+// Index returns the index of the param by its name, -1 if there is no such param.
+func (s Space) Index(name string) int {
+	return slices.IndexFunc(s, func(spec Spec) bool { return spec.Name == name })
+}
+
+// Defaults returns the default values of the params.
+func (s Space) Defaults() Values {
+	values := make(Values, len(s))
+	for i, spec := range s {
+		values[i] = spec.Default
+	}
+
+	return values
+}
+
+// Validate checks that the values are valid for the params.
+func (s Space) Validate(values Values) error {
+	if len(values) != len(s) {
+		return fmt.Errorf("expected %d params, got %d", len(s), len(values))
+	}
+
+	for i, spec := range s {
+		if err := spec.Validate(values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Clamp returns the copy of the values with the numeric ones moved within the bounds of their params, so that the
+// values chosen for some data are valid for the space of other data of narrower bounds.
+func (s Space) Clamp(values Values) Values {
+	clamped := slices.Clone(values)
+	for i, spec := range s {
+		if i < len(clamped) && spec.Kind != Choice {
+			clamped[i] = math.Max(spec.Min, math.Min(spec.Max, clamped[i]))
+		}
+	}
+
+	return clamped
+}
+
+// Format returns the values as the space separated name=value pairs.
+func (s Space) Format(values Values) string {
+	pairs := make([]string, 0, len(values))
+	for i, v := range values {
+		if i < len(s) {
+			pairs = append(pairs, s[i].Name+"="+s[i].Format(v))
+		}
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// Fix returns the copy of the space with the params assigned by their names fixed to the assigned values, so that
+// they are not tuned any more.
+func (s Space) Fix(assignments map[string]string) (Space, error) {
+	fixed := slices.Clone(s)
+	for name, str := range assignments {
+		i := fixed.Index(name)
+		if i < 0 {
+			return nil, fmt.Errorf("unknown param %s, expected one of %s", name, strings.Join(s.names(), ", "))
+		}
+
+		v, err := fixed[i].Parse(str)
+		if err != nil {
+			return nil, err
+		}
+
+		fixed[i].Default, fixed[i].Tunable = v, false
+	}
+
+	return fixed, nil
+}
+
+// Grid returns all the combinations of the grid values of the params, the first param changes the slowest.
+func (s Space) Grid() []Values {
+	grid := []Values{{}}
+	for _, spec := range s {
+		var next []Values
+		for _, prefix := range grid {
+			for _, v := range spec.grid() {
+				next = append(next, append(slices.Clone(prefix), v))
+			}
+		}
+		grid = next
+	}
+
+	return grid
+}
+
+func (s Space) names() []string {
+	names := make([]string, len(s))
+	for i, spec := range s {
+		names[i] = spec.Name
+	}
+
+	return names
+}
+
+// ParseAssignment splits the name=value assignment of a param.
+func ParseAssignment(s string) (name, value string, err error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return "", "", fmt.Errorf("param must be set as name=value, got %s", s)
+	}
+
+	return strings.TrimSpace(name), strings.TrimSpace(value), nil
+}
@@ -0,0 +1,129 @@
+package params
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpace_Grid(t *testing.T) {
+	tests := []struct {
+		name  string
+		space Space
+		want  []Values
+	}{
+		{
+			name: "int and choice",
+			space: Space{
+				{Name: "k", Kind: Int, Min: 1, Max: 2, Default: 1, Tunable: true},
+				{Name: "linkage", Kind: Choice, Choices: []string{"single", "ward"}, Tunable: true},
+			},
+			want: []Values{{1, 0}, {1, 1}, {2, 0}, {2, 1}},
+		},
+		{
+			name: "fixed",
+			space: Space{
+				{Name: "k", Kind: Int, Min: 1, Max: 3, Default: 1, Tunable: true},
+				{Name: "max-iterations", Kind: Int, Min: 1, Max: 1000, Default: 100},
+			},
+			want: []Values{{1, 100}, {2, 100}, {3, 100}},
+		},
+		{
+			name: "log scale int",
+			space: Space{
+				{Name: "k", Kind: Int, Min: 1, Max: 4, Default: 1, LogScale: true, Tunable: true},
+			},
+			want: []Values{{1}, {2}, {3}, {4}},
+		},
+		{
+			name: "degenerate float",
+			space: Space{
+				{Name: "eps", Kind: Float, Min: 1, Max: 1, Default: 1, LogScale: true, Tunable: true},
+			},
+			want: []Values{{1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.space.Grid(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Space.Grid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpace_Grid_float(t *testing.T) {
+	grid := Space{{Name: "eps", Kind: Float, Min: 1, Max: 100, LogScale: true, Tunable: true}}.Grid()
+	if len(grid) != gridSteps {
+		t.Fatalf("Space.Grid() len = %d, want %d", len(grid), gridSteps)
+	}
+	if grid[0][0] != 1 || grid[len(grid)-1][0] != 100 {
+		t.Errorf("Space.Grid() bounds = %v, %v, want 1, 100", grid[0][0], grid[len(grid)-1][0])
+	}
+	for i := 2; i < len(grid); i++ {
+		// evenly spaced on the log scale
+		if r1, r2 := grid[i][0]/grid[i-1][0], grid[i-1][0]/grid[i-2][0]; r1-r2 > 1e-9 || r2-r1 > 1e-9 {
+			t.Errorf("Space.Grid() ratios %v and %v differ", r1, r2)
+		}
+	}
+}
+
+func TestSpace_Fix(t *testing.T) {
+	space := Space{
+		{Name: "k", Kind: Int, Min: 1, Max: 10, Default: 1, Tunable: true},
+		{Name: "linkage", Kind: Choice, Choices: []string{"single", "ward"}, Tunable: true},
+		{Name: "tolerance", Kind: Float, Min: 0, Max: 1, Default: 0.5},
+	}
+
+	tests := []struct {
+		name        string
+		assignments map[string]string
+		want        string
+		wantErr     bool
+	}{
+		{name: "defaults", want: "k=1 linkage=single tolerance=0.5"},
+		{name: "all", assignments: map[string]string{"k": "3", "linkage": "ward", "tolerance": "0.01"}, want: "k=3 linkage=ward tolerance=0.01"},
+		{name: "unknown param", assignments: map[string]string{"eps": "1"}, wantErr: true},
+		{name: "not an integer", assignments: map[string]string{"k": "1.5"}, wantErr: true},
+		{name: "out of bounds", assignments: map[string]string{"k": "11"}, wantErr: true},
+		{name: "unknown choice", assignments: map[string]string{"linkage": "average"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixed, err := space.Fix(tt.assignments)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Space.Fix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := fixed.Format(fixed.Defaults()); got != tt.want {
+				t.Errorf("Space.Fix() defaults = %s, want %s", got, tt.want)
+			}
+			for name := range tt.assignments {
+				i := fixed.Index(name)
+				for _, v := range fixed.Grid() {
+					if v[i] != fixed[i].Default {
+						t.Errorf("Space.Fix() grid tunes fixed %s: %v", name, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSpace_Clamp(t *testing.T) {
+	space := Space{
+		{Name: "k", Kind: Int, Min: 1, Max: 10},
+		{Name: "linkage", Kind: Choice, Choices: []string{"single", "ward"}},
+		{Name: "eps", Kind: Float, Min: 0.5, Max: 2},
+	}
+
+	values := Values{12, 1, 0.1}
+	if got := space.Clamp(values); !reflect.DeepEqual(got, Values{10, 1, 0.5}) {
+		t.Errorf("Space.Clamp() = %v, want [10 1 0.5]", got)
+	}
+	if !reflect.DeepEqual(values, Values{12, 1, 0.1}) {
+		t.Errorf("Space.Clamp() modified the values: %v", values)
+	}
+}
@@ -0,0 +1,109 @@
+package points
+
+import (
+	"fmt"
+	"math"
+)
+
+// Metric is the named distance between the points of the same dimension, the metrics are told apart and persisted by
+// their names.
+type Metric interface {
+	Name() string
+	Distance(a, b []float64) float64
+}
+
+// namedMetric is the metric of the distance function, absolute is set for the absolute difference on the 1-D points.
+type namedMetric struct {
+	name     string
+	distance func(a, b []float64) float64
+	absolute bool
+}
+
+// NewMetric returns the metric of the distance function by its name.
+func NewMetric(name string, distance func(a, b []float64) float64) Metric {
+	return &namedMetric{name: name, distance: distance}
+}
+
+func (m *namedMetric) Name() string {
+	return m.name
+}
+
+func (m *namedMetric) Distance(a, b []float64) float64 {
+	return m.distance(a, b)
+}
+
+func (m *namedMetric) String() string {
+	return m.name
+}
+
+var (
+	Euclidean Metric = &namedMetric{name: "euclidean", distance: euclidean, absolute: true}
+	Manhattan Metric = &namedMetric{name: "manhattan", distance: manhattan, absolute: true}
+	Chebyshev Metric = &namedMetric{name: "chebyshev", distance: chebyshev, absolute: true}
+)
+
+// Metrics holds the built-in metrics by their names.
+var Metrics = map[string]Metric{
+	Euclidean.Name(): Euclidean,
+	Manhattan.Name(): Manhattan,
+	Chebyshev.Name(): Chebyshev,
+}
+
+// ParseMetric returns the built-in metric by its name.
+func ParseMetric(s string) (Metric, error) {
+	m, ok := Metrics[s]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric: %s", s)
+	}
+
+	return m, nil
+}
+
+// IsBuiltIn reports whether the metric is the built-in one of its name, so that it is parsed back by the name.
+func IsBuiltIn(m Metric) bool {
+	return Metrics[m.Name()] == m
+}
+
+// IsAbsolute reports whether the metric is the absolute difference on the 1-D points, as the euclidean, manhattan and
+// chebyshev metrics are, so that the distances could be summed up by the prefix sums of the sorted points.
+func IsAbsolute(m Metric) bool {
+	nm, ok := m.(*namedMetric)
+	return ok && nm.absolute
+}
+
+func euclidean(a, b []float64) float64 {
+	// the most of the points are 1-D locations
+	if len(a) == 1 {
+		return math.Abs(a[0] - b[0])
+	}
+
+	return math.Sqrt(SquaredEuclidean(a, b))
+}
+
+// SquaredEuclidean is the squared euclidean distance, it is not a metric, but is what the sums of squares are built of.
+func SquaredEuclidean(a, b []float64) float64 {
+	d := 0.0
+	for i := range a {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+
+	return d
+}
+
+func manhattan(a, b []float64) float64 {
+	d := 0.0
+	for i := range a {
+		d += math.Abs(a[i] - b[i])
+	}
+
+	return d
+}
+
+func chebyshev(a, b []float64) float64 {
+	d := 0.0
+	for i := range a {
+		d = math.Max(d, math.Abs(a[i]-b[i]))
+	}
+
+	return d
+}
@@ -0,0 +1,305 @@
+package points
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrTooManyPoints is the error of the clusterings and the measures of more points than the distances between their
+// pairs fit in memory.
+var ErrTooManyPoints = errors.New("too many points")
+
+// Set is the set of points of the same dimension. The coordinates are stored flat, point after point, so that the
+// sets of millions of points are a single allocation.
+type Set struct {
+	dim    int
+	values []float64
+	// weights are the weights of the points, nil if all of them weigh 1.
+	weights []float64
+}
+
+// New returns the empty set of points of the dimension.
+func New(dim int) *Set {
+	return &Set{dim: dim}
+}
+
+// NewWithSize returns the empty set of points of the dimension with the capacity for size points.
+func NewWithSize(dim int, size int) *Set {
+	return &Set{dim: dim, values: make([]float64, 0, dim*size)}
+}
+
+// FromValues returns the set of 1-D points with the values.
+func FromValues(values []float64) *Set {
+	return &Set{dim: 1, values: values}
+}
+
+// FromFlat returns the set of points of the dimension with the flat coordinates, the set shares them.
+func FromFlat(dim int, values []float64) (*Set, error) {
+	if dim <= 0 {
+		return nil, fmt.Errorf("dimension must be positive, got %d", dim)
+	}
+
+	if len(values)%dim != 0 {
+		return nil, fmt.Errorf("%d coordinates do not make up points of dimension %d", len(values), dim)
+	}
+
+	return &Set{dim: dim, values: values}, nil
+}
+
+// Add appends the point of weight 1 to the set.
+func (s *Set) Add(p []float64) {
+	s.AddWeighted(p, 1)
+}
+
+// AddWeighted appends the point of the weight to the set.
+func (s *Set) AddWeighted(p []float64, w float64) {
+	if len(p) != s.dim {
+		panic(fmt.Sprintf("point of dimension %d added to set of dimension %d", len(p), s.dim))
+	}
+
+	if s.weights == nil && w != 1 {
+		s.weights = make([]float64, s.Len())
+		for i := range s.weights {
+			s.weights[i] = 1
+		}
+	}
+	if s.weights != nil {
+		s.weights = append(s.weights, w)
+	}
+
+	s.values = append(s.values, p...)
+}
+
+// WithWeights returns the set of the same points with the weights, the set shares the coordinates. The weights must
+// not be negative and must not be all zero, nil weights make all the points weigh 1.
+func (s *Set) WithWeights(weights []float64) (*Set, error) {
+	if weights == nil {
+		return &Set{dim: s.dim, values: s.values}, nil
+	}
+
+	if len(weights) != s.Len() {
+		return nil, fmt.Errorf("%d weights for %d points", len(weights), s.Len())
+	}
+
+	total := 0.0
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("weight of point %d is %v, must be non-negative", i, w)
+		}
+		total += w
+	}
+	if total == 0 && len(weights) > 0 {
+		return nil, fmt.Errorf("all the weights are zero")
+	}
+
+	return &Set{dim: s.dim, values: s.values, weights: weights}, nil
+}
+
+// Weighted reports whether the points have weights other than 1.
+func (s *Set) Weighted() bool {
+	return s.weights != nil
+}
+
+// Weight returns the weight of the i-th point.
+func (s *Set) Weight(i int) float64 {
+	if s.weights == nil {
+		return 1
+	}
+
+	return s.weights[i]
+}
+
+// Weights returns the weights of the points, nil if all of them weigh 1. The slice is shared with the set.
+func (s *Set) Weights() []float64 {
+	return s.weights
+}
+
+// TotalWeight returns the sum of the weights of the points, their number if they are not weighted.
+func (s *Set) TotalWeight() float64 {
+	if s.weights == nil {
+		return float64(s.Len())
+	}
+
+	total := 0.0
+	for _, w := range s.weights {
+		total += w
+	}
+
+	return total
+}
+
+func (s *Set) Dim() int {
+	return s.dim
+}
+
+func (s *Set) Len() int {
+	if s.dim == 0 {
+		return 0
+	}
+
+	return len(s.values) / s.dim
+}
+
+// At returns the coordinates of the i-th point, the slice is shared with the set.
+func (s *Set) At(i int) []float64 {
+	return s.values[i*s.dim : (i+1)*s.dim : (i+1)*s.dim]
+}
+
+// Flat returns the coordinates of all the points, point after point. The slice is shared with the set.
+func (s *Set) Flat() []float64 {
+	return s.values
+}
+
+// Column returns the j-th coordinates of all the points.
+func (s *Set) Column(j int) []float64 {
+	column := make([]float64, s.Len())
+	for i := range column {
+		column[i] = s.values[i*s.dim+j]
+	}
+
+	return column
+}
+
+// Subset returns the set of the points with the indices and their weights.
+func (s *Set) Subset(indices []int) *Set {
+	sub := NewWithSize(s.dim, len(indices))
+	for _, i := range indices {
+		sub.AddWeighted(s.At(i), s.Weight(i))
+	}
+
+	return sub
+}
+
+// Standardize returns the copy of the set with every coordinate shifted to zero mean and scaled to unit variance.
+// Constant coordinates are only shifted. The weights are kept but do not affect the mean and the variance.
+func (s *Set) Standardize() *Set {
+	return s.Standardization().Apply(s)
+}
+
+// Scaling is the affine transformation of every coordinate of the points: the shift is subtracted and the difference
+// is divided by the scale.
+type Scaling struct {
+	Shift []float64 `json:"shift"`
+	Scale []float64 `json:"scale"`
+}
+
+// Standardization returns the scaling of the coordinates of the set to zero mean and unit variance, the scale of the
+// constant coordinates is 1.
+func (s *Set) Standardization() *Scaling {
+	sc := &Scaling{Shift: make([]float64, s.dim), Scale: make([]float64, s.dim)}
+
+	n := float64(s.Len())
+	for j := 0; j < s.dim; j++ {
+		mean, sq := 0.0, 0.0
+		for i := j; i < len(s.values); i += s.dim {
+			mean += s.values[i]
+		}
+		mean /= n
+		for i := j; i < len(s.values); i += s.dim {
+			sq += (s.values[i] - mean) * (s.values[i] - mean)
+		}
+
+		sd := math.Sqrt(sq / n)
+		if sd == 0 {
+			sd = 1
+		}
+
+		sc.Shift[j], sc.Scale[j] = mean, sd
+	}
+
+	return sc
+}
+
+// Apply returns the copy of the set of the points scaled, the weights are kept. The scaling must be of the dimension
+// of the set.
+func (sc *Scaling) Apply(s *Set) *Set {
+	scaled := &Set{dim: s.dim, values: make([]float64, len(s.values)), weights: s.weights}
+	for i, v := range s.values {
+		j := i % s.dim
+		scaled.values[i] = (v - sc.Shift[j]) / sc.Scale[j]
+	}
+
+	return scaled
+}
+
+// Bounds returns the lowest and the highest values of every coordinate.
+func (s *Set) Bounds() (low, high []float64) {
+	low, high = make([]float64, s.dim), make([]float64, s.dim)
+	for j := range low {
+		low[j], high[j] = math.Inf(1), math.Inf(-1)
+	}
+
+	for i := 0; i < s.Len(); i++ {
+		for j, v := range s.At(i) {
+			low[j], high[j] = math.Min(low[j], v), math.Max(high[j], v)
+		}
+	}
+
+	return low, high
+}
+
+// MinVariances returns the lowest variances of the distributions fitted to the points by every coordinate: the
+// variance of the uniform distribution over the smallest positive difference between the values of the coordinate, 1/12
+// for the integer locations, or over the unit interval if all the values are equal. It keeps the likelihood of the
+// clusters of equal points finite on the scale of the data, e.g. of the standardized points.
+func (s *Set) MinVariances() []float64 {
+	variances := make([]float64, s.dim)
+	for j := range variances {
+		values := s.Column(j)
+		sort.Float64s(values)
+
+		gap := math.Inf(1)
+		for i := 1; i < len(values); i++ {
+			if d := values[i] - values[i-1]; d > 0 {
+				gap = math.Min(gap, d)
+			}
+		}
+		if math.IsInf(gap, 1) {
+			gap = 1
+		}
+
+		variances[j] = gap * gap / 12
+	}
+
+	return variances
+}
+
+func (s *Set) String() string {
+	b := strings.Builder{}
+	for i := 0; i < s.Len(); i++ {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(fmt.Sprint(s.At(i)))
+	}
+
+	return b.String()
+}
+
+// Iterator yields the points one by one, so that they are consumed without being held all at once. The point returned
+// by Next is valid until the next call, ok is false when the points are over.
+type Iterator interface {
+	Next() (p []float64, ok bool)
+}
+
+// Iterator returns the iterator over the points of the set in their order.
+func (s *Set) Iterator() Iterator {
+	return &setIterator{set: s}
+}
+
+type setIterator struct {
+	set  *Set
+	next int
+}
+
+func (it *setIterator) Next() ([]float64, bool) {
+	if it.next >= it.set.Len() {
+		return nil, false
+	}
+
+	it.next++
+	return it.set.At(it.next - 1), true
+}
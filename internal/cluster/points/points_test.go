@@ -0,0 +1,197 @@
+package points
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/samber/lo"
+)
+
+func TestFromFlat(t *testing.T) {
+	tests := []struct {
+		name    string
+		dim     int
+		values  []float64
+		wantLen int
+		wantErr bool
+	}{
+		{name: "2-D", dim: 2, values: []float64{1, 2, 3, 4}, wantLen: 2},
+		{name: "empty", dim: 3, values: nil, wantLen: 0},
+		{name: "incomplete point", dim: 2, values: []float64{1, 2, 3}, wantErr: true},
+		{name: "zero dimension", dim: 0, values: []float64{1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromFlat(tt.dim, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromFlat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Len() != tt.wantLen {
+				t.Errorf("FromFlat().Len() = %d, want %d", got.Len(), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestSet_Standardize(t *testing.T) {
+	s, _ := FromFlat(2, []float64{1, 5, 3, 5, 5, 5})
+
+	got := s.Standardize()
+
+	want := []float64{-math.Sqrt(1.5), 0, 0, 0, math.Sqrt(1.5), 0}
+	for i, v := range got.Flat() {
+		if math.Abs(v-want[i]) > 1e-9 {
+			t.Fatalf("Standardize() = %v, want %v", got.Flat(), want)
+		}
+	}
+
+	if !reflect.DeepEqual(s.Flat(), []float64{1, 5, 3, 5, 5, 5}) {
+		t.Errorf("Standardize() modified the set: %v", s.Flat())
+	}
+}
+
+func TestScaling_Apply(t *testing.T) {
+	s, _ := FromFlat(2, []float64{1, 5, 3, 5, 5, 5})
+	other, _ := FromFlat(2, []float64{7, 6})
+
+	got := s.Standardization().Apply(other).Flat()
+
+	want := []float64{2 * math.Sqrt(1.5), 1}
+	for i, v := range got {
+		if math.Abs(v-want[i]) > 1e-9 {
+			t.Fatalf("Apply() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSet_MinVariances(t *testing.T) {
+	tests := []struct {
+		name   string
+		dim    int
+		values []float64
+		want   []float64
+	}{
+		{name: "integer locations", dim: 1, values: []float64{3, 10, 4, 7}, want: []float64{1.0 / 12}},
+		{name: "scaled", dim: 2, values: []float64{0, 5, 0.5, 5, 0.25, 5}, want: []float64{0.25 * 0.25 / 12, 1.0 / 12}},
+		{name: "single point", dim: 1, values: []float64{2}, want: []float64{1.0 / 12}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := FromFlat(tt.dim, tt.values)
+			if got := s.MinVariances(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MinVariances() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet_Subset(t *testing.T) {
+	s, _ := FromFlat(2, []float64{1, 2, 3, 4, 5, 6})
+
+	if got := s.Subset([]int{2, 0}).Flat(); !reflect.DeepEqual(got, []float64{5, 6, 1, 2}) {
+		t.Errorf("Subset() = %v, want %v", got, []float64{5, 6, 1, 2})
+	}
+}
+
+func TestSet_WithWeights(t *testing.T) {
+	s, _ := FromFlat(2, []float64{1, 2, 3, 4, 5, 6})
+
+	tests := []struct {
+		name      string
+		weights   []float64
+		wantTotal float64
+		wantErr   bool
+	}{
+		{name: "unweighted", weights: nil, wantTotal: 3},
+		{name: "weighted", weights: []float64{1, 0, 2.5}, wantTotal: 3.5},
+		{name: "wrong length", weights: []float64{1, 2}, wantErr: true},
+		{name: "negative", weights: []float64{1, -1, 2}, wantErr: true},
+		{name: "all zero", weights: []float64{0, 0, 0}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.WithWeights(tt.weights)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithWeights() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.TotalWeight() != tt.wantTotal || got.Weighted() != (tt.weights != nil) {
+				t.Errorf("WithWeights() total weight = %v, weighted = %t", got.TotalWeight(), got.Weighted())
+			}
+			if s.Weighted() {
+				t.Error("WithWeights() modified the set")
+			}
+		})
+	}
+}
+
+func TestSet_AddWeighted(t *testing.T) {
+	s := New(1)
+	s.Add([]float64{1})
+	s.AddWeighted([]float64{2}, 3)
+	s.Add([]float64{3})
+
+	if !reflect.DeepEqual(s.Weights(), []float64{1, 3, 1}) {
+		t.Fatalf("Weights() = %v, want [1 3 1]", s.Weights())
+	}
+	if got := s.Subset([]int{1, 2}).Weights(); !reflect.DeepEqual(got, []float64{3, 1}) {
+		t.Errorf("Subset().Weights() = %v, want [3 1]", got)
+	}
+	if got := s.Standardize().Weights(); !reflect.DeepEqual(got, []float64{1, 3, 1}) {
+		t.Errorf("Standardize().Weights() = %v, want [1 3 1]", got)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	a, b := []float64{0, 0}, []float64{3, 4}
+	tests := []struct {
+		name   string
+		metric Metric
+		want   float64
+	}{
+		{name: "euclidean", metric: Euclidean, want: 5},
+		{name: "manhattan", metric: Manhattan, want: 7},
+		{name: "chebyshev", metric: Chebyshev, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.metric.Distance(a, b); got != tt.want {
+				t.Errorf("%s() = %v, want %v", tt.name, got, tt.want)
+			}
+			if got := tt.metric.Distance([]float64{-2}, []float64{1}); got != 3 {
+				t.Errorf("%s() for 1-D points = %v, want 3", tt.name, got)
+			}
+		})
+	}
+}
+
+func TestMetric_builtIn(t *testing.T) {
+	custom := NewMetric("euclidean", SquaredEuclidean)
+	tests := []struct {
+		name         string
+		metric       Metric
+		wantBuiltIn  bool
+		wantAbsolute bool
+	}{
+		{name: "euclidean", metric: Euclidean, wantBuiltIn: true, wantAbsolute: true},
+		{name: "parsed", metric: lo.Must(ParseMetric("chebyshev")), wantBuiltIn: true, wantAbsolute: true},
+		{name: "custom of built-in name", metric: custom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBuiltIn(tt.metric); got != tt.wantBuiltIn {
+				t.Errorf("IsBuiltIn() = %t, want %t", got, tt.wantBuiltIn)
+			}
+			if got := IsAbsolute(tt.metric); got != tt.wantAbsolute {
+				t.Errorf("IsAbsolute() = %t, want %t", got, tt.wantAbsolute)
+			}
+		})
+	}
+
+	if _, err := ParseMetric("squared"); err == nil {
+		t.Error("ParseMetric() of unknown metric error = nil")
+	}
+}
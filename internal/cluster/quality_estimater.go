@@ -1,8 +1,11 @@
 package cluster
 
 import (
+	"context"
 	"fmt"
-	"math"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/registry"
 )
 
 type QualityEstimationMethod int
@@ -10,90 +13,138 @@ type QualityEstimationMethod int
 const (
 	Silhouette QualityEstimationMethod = iota
 	Elbow
+	DaviesBouldin
+	CalinskiHarabasz
+	Gap
+	BIC
+	AIC
 )
 
-func (t QualityEstimationMethod) String() string {
-	switch t {
-	case Silhouette:
-		return "silhouette"
-	case Elbow:
-		return "elbow"
-	}
-	return "unknown"
+// qualityEstimator scores the clusterings, higher scores are better, so the metrics which are better when lower are
+// negated. Estimators either score every clustering on its own, then the scores are calculated in parallel right
+// after clustering, or compare the clusterings of all the params variations with each other, like the elbow method
+// looking for the knee of the curve. Comparing estimators return the index of the best result along with the scores.
+// The metric is used by the estimators based on distances, the ones based on sums of squares are euclidean. The
+// estimators based on likelihood score the clusterings of the model clusterers with the likelihood of the model.
+type qualityEstimator struct {
+	score      func(data *points.Set, labels []int, metric points.Metric) float64
+	scoreModel func(logLikelihood float64, paramsNum int, n float64) float64
+	compare    func(ctx context.Context, e *evaluation) (scores []float64, best int, err error)
 }
 
-type qualityEstimator func(data []float64, labels []int) float64
+// evaluation holds the results of all the params variations for the comparing estimators.
+type evaluation struct {
+	data *points.Set
+	// results are ordered as the params variations, the ones failed to be evaluated are nil.
+	results []*Result
+	// evaluate clusters other data with the same params variations, the results are ordered the same way.
+	evaluate func(ctx context.Context, data *points.Set) ([]*Result, error)
+}
 
-func getQualityEstimator(t QualityEstimationMethod) qualityEstimator {
-	switch t {
-	case Silhouette:
-		return calculateSilhouette
-	case Elbow:
-		return calcElbow
-	}
-	return nil
+// ScoreFunc scores the clustering of the data by the labels, higher scores are better. The noise points are labeled
+// as a cluster of their own, the metric is the distance between the points.
+type ScoreFunc func(data *points.Set, labels []int, metric points.Metric) float64
+
+// QualityEstimatorInfo describes the registered quality estimation method.
+type QualityEstimatorInfo struct {
+	Method      QualityEstimationMethod
+	Name        string
+	Description string
+	// Comparing is set for the methods comparing the clusterings of all the params variations with each other
+	// instead of scoring every clustering on its own.
+	Comparing bool
+	estimator qualityEstimator
 }
 
-func calcAvgDistOwn(p float64, cluster []float64) float64 {
-	if len(cluster) == 1 {
-		return 0.0
-	}
+// qualityEstimators holds the registered quality estimation methods, the ids are the methods.
+var qualityEstimators registry.Registry[QualityEstimatorInfo]
+
+func init() {
+	mustRegisterQualityEstimator(Silhouette, "silhouette", "mean silhouette of the entries", qualityEstimator{score: calculateSilhouette})
+	mustRegisterQualityEstimator(Elbow, "elbow", "knee of the within-cluster sum of squares curve", qualityEstimator{compare: calcElbow})
+	mustRegisterQualityEstimator(DaviesBouldin, "davies-bouldin", "negated Davies-Bouldin index", qualityEstimator{score: calcDaviesBouldin})
+	mustRegisterQualityEstimator(CalinskiHarabasz, "calinski-harabasz", "Calinski-Harabasz index", qualityEstimator{score: calcCalinskiHarabasz})
+	mustRegisterQualityEstimator(Gap, "gap", "gap statistic against the uniform reference data", qualityEstimator{compare: calcGap})
+	mustRegisterQualityEstimator(BIC, "bic", "negated bayesian information criterion", qualityEstimator{score: calcBIC, scoreModel: bic})
+	mustRegisterQualityEstimator(AIC, "aic", "negated Akaike information criterion", qualityEstimator{score: calcAIC, scoreModel: aic})
+}
+
+// RegisterQualityEstimator registers the quality estimation method scoring every clustering on its own by its name and
+// returns the method, so that it could be chosen by the name like the built-in ones. It fails if the name is already
+// registered.
+func RegisterQualityEstimator(name, description string, score ScoreFunc) (QualityEstimationMethod, error) {
+	return registerQualityEstimator(name, description, qualityEstimator{score: score})
+}
 
-	sumDistance := 0.0
-	for _, otherPoint := range cluster {
-		sumDistance += math.Abs(p - otherPoint)
+func registerQualityEstimator(name, description string, e qualityEstimator) (QualityEstimationMethod, error) {
+	id, err := qualityEstimators.Register(name, QualityEstimatorInfo{Name: name, Description: description, Comparing: e.compare != nil, estimator: e})
+	if err != nil {
+		return 0, fmt.Errorf("register quality estimator: %w", err)
 	}
 
-	return sumDistance / float64(len(cluster)-1)
+	return QualityEstimationMethod(id), nil
+}
+
+func mustRegisterQualityEstimator(m QualityEstimationMethod, name, description string, e qualityEstimator) {
+	if registered, err := registerQualityEstimator(name, description, e); err != nil || registered != m {
+		panic(fmt.Sprintf("register built-in quality estimator %s: %v", name, err))
+	}
 }
 
-func calcAvgDistOther(p float64, cluster []float64) float64 {
-	sumDistance := 0.0
-	for _, otherPoint := range cluster {
-		sumDistance += math.Abs(p - otherPoint)
+// QualityEstimators returns all the registered quality estimation methods.
+func QualityEstimators() []QualityEstimatorInfo {
+	infos := qualityEstimators.Entries()
+	for i := range infos {
+		infos[i].Method = QualityEstimationMethod(i)
 	}
 
-	return sumDistance / float64(len(cluster))
+	return infos
 }
 
-// calculateSilhouetteScore calculates the silhouette score for each point and returns the average score.
-func calculateSilhouette(data []float64, labels []int) float64 {
-	// create clusters from labels
-	clusters := make(map[int][]float64)
-	for i, label := range labels {
-		clusters[label] = append(clusters[label], data[i])
+// QualityEstimationMethods returns all the registered quality estimation methods.
+func QualityEstimationMethods() []QualityEstimationMethod {
+	methods := make([]QualityEstimationMethod, len(qualityEstimators.Names()))
+	for i := range methods {
+		methods[i] = QualityEstimationMethod(i)
 	}
 
-	totalScore := 0.0
-	for i, point := range data {
-		// calculate a(i)
-		a := calcAvgDistOwn(point, clusters[labels[i]])
-
-		// calculate b(i)
-		b := math.Inf(1)
-		for label, cluster := range clusters {
-			if label == labels[i] {
-				continue // skip own cluster
-			}
-
-			dist := calcAvgDistOther(point, cluster)
-			if dist < b {
-				b = dist
-			}
-		}
+	return methods
+}
 
-		// calculate silhouette score for point i
-		si := (b - a) / math.Max(a, b)
+func (t QualityEstimationMethod) String() string {
+	if name := qualityEstimators.Name(int(t)); name != "" {
+		return name
+	}
+	return "unknown"
+}
 
-		fmt.Printf("si: for point %.2f from cluster %.2f : %.2f\n", point, clusters[labels[i]], si)
+// ParseQualityEstimationMethod returns the quality estimation method by its name.
+func ParseQualityEstimationMethod(s string) (QualityEstimationMethod, error) {
+	if id, ok := qualityEstimators.Lookup(s); ok {
+		return QualityEstimationMethod(id), nil
+	}
+	return 0, fmt.Errorf("unknown quality estimation method: %s", s)
+}
 
-		totalScore += si
+// getQualityEstimator returns the copy of the estimator of the method, nil if the method is unknown.
+func getQualityEstimator(t QualityEstimationMethod) *qualityEstimator {
+	info, ok := qualityEstimators.Get(int(t))
+	if !ok {
+		return nil
 	}
 
-	// return average silhouette score
-	return totalScore / float64(len(data))
+	e := info.estimator
+	return &e
 }
 
-func calcElbow(data []float64, labels []int) float64 {
-	return 0.0
+// argmax returns the index of the best score, the first one of the equal scores.
+func argmax(scores []float64) int {
+	best := -1
+	for i, score := range scores {
+		if best < 0 || isBetterScore(score, scores[best]) {
+			best = i
+		}
+	}
+
+	return best
 }
@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/ckmeans"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+func TestRegister(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	clusterer, err := RegisterClusterer("test-ckmeans", "ckmeans registered by the test", func() Clusterer { return new(ckmeans.Ckmeans) })
+	if err != nil {
+		t.Fatalf("RegisterClusterer() error = %v", err)
+	}
+	// the fewest clusters are the best
+	method, err := RegisterQualityEstimator("test-fewest", "fewest clusters", func(_ *points.Set, labels []int, _ points.Metric) float64 {
+		return -float64(countClusters(labels))
+	})
+	if err != nil {
+		t.Fatalf("RegisterQualityEstimator() error = %v", err)
+	}
+
+	if _, err := RegisterClusterer("kmeans", "", nil); err == nil {
+		t.Error("RegisterClusterer() error = nil for the built-in name")
+	}
+	if _, err := RegisterQualityEstimator("test-fewest", "", nil); err == nil {
+		t.Error("RegisterQualityEstimator() error = nil for the registered name")
+	}
+
+	parsedClusterer, err := ParseClustererType("test-ckmeans")
+	if err != nil || parsedClusterer != clusterer || clusterer.String() != "test-ckmeans" {
+		t.Fatalf("ParseClustererType() = %s, %v, want %s", parsedClusterer, err, clusterer)
+	}
+	parsedMethod, err := ParseQualityEstimationMethod("test-fewest")
+	if err != nil || parsedMethod != method || method.String() != "test-fewest" {
+		t.Fatalf("ParseQualityEstimationMethod() = %s, %v, want %s", parsedMethod, err, method)
+	}
+
+	res, err := New(parsedClusterer, parsedMethod).WithReportedMethods(Silhouette).Clusterize(ctx, points.FromValues([]float64{1, 2, 3, 10, 11, 12}))
+	if err != nil {
+		t.Fatalf("Clusterizer.Clusterize() error = %v", err)
+	}
+	if res.ClustersNum != 1 {
+		t.Errorf("Clusterizer.Clusterize() clusters = %d, want 1", res.ClustersNum)
+	}
+
+	for _, info := range Clusterers() {
+		if info.Type == clusterer {
+			space, err := info.Params(ctx, 10)
+			if err != nil || space.Format(space.Defaults()) != "k=1" {
+				t.Errorf("ClustererInfo.Params() = %v, %v", space, err)
+			}
+			return
+		}
+	}
+	t.Error("Clusterers() lacks the registered clusterer")
+}
@@ -0,0 +1,325 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+// SearchStrategy defines which of the params variations are evaluated to find the best clustering.
+type SearchStrategy int
+
+const (
+	// GridSearch evaluates all the params variations.
+	GridSearch SearchStrategy = iota
+	// RandomSearch evaluates the params variations in random order until the budget is exhausted.
+	RandomSearch
+	// SuccessiveHalving evaluates the params variations on the growing subsamples of the data, halving them by the
+	// score after every round, the rest is evaluated on the whole data.
+	SuccessiveHalving
+	// GoldenSection searches for the best value of the first param, e.g. the number of clusters, by golden section
+	// assuming the score is unimodal in it. The variations of the other params are evaluated for every probed value.
+	GoldenSection
+)
+
+// SearchStrategies lists all the search strategies.
+var SearchStrategies = []SearchStrategy{GridSearch, RandomSearch, SuccessiveHalving, GoldenSection}
+
+func (s SearchStrategy) String() string {
+	switch s {
+	case GridSearch:
+		return "grid"
+	case RandomSearch:
+		return "random"
+	case SuccessiveHalving:
+		return "halving"
+	case GoldenSection:
+		return "golden"
+	}
+	return "unknown"
+}
+
+// ParseSearchStrategy returns the search strategy by its name.
+func ParseSearchStrategy(s string) (SearchStrategy, error) {
+	for _, st := range SearchStrategies {
+		if st.String() == s {
+			return st, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown search strategy: %s", s)
+}
+
+const (
+	// maxHalvingRounds limits the number of the rounds of successive halving, the first round is evaluated on the
+	// 2^(maxHalvingRounds-1)-th part of the data.
+	maxHalvingRounds = 4
+	// minHalvingSample is the minimal size of the subsample the variations are evaluated on by successive halving.
+	minHalvingSample = 10
+)
+
+// errBudgetExhausted is the cause of the search context cancellation when the wall-clock budget is exhausted.
+var errBudgetExhausted = errors.New("search budget exhausted")
+
+// budget counts the evaluations left, negative for unlimited.
+type budget struct {
+	left int
+}
+
+// take returns how many of the n evaluations could be done and takes them from the budget.
+func (b *budget) take(n int) int {
+	if b.left < 0 {
+		return n
+	}
+
+	n = min(n, b.left)
+	b.left -= n
+	return n
+}
+
+// search evaluates the params variations chosen by the search strategy within the budget. The results are ordered as
+// the variations, the ones not evaluated or failed to be set are nil.
+func (c *Clusterizer) search(ctx context.Context, data *points.Set, variations []params.Values, estimators []estimator) ([]*Result, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, c.timeout, errBudgetExhausted)
+		defer cancel()
+	}
+
+	b := &budget{left: -1}
+	if c.maxEvaluations > 0 {
+		b.left = c.maxEvaluations
+	}
+
+	logger.MustFromContext(ctx).Debugf("searching %d params variations by %s", len(variations), c.searchStrategy)
+
+	results := make([]*Result, len(variations))
+	rnd := rand.New(rand.NewSource(c.seed))
+
+	var err error
+	switch c.searchStrategy {
+	case GridSearch:
+		err = c.evaluateAt(ctx, data, variations, seq(b.take(len(variations))), estimators, results)
+	case RandomSearch:
+		indices := rnd.Perm(len(variations))[:b.take(len(variations))]
+		sort.Ints(indices)
+		err = c.evaluateAt(ctx, data, variations, indices, estimators, results)
+	case SuccessiveHalving:
+		err = c.halve(ctx, data, variations, estimators, b, rnd, results)
+	case GoldenSection:
+		err = c.goldenSection(ctx, data, variations, estimators, b, results)
+	default:
+		return nil, fmt.Errorf("unknown search strategy: %s", c.searchStrategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if context.Cause(ctx) == errBudgetExhausted {
+		if !slices.ContainsFunc(results, func(res *Result) bool { return res != nil }) {
+			return nil, fmt.Errorf("no params variations evaluated within %s", c.timeout)
+		}
+
+		logger.MustFromContext(ctx).Debugf("search stopped after %s", c.timeout)
+	}
+
+	return results, nil
+}
+
+// evaluateAt evaluates the variations at the indices and stores the results at the same indices. The evaluations
+// interrupted by the exhausted budget are left nil.
+func (c *Clusterizer) evaluateAt(ctx context.Context, data *points.Set, variations []params.Values, indices []int, estimators []estimator, results []*Result) error {
+	if len(indices) == 0 || ctx.Err() != nil && context.Cause(ctx) == errBudgetExhausted {
+		return nil
+	}
+
+	picked := make([]params.Values, len(indices))
+	for i, idx := range indices {
+		picked[i] = variations[idx]
+	}
+
+	res, err := c.evaluate(ctx, data, picked, estimators)
+	if err != nil && context.Cause(ctx) != errBudgetExhausted {
+		return err
+	}
+
+	for i, idx := range indices {
+		if res != nil {
+			results[idx] = res[i]
+		}
+	}
+
+	return nil
+}
+
+// halve runs successive halving over the variations: every round evaluates the remaining variations on the subsample
+// twice as large as the previous one and keeps the better half of them, the last round is evaluated on the whole data.
+// The number of the variations taken into the first round is limited so that all the rounds fit the budget.
+func (c *Clusterizer) halve(ctx context.Context, data *points.Set, variations []params.Values, estimators []estimator, b *budget, rnd *rand.Rand, results []*Result) error {
+	candidates := rnd.Perm(len(variations))
+	for len(candidates) > 0 && b.left >= 0 && halvingCost(len(candidates), halvingRounds(len(candidates), data.Len())) > b.left {
+		candidates = candidates[:len(candidates)-1]
+	}
+	sort.Ints(candidates)
+
+	rounds := halvingRounds(len(candidates), data.Len())
+	for r := 0; r < rounds-1; r++ {
+		step := 1 << (rounds - 1 - r)
+		indices := make([]int, 0, data.Len()/step+1)
+		for i := 0; i < data.Len(); i += step {
+			indices = append(indices, i)
+		}
+		sample := data.Subset(indices)
+
+		logger.MustFromContext(ctx).Debugf("halving round %d: %d params variations on %d points", r, len(candidates), sample.Len())
+
+		roundResults := make([]*Result, len(variations))
+		if err := c.evaluateAt(ctx, sample, variations, candidates[:b.take(len(candidates))], estimators[:1], roundResults); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		picked := make([]params.Values, len(candidates))
+		pickedResults := make([]*Result, len(candidates))
+		for i, idx := range candidates {
+			picked[i], pickedResults[i] = variations[idx], roundResults[idx]
+		}
+		if _, err := c.choose(ctx, sample, picked, pickedResults, estimators[:1]); err != nil {
+			return err
+		}
+
+		// the failed variations go last, the equally scored ones keep their order
+		order := seq(len(candidates))
+		sort.SliceStable(order, func(i, j int) bool {
+			a, b := pickedResults[order[i]], pickedResults[order[j]]
+			if a == nil || b == nil {
+				return a != nil
+			}
+			return isBetterScore(a.Scores[c.qualityEstimationMethod], b.Scores[c.qualityEstimationMethod])
+		})
+
+		kept := make([]int, 0, (len(candidates)+1)/2)
+		for _, i := range order[:(len(candidates)+1)/2] {
+			kept = append(kept, candidates[i])
+		}
+		sort.Ints(kept)
+		candidates = kept
+	}
+
+	return c.evaluateAt(ctx, data, variations, candidates[:b.take(len(candidates))], estimators, results)
+}
+
+// halvingRounds returns the number of the rounds of successive halving of n variations over the data of the size.
+func halvingRounds(n, size int) int {
+	rounds := 1
+	for n > 1<<(rounds-1) && rounds < maxHalvingRounds && size>>rounds >= minHalvingSample {
+		rounds++
+	}
+
+	return rounds
+}
+
+// halvingCost returns the number of the evaluations of successive halving of n variations in the rounds.
+func halvingCost(n, rounds int) int {
+	cost := 0
+	for r := 0; r < rounds; r++ {
+		cost += n
+		n = (n + 1) / 2
+	}
+
+	return cost
+}
+
+// goldenSection searches for the value of the first param with the best score by golden section. The score of the
+// value is the best score of the variations with it.
+func (c *Clusterizer) goldenSection(ctx context.Context, data *points.Set, variations []params.Values, estimators []estimator, b *budget, results []*Result) error {
+	if estimators[0].score == nil && estimators[0].scoreModel == nil {
+		return fmt.Errorf("%s search needs the clusterings scored on their own, %s compares them", GoldenSection, c.qualityEstimationMethod)
+	}
+
+	groups := make(map[float64][]int)
+	for i, v := range variations {
+		groups[v[0]] = append(groups[v[0]], i)
+	}
+
+	values := make([]float64, 0, len(groups))
+	for v := range groups {
+		values = append(values, v)
+	}
+	sort.Float64s(values)
+
+	scores := make(map[int]float64, len(values))
+	// probe evaluates the not yet evaluated values at the positions, false is returned when the search should stop
+	probe := func(positions ...int) (bool, error) {
+		var indices []int
+		for _, p := range positions {
+			if _, ok := scores[p]; ok {
+				continue
+			}
+			indices = append(indices, groups[values[p]]...)
+			scores[p] = math.NaN()
+		}
+
+		n := b.take(len(indices))
+		if err := c.evaluateAt(ctx, data, variations, indices[:n], estimators, results); err != nil {
+			return false, err
+		}
+
+		for _, p := range positions {
+			for _, idx := range groups[values[p]] {
+				if res := results[idx]; res != nil && isBetterScore(res.Scores[c.qualityEstimationMethod], scores[p]) {
+					scores[p] = res.Scores[c.qualityEstimationMethod]
+				}
+			}
+		}
+
+		return n == len(indices) && ctx.Err() == nil, nil
+	}
+
+	low, high := 0, len(values)-1
+	for high-low > 2 {
+		m1 := low + int(math.Round(float64(high-low)*(1-invPhi)))
+		m2 := low + int(math.Round(float64(high-low)*invPhi))
+		if m1 == m2 {
+			m2++
+		}
+
+		if ok, err := probe(m1, m2); err != nil || !ok {
+			return err
+		}
+
+		logger.MustFromContext(ctx).Tracef("golden section probed %g: %.2f, %g: %.2f", values[m1], scores[m1], values[m2], scores[m2])
+
+		// the smaller values win the ties
+		if isBetterScore(scores[m2], scores[m1]) {
+			low = m1 + 1
+		} else {
+			high = m2 - 1
+		}
+	}
+
+	_, err := probe(seq(high + 1)[low:]...)
+	return err
+}
+
+// invPhi is the inverse of the golden ratio.
+var invPhi = (math.Sqrt(5) - 1) / 2
+
+// seq returns the indices from 0 to n exclusive.
+func seq(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+
+	return s
+}
@@ -0,0 +1,82 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+func TestClusterizer_Clusterize_search(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	var values []float64
+	for _, center := range []float64{0, 100, 200} {
+		for i := -10; i <= 10; i++ {
+			values = append(values, center+float64(i))
+		}
+	}
+	data := points.FromValues(values)
+
+	tests := []struct {
+		name            string
+		strategy        SearchStrategy
+		method          QualityEstimationMethod
+		maxEvaluations  int
+		wantClustersNum int
+		wantEvaluated   int
+		wantErr         bool
+	}{
+		{name: "grid", strategy: GridSearch, method: Silhouette, wantClustersNum: 3, wantEvaluated: 31},
+		{name: "grid with budget", strategy: GridSearch, method: Silhouette, maxEvaluations: 2, wantClustersNum: 2, wantEvaluated: 2},
+		{name: "random", strategy: RandomSearch, method: Silhouette, wantClustersNum: 3, wantEvaluated: 31},
+		{name: "halving", strategy: SuccessiveHalving, method: Silhouette, wantClustersNum: 3, wantEvaluated: 8},
+		{name: "halving by elbow", strategy: SuccessiveHalving, method: Elbow, wantClustersNum: 3, wantEvaluated: 8},
+		{name: "golden", strategy: GoldenSection, method: Silhouette, wantClustersNum: 3, wantEvaluated: 9},
+		{name: "golden by elbow", strategy: GoldenSection, method: Elbow, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := New(Ckmeans, tt.method).
+				WithSearchStrategy(tt.strategy).
+				WithBudget(tt.maxEvaluations, 0).
+				Clusterize(ctx, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Clusterizer.Clusterize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if res.ClustersNum != tt.wantClustersNum {
+				t.Errorf("Clusterizer.Clusterize() clusters = %d, want %d", res.ClustersNum, tt.wantClustersNum)
+			}
+			if len(res.Candidates) != tt.wantEvaluated {
+				t.Errorf("Clusterizer.Clusterize() evaluated %d variations, want %d", len(res.Candidates), tt.wantEvaluated)
+			}
+		})
+	}
+}
+
+func Test_halvingCost(t *testing.T) {
+	tests := []struct {
+		n, size    int
+		wantRounds int
+		wantCost   int
+	}{
+		{n: 1, size: 1000, wantRounds: 1, wantCost: 1},
+		{n: 8, size: 1000, wantRounds: 4, wantCost: 15},
+		{n: 100, size: 1000, wantRounds: 4, wantCost: 188},
+		{n: 100, size: 30, wantRounds: 2, wantCost: 150},
+	}
+	for _, tt := range tests {
+		rounds := halvingRounds(tt.n, tt.size)
+		if rounds != tt.wantRounds {
+			t.Errorf("halvingRounds(%d, %d) = %d, want %d", tt.n, tt.size, rounds, tt.wantRounds)
+		}
+		if cost := halvingCost(tt.n, rounds); cost != tt.wantCost {
+			t.Errorf("halvingCost(%d, %d) = %d, want %d", tt.n, rounds, cost, tt.wantCost)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+)
+
+// calculateSilhouette returns the weighted mean silhouette of the points.
+func calculateSilhouette(data *points.Set, labels []int, metric points.Metric) float64 {
+	return meanSilhouette(data, silhouettes(data, labels, metric, nil))
+}
+
+// sampledSilhouette returns the estimator of the mean silhouette of the random sample of the points of the size. The
+// sampled points are scored against all the points, so the estimate costs O(size·n) distances instead of O(n²).
+func sampledSilhouette(size int, seed int64) func(data *points.Set, labels []int, metric points.Metric) float64 {
+	return func(data *points.Set, labels []int, metric points.Metric) float64 {
+		return meanSilhouette(data, silhouettes(data, labels, metric, silhouetteSample(data, metric, size, seed)))
+	}
+}
+
+// silhouetteSample returns the indices of the random sample of the points of the size, nil for all the points if the
+// size is not positive or not less than the number of the points. The 1-D points with the absolute difference
+// distance are not sampled, as scoring them all is cheaper than the sampling.
+func silhouetteSample(data *points.Set, metric points.Metric, size int, seed int64) []int {
+	if size <= 0 || size >= data.Len() || (data.Dim() == 1 && points.IsAbsolute(metric)) {
+		return nil
+	}
+
+	return rand.New(rand.NewSource(seed)).Perm(data.Len())[:size]
+}
+
+// meanSilhouette returns the mean of the silhouettes of the points weighted by their weights, NaN ones are skipped.
+func meanSilhouette(data *points.Set, s []float64) float64 {
+	sum, total := 0.0, 0.0
+	for i, v := range s {
+		if !math.IsNaN(v) {
+			sum += data.Weight(i) * v
+			total += data.Weight(i)
+		}
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+
+	return sum / total
+}
+
+// silhouettes returns the silhouettes of the points with the indices, all the points if the indices are nil, the rest
+// are NaN. The silhouette of a point is (b - a) / max(a, b), a is the mean distance to the other points of its
+// cluster (0 for the single point), b is the lowest mean distance to the points of another cluster, the means are
+// weighted by the weights of the points. The noise points and the points of a single cluster are NaN. The 1-D points
+// with the absolute difference distance are scored by the prefix sums of the sorted clusters, in O(n log n) if the
+// clusters occupy disjoint intervals and in O(n log n + nk) otherwise, the others in O(n) distances per point.
+func silhouettes(data *points.Set, labels []int, metric points.Metric, indices []int) []float64 {
+	if indices == nil {
+		indices = make([]int, data.Len())
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+
+	s := make([]float64, data.Len())
+	for i := range s {
+		s[i] = math.NaN()
+	}
+
+	// clusters are numbered densely, so that the sums are slices
+	ids := make(map[int]int)
+	for _, l := range labels {
+		if _, ok := ids[l]; !ok && l != Noise {
+			ids[l] = len(ids)
+		}
+	}
+	if len(ids) < 2 {
+		return s
+	}
+
+	// weights are the total weights of the clusters
+	weights := make([]float64, len(ids))
+	for i, l := range labels {
+		if l != Noise {
+			weights[ids[l]] += data.Weight(i)
+		}
+	}
+
+	// the sums are the weighted sums of the distances to the points of every cluster, the ones of the own cluster and
+	// of the near clusters are set, the nearest cluster is among the near ones
+	score := func(i int, sums []float64, near []int) {
+		own := ids[labels[i]]
+		a := 0.0
+		if others := weights[own] - data.Weight(i); others > 0 {
+			a = sums[own] / others
+		}
+
+		b := math.Inf(1)
+		for _, c := range near {
+			if c != own && weights[c] > 0 {
+				b = math.Min(b, sums[c]/weights[c])
+			}
+		}
+		if math.IsInf(b, 1) {
+			return
+		}
+
+		if m := math.Max(a, b); m > 0 {
+			s[i] = (b - a) / m
+		} else {
+			s[i] = 0
+		}
+	}
+
+	if data.Dim() == 1 && points.IsAbsolute(metric) {
+		silhouettes1D(data, labels, ids, indices, score)
+		return s
+	}
+
+	all := make([]int, len(ids))
+	for c := range all {
+		all[c] = c
+	}
+
+	sums := make([]float64, len(ids))
+	for _, i := range indices {
+		if labels[i] == Noise {
+			continue
+		}
+
+		for c := range sums {
+			sums[c] = 0
+		}
+		for j, l := range labels {
+			if l != Noise {
+				sums[ids[l]] += data.Weight(j) * metric.Distance(data.At(i), data.At(j))
+			}
+		}
+		score(i, sums, all)
+	}
+
+	return s
+}
+
+// silhouettes1D scores the points with the indices by the weighted sums of the absolute differences to the points of
+// the clusters. The points are visited in ascending order, so the number of the points of every cluster below the
+// current one only grows and the sums are taken from the prefix sums of the sorted clusters without a search. When the
+// clusters occupy disjoint intervals, as the ones of k-means do, the mean distance to a cluster grows with the number
+// of the intervals between it and the point, so only the adjacent clusters are compared and the points are scored in
+// O(n log n), otherwise in O(n log n + nk).
+func silhouettes1D(data *points.Set, labels []int, ids map[int]int, indices []int, score func(i int, sums []float64, near []int)) {
+	values := data.Flat()
+
+	// members are the indices of the points of every cluster sorted by their values
+	members := make([][]int, len(ids))
+	for i, l := range labels {
+		if l != Noise {
+			members[ids[l]] = append(members[ids[l]], i)
+		}
+	}
+
+	// the prefix sums of the weights and of the weighted values
+	prefixWeights := make([][]float64, len(ids))
+	prefixValues := make([][]float64, len(ids))
+	for c, m := range members {
+		sort.Slice(m, func(a, b int) bool { return values[m[a]] < values[m[b]] })
+		prefixWeights[c] = make([]float64, len(m)+1)
+		prefixValues[c] = make([]float64, len(m)+1)
+		for j, i := range m {
+			prefixWeights[c][j+1] = prefixWeights[c][j] + data.Weight(i)
+			prefixValues[c][j+1] = prefixValues[c][j] + data.Weight(i)*values[i]
+		}
+	}
+
+	weights := make([]float64, len(ids))
+	for c, w := range prefixWeights {
+		weights[c] = w[len(w)-1]
+	}
+	near := nearClusters(values, members, weights)
+
+	order := make([]int, 0, len(indices))
+	for _, i := range indices {
+		if labels[i] != Noise {
+			order = append(order, i)
+		}
+	}
+	sort.Slice(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+
+	below := make([]int, len(ids))
+	sums := make([]float64, len(ids))
+	sum := func(c int, x float64) {
+		m := members[c]
+		for below[c] < len(m) && values[m[below[c]]] < x {
+			below[c]++
+		}
+
+		left, n := below[c], len(m)
+		w, v := prefixWeights[c], prefixValues[c]
+		sums[c] = x*w[left] - v[left] + (v[n] - v[left]) - x*(w[n]-w[left])
+	}
+
+	for _, i := range order {
+		x, own := values[i], ids[labels[i]]
+		sum(own, x)
+		for _, c := range near[own] {
+			sum(c, x)
+		}
+		score(i, sums, near[own])
+	}
+}
+
+// nearClusters returns the clusters the nearest cluster of the points of every cluster is among by the sorted members
+// and the total weights of the clusters: the closest ones of positive weight on both sides if the clusters occupy
+// disjoint intervals, all the others otherwise.
+func nearClusters(values []float64, members [][]int, weights []float64) [][]int {
+	byMin := make([]int, len(members))
+	for c := range byMin {
+		byMin[c] = c
+	}
+	sort.Slice(byMin, func(a, b int) bool { return values[members[byMin[a]][0]] < values[members[byMin[b]][0]] })
+
+	disjoint := true
+	for j := 1; j < len(byMin) && disjoint; j++ {
+		prev := members[byMin[j-1]]
+		disjoint = values[prev[len(prev)-1]] <= values[members[byMin[j]][0]]
+	}
+
+	near := make([][]int, len(members))
+	if !disjoint {
+		for c := range near {
+			for other := range members {
+				if other != c {
+					near[c] = append(near[c], other)
+				}
+			}
+		}
+
+		return near
+	}
+
+	// the passes in both directions carry the last cluster of positive weight
+	last := -1
+	for _, c := range byMin {
+		if last >= 0 {
+			near[c] = append(near[c], last)
+		}
+		if weights[c] > 0 {
+			last = c
+		}
+	}
+	last = -1
+	for j := len(byMin) - 1; j >= 0; j-- {
+		c := byMin[j]
+		if last >= 0 {
+			near[c] = append(near[c], last)
+		}
+		if weights[c] > 0 {
+			last = c
+		}
+	}
+
+	return near
+}
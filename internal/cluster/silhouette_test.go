@@ -0,0 +1,141 @@
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+)
+
+// naiveSilhouettes scores every point by the definition with the means weighted by the weights of the points.
+func naiveSilhouettes(data *points.Set, labels []int, metric points.Metric) []float64 {
+	s := make([]float64, data.Len())
+	for i := range s {
+		s[i] = math.NaN()
+		if labels[i] == Noise {
+			continue
+		}
+
+		sums, weights := map[int]float64{}, map[int]float64{}
+		for j, l := range labels {
+			if l != Noise {
+				sums[l] += data.Weight(j) * metric.Distance(data.At(i), data.At(j))
+				weights[l] += data.Weight(j)
+			}
+		}
+		if len(weights) < 2 {
+			continue
+		}
+
+		a := 0.0
+		if others := weights[labels[i]] - data.Weight(i); others > 0 {
+			a = sums[labels[i]] / others
+		}
+		b := math.Inf(1)
+		for l, sum := range sums {
+			if l != labels[i] && weights[l] > 0 {
+				b = math.Min(b, sum/weights[l])
+			}
+		}
+		if math.IsInf(b, 1) {
+			continue
+		}
+		if m := math.Max(a, b); m > 0 {
+			s[i] = (b - a) / m
+		} else {
+			s[i] = 0
+		}
+	}
+
+	return s
+}
+
+func TestSilhouettes(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := func(n, dim, clusters int, weighted, contiguous bool) (*points.Set, []int) {
+		values := make([]float64, n*dim)
+		for i := range values {
+			values[i] = math.Round(r.Float64() * 50)
+		}
+		labels := make([]int, n)
+		ids := r.Perm(clusters)
+		for i := range labels {
+			labels[i] = r.Intn(clusters+1) - 1
+			// the clusters of the contiguous labels are the bands of the values, the ids are not in their order
+			if contiguous && labels[i] != Noise {
+				labels[i] = ids[int(values[i])*clusters/51]
+			}
+		}
+		data, _ := points.FromFlat(dim, values)
+		if weighted {
+			weights := make([]float64, n)
+			for i := range weights {
+				weights[i] = float64(r.Intn(4))
+			}
+			weights[0] = 1
+			data, _ = data.WithWeights(weights)
+		}
+
+		return data, labels
+	}
+
+	tests := []struct {
+		name       string
+		dim        int
+		clusters   int
+		metric     points.Metric
+		weighted   bool
+		contiguous bool
+	}{
+		{name: "1-D euclidean", dim: 1, clusters: 4, metric: points.Euclidean},
+		{name: "1-D manhattan", dim: 1, clusters: 2, metric: points.Manhattan},
+		{name: "1-D squared", dim: 1, clusters: 3, metric: points.NewMetric("squared-euclidean", points.SquaredEuclidean)},
+		{name: "2-D euclidean", dim: 2, clusters: 3, metric: points.Euclidean},
+		{name: "single cluster", dim: 1, clusters: 1, metric: points.Euclidean},
+		{name: "1-D weighted", dim: 1, clusters: 3, metric: points.Euclidean, weighted: true},
+		{name: "2-D weighted", dim: 2, clusters: 3, metric: points.Manhattan, weighted: true},
+		{name: "1-D contiguous", dim: 1, clusters: 5, metric: points.Euclidean, contiguous: true},
+		{name: "1-D weighted contiguous", dim: 1, clusters: 3, metric: points.Manhattan, weighted: true, contiguous: true},
+		{name: "1-D contiguous of zero weights", dim: 1, clusters: 12, metric: points.Euclidean, weighted: true, contiguous: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, n := range []int{1, 2, 7, 200} {
+				data, labels := random(n, tt.dim, tt.clusters, tt.weighted, tt.contiguous)
+				got := silhouettes(data, labels, tt.metric, nil)
+				want := naiveSilhouettes(data, labels, tt.metric)
+				for i := range want {
+					if math.IsNaN(got[i]) != math.IsNaN(want[i]) || math.Abs(got[i]-want[i]) > 1e-9 {
+						t.Fatalf("silhouettes() of %d points [%d] = %v, want %v", n, i, got[i], want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSampledSilhouette(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 2*600)
+	labels := make([]int, 600)
+	for i := range labels {
+		labels[i] = i % 3
+		values[2*i], values[2*i+1] = float64(labels[i]*10)+r.NormFloat64(), r.NormFloat64()
+	}
+	data, _ := points.FromFlat(2, values)
+
+	exact := calculateSilhouette(data, labels, points.Euclidean)
+	if got := sampledSilhouette(100, 1)(data, labels, points.Euclidean); math.Abs(got-exact) > 0.05 {
+		t.Errorf("sampledSilhouette() = %v, want about %v", got, exact)
+	}
+	if got := sampledSilhouette(0, 1)(data, labels, points.Euclidean); got != exact {
+		t.Errorf("sampledSilhouette() of no sample = %v, want %v", got, exact)
+	}
+
+	// the 1-D locations are scored exactly whatever the sample is
+	column := points.FromValues(data.Column(0))
+	if got, want := sampledSilhouette(100, 1)(column, labels, points.Euclidean), calculateSilhouette(column, labels, points.Euclidean); got != want {
+		t.Errorf("sampledSilhouette() of 1-D points = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,355 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/boson-research/patterns/internal/cluster/hierarchical"
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// ResamplingMethod defines how the resamples of the points the stability is measured on are drawn.
+type ResamplingMethod int
+
+const (
+	// Bootstrap draws as many points as there are with replacement.
+	Bootstrap ResamplingMethod = iota
+	// Subsampling draws the fraction of the points without replacement.
+	Subsampling
+)
+
+// ResamplingMethods lists all the resampling methods.
+var ResamplingMethods = []ResamplingMethod{Bootstrap, Subsampling}
+
+func (m ResamplingMethod) String() string {
+	switch m {
+	case Bootstrap:
+		return "bootstrap"
+	case Subsampling:
+		return "subsample"
+	}
+	return "unknown"
+}
+
+// ParseResamplingMethod returns the resampling method by its name.
+func ParseResamplingMethod(s string) (ResamplingMethod, error) {
+	for _, m := range ResamplingMethods {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown resampling method: %s", s)
+}
+
+const (
+	// DefaultResamples is the default number of the resamples the stability is measured on.
+	DefaultResamples = 20
+	// DefaultSampleFraction is the default fraction of the points drawn by the subsampling.
+	DefaultSampleFraction = 0.8
+)
+
+// MaxStabilityPoints is the largest number of the points the stability is measured for. The co-association of the
+// pairs of the points and the tree of their consensus take 8·n² bytes, 200 MB for MaxStabilityPoints.
+const MaxStabilityPoints = 5000
+
+// notSampled is the label of the points missing from a resample.
+const notSampled = Noise - 1
+
+// Stability is the stability of the clusters under the resampling of the points. The clusters of the Jaccard
+// stability of 0.75 and higher are commonly considered stable, of 0.5 and lower dissolved.
+type Stability struct {
+	// Resamples is the number of the resamples clustered.
+	Resamples int
+	// Jaccard holds the stabilities of the clusters by their labels: the mean over the resamples of the highest
+	// Jaccard similarity of the cluster, restricted to the resampled points, to a cluster of the resample.
+	Jaccard map[int]float64
+	// Cohesion holds the mean co-association of the pairs of the points of every cluster by the labels, NaN for the
+	// single point clusters. The co-association of two points is the fraction of the resamples drawing both of them
+	// where they are clustered together.
+	Cohesion map[int]float64
+	// Separation is the mean co-association of the pairs of the points of different clusters.
+	Separation float64
+	// Ambiguity is the proportion of the pairs of the points of the co-association strictly between 0.1 and 0.9, the
+	// pairs the resamples disagree on.
+	Ambiguity float64
+	// Consensus holds the consensus labels of the points: the tree of the average linkage by one minus the
+	// co-association cut into as many clusters as the labels have.
+	Consensus []int
+}
+
+// WithResampling sets how the stability is measured: the method and the number of the resamples and the fraction of
+// the points drawn by the subsampling, non-positive values stand for the defaults.
+func (c *Clusterizer) WithResampling(method ResamplingMethod, resamples int, fraction float64) *Clusterizer {
+	if resamples <= 0 {
+		resamples = DefaultResamples
+	}
+	if fraction <= 0 || fraction > 1 {
+		fraction = DefaultSampleFraction
+	}
+
+	c.resampling, c.resamples, c.sampleFraction = method, resamples, fraction
+	return c
+}
+
+// Stability clusters the resamples of the points by the clusterer with the values of the params, the ones out of the
+// bounds for a resample are clamped, and measures how well the clusters of the labels survive the resampling. The
+// resamples and the seeds of their clusterings are derived from the seed. The co-association of all the pairs of the
+// points is counted, so it takes O(n²) memory and fails with points.ErrTooManyPoints for more than MaxStabilityPoints
+// points.
+func (c *Clusterizer) Stability(ctx context.Context, data *points.Set, labels []int, values params.Values) (*Stability, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "Stability")
+	defer span.End()
+
+	n := data.Len()
+	if n < 2 {
+		return nil, errors.New("at least 2 points are needed to measure the stability")
+	}
+	if n > MaxStabilityPoints {
+		return nil, fmt.Errorf("stability of %d points, at most %d are supported: %w", n, MaxStabilityPoints, points.ErrTooManyPoints)
+	}
+
+	logger.MustFromContext(ctx).Debugf("measuring stability of %d points on %d %s resamples", n, c.resamples, c.resampling)
+
+	rnd := rand.New(rand.NewSource(c.seed))
+	samples := make([][]int, c.resamples)
+	seeds := make([]int64, c.resamples)
+	for b := range samples {
+		samples[b] = c.resample(n, rnd)
+		seeds[b] = rnd.Int63()
+	}
+
+	resampleLabels := make([][]int, c.resamples)
+	errs := make([]error, c.resamples)
+
+	sem := make(chan struct{}, max(1, min(c.concurrency, runtime.GOMAXPROCS(0))))
+	wg := sync.WaitGroup{}
+	for b := range samples {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(b int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			resampleLabels[b], errs[b] = c.clusterResample(ctx, data, samples[b], seeds[b], values)
+		}(b)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for b, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("cluster resample %d: %w", b, err)
+		}
+	}
+
+	return newStability(labels, resampleLabels), nil
+}
+
+// resample returns the indices of the resample of n points.
+func (c *Clusterizer) resample(n int, rnd *rand.Rand) []int {
+	if c.resampling == Subsampling {
+		size := min(n, max(2, int(math.Round(c.sampleFraction*float64(n)))))
+		indices := rnd.Perm(n)[:size]
+		sort.Ints(indices)
+
+		return indices
+	}
+
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = rnd.Intn(n)
+	}
+
+	return indices
+}
+
+// clusterResample clusters the points with the indices and returns the labels of all the points, notSampled for the
+// points missing from the resample. The points drawn several times take the label of their first draw.
+func (c *Clusterizer) clusterResample(ctx context.Context, data *points.Set, indices []int, seed int64, values params.Values) ([]int, error) {
+	seeded := *c
+	seeded.seed = seed
+
+	clusterer, err := seeded.newClusterer(ctx, data.Subset(indices))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := clusterer.SetParams(ctx, clusterer.Params().Clamp(values)); err != nil {
+		return nil, fmt.Errorf("set params: %w", err)
+	}
+
+	_, sampleLabels := clusterer.Cluster(ctx)
+
+	labels := make([]int, data.Len())
+	for i := range labels {
+		labels[i] = notSampled
+	}
+	for j, i := range indices {
+		if labels[i] == notSampled {
+			labels[i] = sampleLabels[j]
+		}
+	}
+
+	return labels, nil
+}
+
+// newStability measures the stability of the clusters of the labels by the labels of the resamples.
+func newStability(labels []int, resampleLabels [][]int) *Stability {
+	n := len(labels)
+	s := &Stability{
+		Resamples: len(resampleLabels),
+		Jaccard:   make(map[int]float64),
+		Cohesion:  make(map[int]float64),
+	}
+
+	sums, counts := make(map[int]float64), make(map[int]int)
+	for _, rl := range resampleLabels {
+		for label, j := range resampleJaccard(labels, rl) {
+			sums[label] += j
+			counts[label]++
+		}
+	}
+	for label, count := range counts {
+		s.Jaccard[label] = sums[label] / float64(count)
+	}
+
+	// together and sampled count the resamples clustering the pairs of the points together and drawing both of them,
+	// condensed to the upper triangle
+	together := make([]int32, n*(n-1)/2)
+	sampled := make([]int32, n*(n-1)/2)
+	for _, rl := range resampleLabels {
+		k := 0
+		for i := 0; i < n; i++ {
+			if rl[i] == notSampled {
+				k += n - i - 1
+				continue
+			}
+
+			for j := i + 1; j < n; j, k = j+1, k+1 {
+				if rl[j] == notSampled {
+					continue
+				}
+
+				sampled[k]++
+				if rl[i] == rl[j] && rl[i] != Noise {
+					together[k]++
+				}
+			}
+		}
+	}
+
+	within, withinPairs := make(map[int]float64), make(map[int]int)
+	between, betweenPairs := 0.0, 0
+	ambiguous, pairs := 0, 0
+	k := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j, k = j+1, k+1 {
+			if sampled[k] == 0 {
+				continue
+			}
+
+			a := float64(together[k]) / float64(sampled[k])
+			pairs++
+			if a > 0.1 && a < 0.9 {
+				ambiguous++
+			}
+
+			switch li, lj := labels[i], labels[j]; {
+			case li == Noise || lj == Noise:
+			case li == lj:
+				within[li] += a
+				withinPairs[li]++
+			default:
+				between += a
+				betweenPairs++
+			}
+		}
+	}
+
+	for label := range s.Jaccard {
+		s.Cohesion[label] = math.NaN()
+		if withinPairs[label] > 0 {
+			s.Cohesion[label] = within[label] / float64(withinPairs[label])
+		}
+	}
+	s.Separation = math.NaN()
+	if betweenPairs > 0 {
+		s.Separation = between / float64(betweenPairs)
+	}
+	s.Ambiguity = math.NaN()
+	if pairs > 0 {
+		s.Ambiguity = float64(ambiguous) / float64(pairs)
+	}
+
+	// the pairs never drawn together are taken as never clustered together
+	distance := func(i, j int) float64 {
+		if i > j {
+			i, j = j, i
+		}
+		k := n*i - i*(i+1)/2 + j - i - 1
+		if sampled[k] == 0 {
+			return 1
+		}
+
+		return 1 - float64(together[k])/float64(sampled[k])
+	}
+	s.Consensus = hierarchical.NewDendrogram(n, distance, hierarchical.AverageLinkage).Cut(max(1, countClusters(labels)))
+
+	return s
+}
+
+// labelPair is the pair of the label of a point and of its label in a resample.
+type labelPair struct {
+	label, resampleLabel int
+}
+
+// resampleJaccard returns the highest Jaccard similarities of the clusters of the labels, restricted to the points of
+// the resample, to the clusters of the resample by the labels. The clusters missing from the resample are skipped.
+func resampleJaccard(labels, resampleLabels []int) map[int]float64 {
+	sizes, resampleSizes := make(map[int]int), make(map[int]int)
+	intersections := make(map[labelPair]int)
+	for i, l := range labels {
+		r := resampleLabels[i]
+		if r == notSampled {
+			continue
+		}
+
+		if r != Noise {
+			resampleSizes[r]++
+		}
+		if l == Noise {
+			continue
+		}
+
+		sizes[l]++
+		if r != Noise {
+			intersections[labelPair{l, r}]++
+		}
+	}
+
+	jaccard := make(map[int]float64, len(sizes))
+	for l := range sizes {
+		jaccard[l] = 0
+	}
+	for p, in := range intersections {
+		j := float64(in) / float64(sizes[p.label]+resampleSizes[p.resampleLabel]-in)
+		jaccard[p.label] = math.Max(jaccard[p.label], j)
+	}
+
+	return jaccard
+}
@@ -0,0 +1,94 @@
+package cluster
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+func Test_newStability(t *testing.T) {
+	labels := []int{0, 0, 1, 1}
+	resamples := [][]int{
+		{0, 0, 1, 1},
+		{0, 1, 1, notSampled},
+	}
+
+	got := newStability(labels, resamples)
+
+	if !reflect.DeepEqual(got.Jaccard, map[int]float64{0: 0.75, 1: 0.75}) {
+		t.Errorf("Jaccard = %v, want map[0:0.75 1:0.75]", got.Jaccard)
+	}
+	if !reflect.DeepEqual(got.Cohesion, map[int]float64{0: 0.5, 1: 1}) {
+		t.Errorf("Cohesion = %v, want map[0:0.5 1:1]", got.Cohesion)
+	}
+	if got.Separation != 0.125 {
+		t.Errorf("Separation = %v, want 0.125", got.Separation)
+	}
+	if math.Abs(got.Ambiguity-1.0/3) > 1e-9 {
+		t.Errorf("Ambiguity = %v, want 1/3", got.Ambiguity)
+	}
+	if !reflect.DeepEqual(got.Consensus, []int{0, 0, 1, 1}) {
+		t.Errorf("Consensus = %v, want [0 0 1 1]", got.Consensus)
+	}
+}
+
+func TestClusterizer_Stability(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 90)
+	for i := range values {
+		values[i] = float64(i%3*100) + r.NormFloat64()
+	}
+	data := points.FromValues(values)
+
+	for _, m := range ResamplingMethods {
+		t.Run(m.String(), func(t *testing.T) {
+			c := New(KMeans, Silhouette).WithParams(map[string]string{"k": "3", "initer": "plusplus", "restarts": "5"}).WithResampling(m, 10, 0)
+			res, err := c.Clusterize(ctx, data)
+			if err != nil {
+				t.Fatalf("Clusterize() error = %v", err)
+			}
+
+			got, err := c.Stability(ctx, data, res.Labels, res.Params)
+			if err != nil {
+				t.Fatalf("Stability() error = %v", err)
+			}
+
+			if got.Resamples != 10 || len(got.Jaccard) != 3 {
+				t.Fatalf("Stability() resamples = %d, clusters = %d, want 10 and 3", got.Resamples, len(got.Jaccard))
+			}
+			for label, j := range got.Jaccard {
+				if j < 0.9 || got.Cohesion[label] < 0.9 {
+					t.Errorf("Stability() of cluster %d: Jaccard = %v, cohesion = %v, want stable", label, j, got.Cohesion[label])
+				}
+			}
+			if got.Ambiguity > 0.05 || got.Separation > 0.05 {
+				t.Errorf("Stability() ambiguity = %v, separation = %v, want about 0", got.Ambiguity, got.Separation)
+			}
+
+			// the consensus is the same partition up to the labels
+			relabel := make(map[int]int)
+			for i, l := range res.Labels {
+				if want, ok := relabel[l]; ok && want != got.Consensus[i] {
+					t.Fatalf("Stability() consensus = %v, want the partition of %v", got.Consensus, res.Labels)
+				}
+				relabel[l] = got.Consensus[i]
+			}
+		})
+	}
+}
+
+func TestClusterizer_Stability_tooManyPoints(t *testing.T) {
+	ctx := logger.InjectIntoContext(context.Background(), logger.MustCreate())
+	data := points.FromValues(make([]float64, MaxStabilityPoints+1))
+
+	if _, err := New(KMeans, Silhouette).Stability(ctx, data, make([]int, data.Len()), nil); err == nil {
+		t.Error("Stability() error = nil, want the number of the points rejected")
+	}
+}
@@ -1,26 +1,38 @@
 package internal
 
 import (
+	"fmt"
 	"os/exec"
 	"strings"
 )
 
 func MustGetGitVersion() string {
+	version, err := GetGitVersion()
+	if err != nil {
+		panic(err)
+	}
+
+	return version
+}
+
+// GetGitVersion returns the version in the form of <tag>-<commit>. It fails when git is not available or the repository
+// has no tags.
+func GetGitVersion() (string, error) {
 	tagCmd := exec.Command("git", "describe", "--tags", "--abbrev=0")
 	commitCmd := exec.Command("git", "rev-parse", "--short", "HEAD")
 
 	tagOutput, err := tagCmd.Output()
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("describe tags: %w", err)
 	}
 
 	commitOutput, err := commitCmd.Output()
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("get commit: %w", err)
 	}
 
 	tag := strings.TrimSpace(string(tagOutput))
 	commit := strings.TrimSpace(string(commitOutput))
 
-	return tag + "-" + commit
+	return tag + "-" + commit, nil
 }
@@ -0,0 +1,222 @@
+package export
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The columnar format is a compact binary format storing the table column by column:
+//
+//	magic        "PCOL"
+//	version      byte, currently 1
+//	rows         uvarint
+//	columns      uvarint
+//	per column:
+//	  name       uvarint length followed by the utf-8 bytes
+//	  kind       byte: 1 for int, 2 for float, 3 for string
+//	  values     int:    zigzag varint deltas from the previous value of the column, the first one is taken from 0
+//	             float:  8 bytes little endian IEEE 754 per value
+//	             string: dictionary of uvarint size followed by the uvarint length prefixed values, then the uvarint
+//	                     dictionary index per value
+const (
+	columnarMagic   = "PCOL"
+	columnarVersion = 1
+)
+
+func encodeColumnar(w io.Writer, t *table) error {
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, binary.MaxVarintLen64)
+
+	writeUvarint := func(v uint64) error {
+		_, err := bw.Write(buf[:binary.PutUvarint(buf, v)])
+		return err
+	}
+	writeString := func(s string) error {
+		if err := writeUvarint(uint64(len(s))); err != nil {
+			return err
+		}
+		_, err := bw.WriteString(s)
+		return err
+	}
+
+	if _, err := bw.WriteString(columnarMagic); err != nil {
+		return err
+	}
+	if err := bw.WriteByte(columnarVersion); err != nil {
+		return err
+	}
+	if err := writeUvarint(uint64(t.rows())); err != nil {
+		return err
+	}
+	if err := writeUvarint(uint64(len(t.columns))); err != nil {
+		return err
+	}
+
+	for _, c := range t.columns {
+		if err := writeString(c.name); err != nil {
+			return err
+		}
+		if err := bw.WriteByte(byte(c.kind)); err != nil {
+			return err
+		}
+
+		switch c.kind {
+		case intColumn:
+			prev := int64(0)
+			for _, v := range c.ints {
+				if _, err := bw.Write(buf[:binary.PutVarint(buf, v-prev)]); err != nil {
+					return err
+				}
+				prev = v
+			}
+		case floatColumn:
+			for _, v := range c.floats {
+				binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+				if _, err := bw.Write(buf[:8]); err != nil {
+					return err
+				}
+			}
+		case stringColumn:
+			var dict []string
+			idx := make(map[string]int)
+			for _, v := range c.strings {
+				if _, ok := idx[v]; !ok {
+					idx[v] = len(dict)
+					dict = append(dict, v)
+				}
+			}
+
+			if err := writeUvarint(uint64(len(dict))); err != nil {
+				return err
+			}
+			for _, v := range dict {
+				if err := writeString(v); err != nil {
+					return err
+				}
+			}
+			for _, v := range c.strings {
+				if err := writeUvarint(uint64(idx[v])); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Column is a decoded column of the columnar format. Only the values slice matching the kind of the column is set.
+type Column struct {
+	Name    string
+	Ints    []int64
+	Floats  []float64
+	Strings []string
+}
+
+// DecodeColumnar reads the table written in the columnar format.
+func DecodeColumnar(r io.Reader) ([]Column, error) {
+	br := bufio.NewReader(r)
+
+	readString := func() (string, error) {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return "", err
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	magic := make([]byte, len(columnarMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("read magic: %w", err)
+	}
+	if string(magic) != columnarMagic {
+		return nil, fmt.Errorf("not a columnar file")
+	}
+
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("read version: %w", err)
+	}
+	if version != columnarVersion {
+		return nil, fmt.Errorf("unsupported columnar version %d", version)
+	}
+
+	rows, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("read rows number: %w", err)
+	}
+	columnsNum, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("read columns number: %w", err)
+	}
+
+	columns := make([]Column, columnsNum)
+	for i := range columns {
+		c := &columns[i]
+		if c.Name, err = readString(); err != nil {
+			return nil, fmt.Errorf("read column name: %w", err)
+		}
+
+		kind, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("read column %s kind: %w", c.Name, err)
+		}
+
+		switch columnKind(kind) {
+		case intColumn:
+			c.Ints = make([]int64, rows)
+			prev := int64(0)
+			for row := range c.Ints {
+				delta, err := binary.ReadVarint(br)
+				if err != nil {
+					return nil, fmt.Errorf("read column %s: %w", c.Name, err)
+				}
+				prev += delta
+				c.Ints[row] = prev
+			}
+		case floatColumn:
+			c.Floats = make([]float64, rows)
+			b := make([]byte, 8)
+			for row := range c.Floats {
+				if _, err := io.ReadFull(br, b); err != nil {
+					return nil, fmt.Errorf("read column %s: %w", c.Name, err)
+				}
+				c.Floats[row] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+			}
+		case stringColumn:
+			dictSize, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, fmt.Errorf("read column %s dictionary: %w", c.Name, err)
+			}
+			dict := make([]string, dictSize)
+			for j := range dict {
+				if dict[j], err = readString(); err != nil {
+					return nil, fmt.Errorf("read column %s dictionary: %w", c.Name, err)
+				}
+			}
+
+			c.Strings = make([]string, rows)
+			for row := range c.Strings {
+				idx, err := binary.ReadUvarint(br)
+				if err != nil {
+					return nil, fmt.Errorf("read column %s: %w", c.Name, err)
+				}
+				if idx >= dictSize {
+					return nil, fmt.Errorf("read column %s: dictionary index %d out of range", c.Name, idx)
+				}
+				c.Strings[row] = dict[idx]
+			}
+		default:
+			return nil, fmt.Errorf("unknown kind %d of column %s", kind, c.Name)
+		}
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/boson-research/patterns/internal/neighbourhood"
+)
+
+const (
+	dendrogramSuffix = ".dendrogram"
+	newickExt        = ".nwk"
+	jsonExt          = ".json"
+)
+
+// dendrogramNode is the JSON representation of the cluster of the dendrogram. Leaves are the text entries, inner nodes
+// are the merges with their heights and sizes.
+type dendrogramNode struct {
+	Entry    *int              `json:"entry,omitempty"`
+	Location *int              `json:"location,omitempty"`
+	Height   float64           `json:"height"`
+	Size     int               `json:"size"`
+	Children []*dendrogramNode `json:"children,omitempty"`
+}
+
+type dendrogramTree struct {
+	Center string          `json:"center"`
+	Tree   *dendrogramNode `json:"tree"`
+}
+
+// hasDendrograms reports whether any of the neighbourhoods is clusterized hierarchically.
+func hasDendrograms(neighbourhoods []*neighbourhood.Neighbourhood) bool {
+	for _, n := range neighbourhoods {
+		if n.Dendrogram != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeDendrograms writes the dendrograms of the neighbourhoods next to the path in the Newick format, a tree per line
+// labeled with the center and the leaves labeled with the entry locations, and in JSON. Neighbourhoods without
+// dendrograms are skipped. The single neighbourhood is written as the JSON object, several ones as the array.
+func writeDendrograms(path string, neighbourhoods []*neighbourhood.Neighbourhood) error {
+	base := strings.TrimSuffix(path, filepath.Ext(path)) + dendrogramSuffix
+
+	var trees []dendrogramTree
+	if err := writeFile(base+newickExt, func(w io.Writer) error {
+		for _, n := range neighbourhoods {
+			if n.Dendrogram == nil {
+				continue
+			}
+
+			locations := n.TextEntries.Locations()
+			if err := n.Dendrogram.WriteNewick(w, func(leaf int) string {
+				return strconv.Itoa(locations[leaf])
+			}, n.Center.String()); err != nil {
+				return err
+			}
+
+			trees = append(trees, dendrogramTree{Center: n.Center.String(), Tree: dendrogramJSON(n, n.Dendrogram.Root())})
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return writeFile(base+jsonExt, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		if len(neighbourhoods) == 1 && len(trees) == 1 {
+			return enc.Encode(trees[0])
+		}
+
+		return enc.Encode(trees)
+	})
+}
+
+func dendrogramJSON(n *neighbourhood.Neighbourhood, id int) *dendrogramNode {
+	d := n.Dendrogram
+	if id < d.Leaves() {
+		entry, location := id, n.TextEntries.Locations()[id]
+		return &dendrogramNode{Entry: &entry, Location: &location, Size: 1}
+	}
+
+	m := d.Merges()[id-d.Leaves()]
+	return &dendrogramNode{
+		Height:   m.Height,
+		Size:     m.Size,
+		Children: []*dendrogramNode{dendrogramJSON(n, m.Left), dendrogramJSON(n, m.Right)},
+	}
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+)
+
+type encoder func(w io.Writer, t *table) error
+
+// encodeCSV writes the header with the column names followed by the rows.
+func encodeCSV(w io.Writer, t *table) error {
+	cw := csv.NewWriter(w)
+
+	record := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		record[i] = c.name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	for row := 0; row < t.rows(); row++ {
+		for i, c := range t.columns {
+			record[i] = c.format(row)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// encodeJSON writes the array of objects, one per row.
+func encodeJSON(w io.Writer, t *table) error {
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.WriteString("["); err != nil {
+		return err
+	}
+
+	for row := 0; row < t.rows(); row++ {
+		if row > 0 {
+			if _, err := bw.WriteString(","); err != nil {
+				return err
+			}
+		}
+
+		if _, err := bw.WriteString("\n  "); err != nil {
+			return err
+		}
+
+		if err := writeJSONObject(bw, t, row); err != nil {
+			return err
+		}
+	}
+
+	if _, err := bw.WriteString("\n]\n"); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// encodeJSONL writes an object per line.
+func encodeJSONL(w io.Writer, t *table) error {
+	bw := bufio.NewWriter(w)
+
+	for row := 0; row < t.rows(); row++ {
+		if err := writeJSONObject(bw, t, row); err != nil {
+			return err
+		}
+
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// writeJSONObject writes the row as an object keeping the order of the columns.
+func writeJSONObject(w *bufio.Writer, t *table, row int) error {
+	if err := w.WriteByte('{'); err != nil {
+		return err
+	}
+
+	for i, c := range t.columns {
+		if i > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+
+		name, err := json.Marshal(c.name)
+		if err != nil {
+			return err
+		}
+
+		// json has no representation of the not finite floats
+		value := []byte("null")
+		if c.kind != floatColumn || !math.IsNaN(c.floats[row]) && !math.IsInf(c.floats[row], 0) {
+			if value, err = json.Marshal(c.value(row)); err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(name); err != nil {
+			return err
+		}
+		if err := w.WriteByte(':'); err != nil {
+			return err
+		}
+		if _, err := w.Write(value); err != nil {
+			return err
+		}
+	}
+
+	return w.WriteByte('}')
+}
@@ -0,0 +1,207 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boson-research/patterns/internal/neighbourhood"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	clustersSuffix  = ".clusters"
+	qualitySuffix   = ".quality"
+	segmentsSuffix  = ".segments"
+	stabilitySuffix = ".stability"
+)
+
+type Exporter interface {
+	Export(ctx context.Context, neighbourhoods []*neighbourhood.Neighbourhood) error
+}
+
+// Config defines where and how the results are exported. Besides the text entries the clusters of the clusterized
+// neighbourhoods and the quality scores of the evaluated clusterings are exported, they are written next to the
+// entries with the .clusters and .quality suffixes before the extension. The dendrograms of the hierarchical
+// clusterings are written next to them with the .dendrogram suffix in the Newick (.nwk) and JSON formats. The segments
+// of the segmented neighbourhoods are written with the .segments suffix, the summaries of the stability of the
+// clusters with the .stability suffix.
+type Config struct {
+	Format Format
+	// Dir is the directory a file per neighbourhood center is written to.
+	Dir string
+	// File is the single file all the neighbourhoods are written to. If set, Dir is ignored.
+	File string
+}
+
+// New returns the exporter writing files according to the config.
+func New(cfg Config) Exporter {
+	return &fileExporter{cfg: cfg, encode: getEncoder(cfg.Format)}
+}
+
+type fileExporter struct {
+	cfg    Config
+	encode encoder
+}
+
+func (e *fileExporter) Export(ctx context.Context, neighbourhoods []*neighbourhood.Neighbourhood) error {
+	ctx, span := otel.Tracer("").Start(ctx, "Export")
+	defer span.End()
+
+	if e.encode == nil {
+		return fmt.Errorf("unknown export format: %s", e.cfg.Format)
+	}
+
+	neighbourhoods = withEntries(neighbourhoods)
+
+	withClusters := clusterized(neighbourhoods)
+	withSegments := segmented(neighbourhoods)
+	withStability := stable(neighbourhoods)
+
+	if e.cfg.File != "" {
+		logger.MustFromContext(ctx).Debugf("exporting %d neighbourhoods to %s", len(neighbourhoods), e.cfg.File)
+
+		if err := os.MkdirAll(filepath.Dir(e.cfg.File), 0o755); err != nil {
+			return fmt.Errorf("create output directory: %w", err)
+		}
+
+		if err := e.write(e.cfg.File, entriesTable(neighbourhoods, true)); err != nil {
+			return err
+		}
+
+		if withClusters {
+			if err := e.write(withSuffix(e.cfg.File, clustersSuffix), clustersTable(neighbourhoods, true)); err != nil {
+				return err
+			}
+
+			if err := e.write(withSuffix(e.cfg.File, qualitySuffix), qualityTable(neighbourhoods, true)); err != nil {
+				return err
+			}
+		}
+
+		if withSegments {
+			if err := e.write(withSuffix(e.cfg.File, segmentsSuffix), segmentsTable(neighbourhoods, true)); err != nil {
+				return err
+			}
+		}
+
+		if withStability {
+			if err := e.write(withSuffix(e.cfg.File, stabilitySuffix), stabilityTable(neighbourhoods, true)); err != nil {
+				return err
+			}
+		}
+
+		if hasDendrograms(neighbourhoods) {
+			return writeDendrograms(e.cfg.File, neighbourhoods)
+		}
+
+		return nil
+	}
+
+	logger.MustFromContext(ctx).Debugf("exporting %d neighbourhoods to %s", len(neighbourhoods), e.cfg.Dir)
+
+	if err := os.MkdirAll(e.cfg.Dir, 0o755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+
+	for _, n := range neighbourhoods {
+		path := filepath.Join(e.cfg.Dir, fileName(n.Center.String())+e.cfg.Format.Ext())
+		if err := e.write(path, entriesTable([]*neighbourhood.Neighbourhood{n}, false)); err != nil {
+			return err
+		}
+
+		if withClusters {
+			if err := e.write(withSuffix(path, clustersSuffix), clustersTable([]*neighbourhood.Neighbourhood{n}, false)); err != nil {
+				return err
+			}
+
+			if err := e.write(withSuffix(path, qualitySuffix), qualityTable([]*neighbourhood.Neighbourhood{n}, false)); err != nil {
+				return err
+			}
+		}
+
+		if withSegments {
+			if err := e.write(withSuffix(path, segmentsSuffix), segmentsTable([]*neighbourhood.Neighbourhood{n}, false)); err != nil {
+				return err
+			}
+		}
+
+		if withStability && n.Stability != nil {
+			if err := e.write(withSuffix(path, stabilitySuffix), stabilityTable([]*neighbourhood.Neighbourhood{n}, false)); err != nil {
+				return err
+			}
+		}
+
+		if n.Dendrogram != nil {
+			if err := writeDendrograms(path, []*neighbourhood.Neighbourhood{n}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *fileExporter) write(path string, t *table) error {
+	return writeFile(path, func(w io.Writer) error {
+		return e.encode(w, t)
+	})
+}
+
+// writeFile creates the file at the path and writes it with the buffered writer.
+func writeFile(path string, write func(w io.Writer) error) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close %s: %w", path, closeErr)
+		}
+	}()
+
+	bw := bufio.NewWriter(file)
+	if err := write(bw); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func withEntries(neighbourhoods []*neighbourhood.Neighbourhood) []*neighbourhood.Neighbourhood {
+	res := make([]*neighbourhood.Neighbourhood, 0, len(neighbourhoods))
+	for _, n := range neighbourhoods {
+		if len(n.TextEntries.Locations()) == 0 {
+			continue
+		}
+
+		res = append(res, n)
+	}
+
+	return res
+}
+
+// withSuffix inserts the suffix before the extension of the path.
+func withSuffix(path string, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + suffix + ext
+}
+
+// fileName replaces the symbols which could not be a part of a file name.
+func fileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == 0 || r == '/' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,272 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/alphabet"
+	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/neighbourhood"
+	"github.com/boson-research/patterns/internal/segment"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+var ctx = logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+func testNeighbourhoods() []*neighbourhood.Neighbourhood {
+	center := alphabet.NewPattern(alphabet.UTF8.Split([]byte("a/b")))
+	element := alphabet.NewPattern(alphabet.UTF8.Split([]byte("ж,b")))
+
+	n := neighbourhood.New(center).WithElements([]*alphabet.Pattern{element})
+	n.TextEntries = neighbourhood.NewTextEntries()
+	n.TextEntries.Add(1, 1, element)
+	n.TextEntries.Add(10, 12, element)
+
+	empty := neighbourhood.New(alphabet.NewPattern(alphabet.UTF8.Split([]byte("bbb"))))
+
+	return []*neighbourhood.Neighbourhood{n, empty}
+}
+
+func TestExporter_Export(t *testing.T) {
+	tests := []struct {
+		format Format
+		file   bool
+		want   string
+	}{
+		{
+			format: CSV,
+			want:   "location,pattern,byte_offset\n1,\"ж,b\",1\n10,\"ж,b\",12\n",
+		},
+		{
+			format: CSV,
+			file:   true,
+			want:   "center,location,pattern,byte_offset\na/b,1,\"ж,b\",1\na/b,10,\"ж,b\",12\n",
+		},
+		{
+			format: JSON,
+			want: "[\n" +
+				"  {\"location\":1,\"pattern\":\"ж,b\",\"byte_offset\":1},\n" +
+				"  {\"location\":10,\"pattern\":\"ж,b\",\"byte_offset\":12}\n" +
+				"]\n",
+		},
+		{
+			format: JSONL,
+			file:   true,
+			want: "{\"center\":\"a/b\",\"location\":1,\"pattern\":\"ж,b\",\"byte_offset\":1}\n" +
+				"{\"center\":\"a/b\",\"location\":10,\"pattern\":\"ж,b\",\"byte_offset\":12}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := Config{Format: tt.format, Dir: dir}
+			path := filepath.Join(dir, "a_b"+tt.format.Ext())
+			if tt.file {
+				path = filepath.Join(dir, "all"+tt.format.Ext())
+				cfg.File = path
+			}
+
+			if err := New(cfg).Export(ctx, testNeighbourhoods()); err != nil {
+				t.Fatalf("Exporter.Export() error = %v", err)
+			}
+
+			files, _ := os.ReadDir(dir)
+			if len(files) != 1 {
+				t.Errorf("Exporter.Export() wrote %d files, want 1", len(files))
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read exported file: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Exporter.Export() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnar(t *testing.T) {
+	b := bytes.Buffer{}
+	if err := encodeColumnar(&b, entriesTable(testNeighbourhoods(), true)); err != nil {
+		t.Fatalf("encodeColumnar() error = %v", err)
+	}
+
+	got, err := DecodeColumnar(&b)
+	if err != nil {
+		t.Fatalf("DecodeColumnar() error = %v", err)
+	}
+
+	want := []Column{
+		{Name: "center", Strings: []string{"a/b", "a/b"}},
+		{Name: "location", Ints: []int64{1, 10}},
+		{Name: "pattern", Strings: []string{"ж,b", "ж,b"}},
+		{Name: "byte_offset", Ints: []int64{1, 12}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeColumnar() = %v, want %v", got, want)
+	}
+}
+
+func TestExporter_Export_dendrogram(t *testing.T) {
+	n := testNeighbourhoods()[0]
+	clusterizer := cluster.New(cluster.Agglomerative, cluster.Silhouette)
+	if err := n.Clusterize(ctx, clusterizer, neighbourhood.NewFeatureExtractor()); err != nil {
+		t.Fatalf("Neighbourhood.Clusterize() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := New(Config{Format: CSV, Dir: dir}).Export(ctx, []*neighbourhood.Neighbourhood{n}); err != nil {
+		t.Fatalf("Exporter.Export() error = %v", err)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{
+			file: "a_b.dendrogram.nwk",
+			want: "(1:9,10:9)a/b;\n",
+		},
+		{
+			file: "a_b.dendrogram.json",
+			want: `{"center":"a/b","tree":{"height":9,"size":2,"children":[` +
+				`{"entry":0,"location":1,"height":0,"size":1},{"entry":1,"location":10,"height":0,"size":1}]}}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatalf("read exported file: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Exporter.Export() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExporter_Export_soft(t *testing.T) {
+	n := testNeighbourhoods()[0]
+	if err := n.Clusterize(ctx, cluster.New(cluster.GMM, cluster.BIC), neighbourhood.NewFeatureExtractor()); err != nil {
+		t.Fatalf("Neighbourhood.Clusterize() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := New(Config{Format: CSV, Dir: dir}).Export(ctx, []*neighbourhood.Neighbourhood{n}); err != nil {
+		t.Fatalf("Exporter.Export() error = %v", err)
+	}
+
+	tests := []struct {
+		file       string
+		wantHeader string
+	}{
+		{file: "a_b.csv", wantHeader: "location,pattern,byte_offset,cluster,probability"},
+		{file: "a_b.clusters.csv", wantHeader: "cluster,centroid,size,soft_size,spread,start,end,params,seed,score"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatalf("read exported file: %v", err)
+			}
+			if header, _, _ := bytes.Cut(got, []byte("\n")); string(header) != tt.wantHeader {
+				t.Errorf("Exporter.Export() header = %q, want %q", header, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestExporter_Export_medoids(t *testing.T) {
+	n := testNeighbourhoods()[0]
+	if err := n.Clusterize(ctx, cluster.New(cluster.KMedoids, cluster.Silhouette), neighbourhood.NewFeatureExtractor()); err != nil {
+		t.Fatalf("Neighbourhood.Clusterize() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := New(Config{Format: CSV, Dir: dir}).Export(ctx, []*neighbourhood.Neighbourhood{n}); err != nil {
+		t.Fatalf("Exporter.Export() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "a_b.clusters.csv"))
+	if err != nil {
+		t.Fatalf("read exported file: %v", err)
+	}
+
+	header, row, _ := bytes.Cut(got, []byte("\n"))
+	if want := "cluster,centroid,medoid,size,spread,silhouette,start,end,params,seed,score"; string(header) != want {
+		t.Errorf("Exporter.Export() header = %q, want %q", header, want)
+	}
+	// the medoid is the first of the entries equally distant from each other
+	if want := "0,5.5,1,"; !bytes.HasPrefix(row, []byte(want)) {
+		t.Errorf("Exporter.Export() row = %q, want prefix %q", row, want)
+	}
+}
+
+func TestExporter_Export_segments(t *testing.T) {
+	n := testNeighbourhoods()[0]
+	if err := n.Segment(ctx, segment.New(segment.PELT), 20); err != nil {
+		t.Fatalf("Neighbourhood.Segment() error = %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "all.csv")
+	if err := New(Config{Format: CSV, File: file}).Export(ctx, []*neighbourhood.Neighbourhood{n}); err != nil {
+		t.Fatalf("Exporter.Export() error = %v", err)
+	}
+
+	got, err := os.ReadFile(withSuffix(file, segmentsSuffix))
+	if err != nil {
+		t.Fatalf("read exported file: %v", err)
+	}
+
+	want := "center,segment,start,end,count,rate\na/b,0,0,20,2,0.1\n"
+	if string(got) != want {
+		t.Errorf("Exporter.Export() = %q, want %q", got, want)
+	}
+}
+
+func TestExporter_Export_stability(t *testing.T) {
+	n := testNeighbourhoods()[0]
+	clusterizer := cluster.New(cluster.KMeans, cluster.Silhouette).WithResampling(cluster.Subsampling, 3, 1)
+	features := neighbourhood.NewFeatureExtractor()
+	if err := n.Clusterize(ctx, clusterizer, features); err != nil {
+		t.Fatalf("Neighbourhood.Clusterize() error = %v", err)
+	}
+	if err := n.AnalyzeStability(ctx, clusterizer, features); err != nil {
+		t.Fatalf("Neighbourhood.AnalyzeStability() error = %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "all.csv")
+	if err := New(Config{Format: CSV, File: file}).Export(ctx, []*neighbourhood.Neighbourhood{n}); err != nil {
+		t.Fatalf("Exporter.Export() error = %v", err)
+	}
+
+	got, err := os.ReadFile(withSuffix(file, stabilitySuffix))
+	if err != nil {
+		t.Fatalf("read exported file: %v", err)
+	}
+	if header, _, _ := bytes.Cut(got, []byte("\n")); string(header) != "center,resamples,clusters,separation,ambiguity" {
+		t.Errorf("Exporter.Export() stability header = %q", header)
+	}
+
+	got, err = os.ReadFile(withSuffix(file, clustersSuffix))
+	if err != nil {
+		t.Fatalf("read exported file: %v", err)
+	}
+	if header, _, _ := bytes.Cut(got, []byte("\n")); !bytes.HasSuffix(header, []byte(",stability,cohesion")) {
+		t.Errorf("Exporter.Export() clusters header = %q, want suffix %q", header, ",stability,cohesion")
+	}
+
+	got, err = os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read exported file: %v", err)
+	}
+	if header, _, _ := bytes.Cut(got, []byte("\n")); !bytes.HasSuffix(header, []byte(",consensus")) {
+		t.Errorf("Exporter.Export() entries header = %q, want suffix %q", header, ",consensus")
+	}
+}
@@ -0,0 +1,59 @@
+package export
+
+import "fmt"
+
+type Format int
+
+const (
+	CSV Format = iota
+	JSON
+	JSONL
+	Columnar
+)
+
+func (f Format) String() string {
+	switch f {
+	case CSV:
+		return "csv"
+	case JSON:
+		return "json"
+	case JSONL:
+		return "jsonl"
+	case Columnar:
+		return "columnar"
+	}
+	return "unknown"
+}
+
+// Ext returns the extension of the files written in the format.
+func (f Format) Ext() string {
+	switch f {
+	case Columnar:
+		return ".pcol"
+	}
+	return "." + f.String()
+}
+
+// ParseFormat returns the format by its name.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range []Format{CSV, JSON, JSONL, Columnar} {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown export format: %s", s)
+}
+
+func getEncoder(f Format) encoder {
+	switch f {
+	case CSV:
+		return encodeCSV
+	case JSON:
+		return encodeJSON
+	case JSONL:
+		return encodeJSONL
+	case Columnar:
+		return encodeColumnar
+	}
+	return nil
+}
@@ -0,0 +1,396 @@
+package export
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/neighbourhood"
+	"github.com/samber/lo"
+)
+
+type columnKind byte
+
+const (
+	intColumn columnKind = iota + 1
+	floatColumn
+	stringColumn
+)
+
+// column holds the values of one of the slices depending on its kind.
+type column struct {
+	name    string
+	kind    columnKind
+	ints    []int64
+	floats  []float64
+	strings []string
+}
+
+func (c *column) len() int {
+	switch c.kind {
+	case intColumn:
+		return len(c.ints)
+	case floatColumn:
+		return len(c.floats)
+	}
+	return len(c.strings)
+}
+
+// format returns the text representation of the value in the row.
+func (c *column) format(row int) string {
+	switch c.kind {
+	case intColumn:
+		return strconv.FormatInt(c.ints[row], 10)
+	case floatColumn:
+		return strconv.FormatFloat(c.floats[row], 'g', -1, 64)
+	}
+	return c.strings[row]
+}
+
+// value returns the value in the row.
+func (c *column) value(row int) any {
+	switch c.kind {
+	case intColumn:
+		return c.ints[row]
+	case floatColumn:
+		return c.floats[row]
+	}
+	return c.strings[row]
+}
+
+// table is the unit of export, every format knows how to encode it.
+type table struct {
+	columns []*column
+}
+
+func (t *table) rows() int {
+	if len(t.columns) == 0 {
+		return 0
+	}
+
+	return t.columns[0].len()
+}
+
+// entriesTable builds the table of the text entries of the neighbourhoods. The center column is added if withCenter
+// is set, the cluster column is added if the neighbourhoods are clusterized, noise entries have cluster.Noise there.
+// The probability column of the entries to belong to their clusters is added if the clusterings are soft, the
+// silhouette column if the clusterings are scored with the silhouette, the consensus column of the consensus labels if
+// the stability of the clusters is analyzed.
+func entriesTable(neighbourhoods []*neighbourhood.Neighbourhood, withCenter bool) *table {
+	center := &column{name: "center", kind: stringColumn}
+	location := &column{name: "location", kind: intColumn}
+	pattern := &column{name: "pattern", kind: stringColumn}
+	byteOffset := &column{name: "byte_offset", kind: intColumn}
+	clusterID := &column{name: "cluster", kind: intColumn}
+	probability := &column{name: "probability", kind: floatColumn}
+	silhouette := &column{name: "silhouette", kind: floatColumn}
+	consensus := &column{name: "consensus", kind: intColumn}
+
+	for _, n := range neighbourhoods {
+		labels := make([]int64, len(n.TextEntries.Locations()))
+		probabilities := make([]float64, len(n.TextEntries.Locations()))
+		for i := range labels {
+			labels[i] = cluster.Noise
+		}
+		for _, c := range n.Clusters {
+			for _, e := range c.Entries() {
+				labels[e.Index()] = int64(c.ID())
+				probabilities[e.Index()] = e.Probability()
+			}
+		}
+
+		for i, loc := range n.TextEntries.Locations() {
+			center.strings = append(center.strings, n.Center.String())
+			location.ints = append(location.ints, int64(loc))
+			pattern.strings = append(pattern.strings, n.TextEntries.Patterns()[i].String())
+			byteOffset.ints = append(byteOffset.ints, int64(n.TextEntries.ByteOffsets()[i]))
+		}
+		clusterID.ints = append(clusterID.ints, labels...)
+		probability.floats = append(probability.floats, probabilities...)
+
+		for i := range n.TextEntries.Locations() {
+			if i < len(n.Silhouettes) {
+				silhouette.floats = append(silhouette.floats, n.Silhouettes[i])
+			} else {
+				silhouette.floats = append(silhouette.floats, math.NaN())
+			}
+
+			if n.Stability != nil {
+				consensus.ints = append(consensus.ints, int64(n.Stability.Consensus[i]))
+			} else {
+				consensus.ints = append(consensus.ints, cluster.Noise)
+			}
+		}
+	}
+
+	t := &table{columns: []*column{location, pattern, byteOffset}}
+	if withCenter {
+		t.columns = append([]*column{center}, t.columns...)
+	}
+	if clusterized(neighbourhoods) {
+		t.columns = append(t.columns, clusterID)
+	}
+	if soft(neighbourhoods) {
+		t.columns = append(t.columns, probability)
+	}
+	if silhouettes(neighbourhoods) {
+		t.columns = append(t.columns, silhouette)
+	}
+	if stable(neighbourhoods) {
+		t.columns = append(t.columns, consensus)
+	}
+
+	return t
+}
+
+// clustersTable builds the table of the clusters of the neighbourhoods, a row per cluster. The center column is added
+// if withCenter is set, the soft_size column of the sums of the probabilities of the entries to belong to the clusters
+// is added if the clusterings are soft, the medoid column of the locations of the entries the centers are is added if
+// the clusterings are around medoids, the silhouette column of the mean silhouettes of the entries of the clusters is
+// added if the clusterings are scored with the silhouette, the stability and cohesion columns if the stability of the
+// clusters is analyzed.
+func clustersTable(neighbourhoods []*neighbourhood.Neighbourhood, withCenter bool) *table {
+	center := &column{name: "center", kind: stringColumn}
+	id := &column{name: "cluster", kind: intColumn}
+	centroid := &column{name: "centroid", kind: floatColumn}
+	medoid := &column{name: "medoid", kind: intColumn}
+	size := &column{name: "size", kind: intColumn}
+	softSize := &column{name: "soft_size", kind: floatColumn}
+	spread := &column{name: "spread", kind: floatColumn}
+	silhouette := &column{name: "silhouette", kind: floatColumn}
+	start := &column{name: "start", kind: intColumn}
+	end := &column{name: "end", kind: intColumn}
+	params := &column{name: "params", kind: stringColumn}
+	seed := &column{name: "seed", kind: intColumn}
+	score := &column{name: "score", kind: floatColumn}
+	stability := &column{name: "stability", kind: floatColumn}
+	cohesion := &column{name: "cohesion", kind: floatColumn}
+
+	for _, n := range neighbourhoods {
+		for _, c := range n.Clusters {
+			first, last := c.Entries()[0].Loc(), c.Entries()[0].Loc()
+			for _, e := range c.Entries() {
+				first, last = min(first, e.Loc()), max(last, e.Loc())
+			}
+
+			center.strings = append(center.strings, n.Center.String())
+			id.ints = append(id.ints, int64(c.ID()))
+			centroid.floats = append(centroid.floats, c.Center())
+			if c.Medoid() != nil {
+				medoid.ints = append(medoid.ints, int64(c.Medoid().Loc()))
+			} else {
+				medoid.ints = append(medoid.ints, -1)
+			}
+			size.ints = append(size.ints, int64(c.Size()))
+			softSize.floats = append(softSize.floats, c.SoftSize())
+			spread.floats = append(spread.floats, c.Spread())
+			silhouette.floats = append(silhouette.floats, c.Silhouette())
+			start.ints = append(start.ints, int64(first))
+			end.ints = append(end.ints, int64(last))
+			params.strings = append(params.strings, n.ClustersSpace.Format(n.ClustersParams))
+			seed.ints = append(seed.ints, n.ClustersSeed)
+			score.floats = append(score.floats, n.ClustersScore)
+			stability.floats = append(stability.floats, c.Stability())
+			cohesion.floats = append(cohesion.floats, c.Cohesion())
+		}
+	}
+
+	t := &table{columns: []*column{id, centroid, size, spread, start, end, params, seed, score}}
+	if silhouettes(neighbourhoods) {
+		t.columns = slices.Insert(t.columns, 4, silhouette)
+	}
+	if soft(neighbourhoods) {
+		t.columns = slices.Insert(t.columns, 3, softSize)
+	}
+	if stable(neighbourhoods) {
+		t.columns = append(t.columns, stability, cohesion)
+	}
+	if medoids(neighbourhoods) {
+		t.columns = slices.Insert(t.columns, 2, medoid)
+	}
+	if withCenter {
+		t.columns = append([]*column{center}, t.columns...)
+	}
+
+	return t
+}
+
+// qualityTable builds the table of the quality scores of the clusterings evaluated for the neighbourhoods, a row per
+// clustering with a column per estimated quality method. The chosen column marks the clusterings the clusters were
+// taken from. The center column is added if withCenter is set.
+func qualityTable(neighbourhoods []*neighbourhood.Neighbourhood, withCenter bool) *table {
+	center := &column{name: "center", kind: stringColumn}
+	params := &column{name: "params", kind: stringColumn}
+	clustersNum := &column{name: "clusters", kind: intColumn}
+	chosen := &column{name: "chosen", kind: intColumn}
+
+	var methods []cluster.QualityEstimationMethod
+	for _, m := range cluster.QualityEstimationMethods() {
+		for _, n := range neighbourhoods {
+			if len(n.ClustersCandidates) > 0 {
+				if _, ok := n.ClustersCandidates[0].Scores[m]; ok {
+					methods = append(methods, m)
+					break
+				}
+			}
+		}
+	}
+
+	scores := make([]*column, len(methods))
+	for i, m := range methods {
+		scores[i] = &column{name: m.String(), kind: floatColumn}
+	}
+
+	for _, n := range neighbourhoods {
+		for _, c := range n.ClustersCandidates {
+			center.strings = append(center.strings, n.Center.String())
+			params.strings = append(params.strings, c.Space.Format(c.Params))
+			clustersNum.ints = append(clustersNum.ints, int64(c.ClustersNum))
+			chosen.ints = append(chosen.ints, int64(lo.Ternary(reflect.DeepEqual(c.Params, n.ClustersParams), 1, 0)))
+
+			for i, m := range methods {
+				score, ok := c.Scores[m]
+				scores[i].floats = append(scores[i].floats, lo.Ternary(ok, score, math.NaN()))
+			}
+		}
+	}
+
+	t := &table{columns: append([]*column{params, clustersNum, chosen}, scores...)}
+	if withCenter {
+		t.columns = append([]*column{center}, t.columns...)
+	}
+
+	return t
+}
+
+// segmentsTable builds the table of the segments of the neighbourhoods, a row per segment. The center column is added
+// if withCenter is set.
+func segmentsTable(neighbourhoods []*neighbourhood.Neighbourhood, withCenter bool) *table {
+	center := &column{name: "center", kind: stringColumn}
+	id := &column{name: "segment", kind: intColumn}
+	start := &column{name: "start", kind: intColumn}
+	end := &column{name: "end", kind: intColumn}
+	count := &column{name: "count", kind: intColumn}
+	rate := &column{name: "rate", kind: floatColumn}
+
+	for _, n := range neighbourhoods {
+		for i, s := range n.Segments {
+			center.strings = append(center.strings, n.Center.String())
+			id.ints = append(id.ints, int64(i))
+			start.ints = append(start.ints, int64(s.Start))
+			end.ints = append(end.ints, int64(s.End))
+			count.ints = append(count.ints, int64(s.Count))
+			rate.floats = append(rate.floats, s.Rate)
+		}
+	}
+
+	t := &table{columns: []*column{id, start, end, count, rate}}
+	if withCenter {
+		t.columns = append([]*column{center}, t.columns...)
+	}
+
+	return t
+}
+
+// stabilityTable builds the table of the summaries of the stability of the clusters of the neighbourhoods, a row per
+// neighbourhood of the analyzed stability: the number of the resamples, the mean co-association of the pairs of the
+// entries of different clusters and the proportion of the pairs of the ambiguous co-association. The center column
+// is added if withCenter is set.
+func stabilityTable(neighbourhoods []*neighbourhood.Neighbourhood, withCenter bool) *table {
+	center := &column{name: "center", kind: stringColumn}
+	resamples := &column{name: "resamples", kind: intColumn}
+	clustersNum := &column{name: "clusters", kind: intColumn}
+	separation := &column{name: "separation", kind: floatColumn}
+	ambiguity := &column{name: "ambiguity", kind: floatColumn}
+
+	for _, n := range neighbourhoods {
+		if n.Stability == nil {
+			continue
+		}
+
+		center.strings = append(center.strings, n.Center.String())
+		resamples.ints = append(resamples.ints, int64(n.Stability.Resamples))
+		clustersNum.ints = append(clustersNum.ints, int64(len(n.Clusters)))
+		separation.floats = append(separation.floats, n.Stability.Separation)
+		ambiguity.floats = append(ambiguity.floats, n.Stability.Ambiguity)
+	}
+
+	t := &table{columns: []*column{resamples, clustersNum, separation, ambiguity}}
+	if withCenter {
+		t.columns = append([]*column{center}, t.columns...)
+	}
+
+	return t
+}
+
+func clusterized(neighbourhoods []*neighbourhood.Neighbourhood) bool {
+	for _, n := range neighbourhoods {
+		if n.Clusters != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func segmented(neighbourhoods []*neighbourhood.Neighbourhood) bool {
+	for _, n := range neighbourhoods {
+		if n.Segments != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// soft reports whether any of the neighbourhoods is clusterized softly, i.e. with the probabilities of the entries to
+// belong to the clusters.
+func soft(neighbourhoods []*neighbourhood.Neighbourhood) bool {
+	for _, n := range neighbourhoods {
+		for _, c := range n.Clusters {
+			if c.Entries()[0].Probabilities() != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// medoids reports whether any of the neighbourhoods is clusterized around medoids, i.e. the centers of the clusters
+// are the entries.
+func medoids(neighbourhoods []*neighbourhood.Neighbourhood) bool {
+	for _, n := range neighbourhoods {
+		for _, c := range n.Clusters {
+			if c.Medoid() != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// silhouettes reports whether any of the neighbourhoods is clusterized with the silhouettes of the entries.
+func silhouettes(neighbourhoods []*neighbourhood.Neighbourhood) bool {
+	for _, n := range neighbourhoods {
+		if n.Silhouettes != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stable reports whether the stability of the clusters of any of the neighbourhoods is analyzed.
+func stable(neighbourhoods []*neighbourhood.Neighbourhood) bool {
+	for _, n := range neighbourhoods {
+		if n.Stability != nil {
+			return true
+		}
+	}
+
+	return false
+}
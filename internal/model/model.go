@@ -0,0 +1,78 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/cluster/params"
+)
+
+// Version is the version of the format of the model files, the files of the other versions are not read.
+const Version = 1
+
+// Model is the persisted clustering of the neighbourhoods: the alphabet and the neighbourhoods the text entries are
+// found by, the features the entries are clustered on and the models of the clusters of every neighbourhood. The
+// entries of other texts are assigned to the clusters by it without refitting.
+type Model struct {
+	Version int `json:"version"`
+	// Encoding and Alphabet are the name of the encoding and the symbols of the alphabet. The symbols, the shape and
+	// the patterns are kept as bytes, base64 in JSON, as they are not valid utf-8 in the other encodings.
+	Encoding string `json:"encoding"`
+	Alphabet []byte `json:"alphabet"`
+	// Shape is the spec of the shape the neighbourhoods were extracted by.
+	Shape []byte `json:"shape"`
+	// Clusterer is the name of the clusterer the clusters were found by.
+	Clusterer string `json:"clusterer"`
+	// Features are the names of the features of the entries clustered, DensityRadius is the radius of the density
+	// feature.
+	Features       []string        `json:"features"`
+	DensityRadius  int             `json:"density_radius"`
+	Neighbourhoods []Neighbourhood `json:"neighbourhoods"`
+}
+
+// Neighbourhood is the persisted clustering of the entries of a neighbourhood.
+type Neighbourhood struct {
+	Center   []byte   `json:"center"`
+	Elements [][]byte `json:"elements"`
+	// Space and Params are the space of the clusterer params and the values the clusters were chosen with, Seed is the
+	// seed of the random numbers of the clustering.
+	Space  params.Space  `json:"space"`
+	Params params.Values `json:"params"`
+	Seed   int64         `json:"seed"`
+	// Clusters assigns the entries to the clusters by their ids.
+	Clusters *cluster.Model `json:"clusters"`
+}
+
+// Write writes the model as JSON.
+func Write(w io.Writer, m *Model) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("encode model: %w", err)
+	}
+
+	return nil
+}
+
+// Read reads the model written by Write, it fails if the model is of another version or some neighbourhood has no
+// clusters.
+func Read(r io.Reader) (*Model, error) {
+	var m Model
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode model: %w", err)
+	}
+
+	if m.Version != Version {
+		return nil, fmt.Errorf("model of version %d, want %d", m.Version, Version)
+	}
+
+	for _, n := range m.Neighbourhoods {
+		if n.Clusters == nil {
+			return nil, fmt.Errorf("neighbourhood %s has no clusters", n.Center)
+		}
+	}
+
+	return &m, nil
+}
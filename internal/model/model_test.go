@@ -0,0 +1,62 @@
+package model
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+)
+
+func TestRead(t *testing.T) {
+	m := &Model{
+		Version:       Version,
+		Encoding:      "bytes",
+		Alphabet:      []byte("\x80\x81\xff"),
+		Shape:         []byte("center=ABB; element=A?B; centers=\x80\x81\x81"),
+		Clusterer:     "kmeans",
+		Features:      []string{"location"},
+		DensityRadius: 50,
+		Neighbourhoods: []Neighbourhood{{
+			Center:   []byte("\x80\x81\x81"),
+			Elements: [][]byte{[]byte("\x80\x80\x81"), []byte("\x80\x81\x81"), []byte("\x80\xff\x81")},
+			Space:    params.Space{{Name: "k", Kind: params.Int, Min: 1, Max: 3, Default: 1, Tunable: true}},
+			Params:   params.Values{2},
+			Seed:     7,
+			Clusters: &cluster.Model{Centroids: points.FromValues([]float64{1, 10}), Metric: points.Euclidean},
+		}},
+	}
+
+	b := bytes.Buffer{}
+	if err := Write(&b, m); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	written := b.String()
+
+	got, err := Read(&b)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	n := got.Neighbourhoods[0]
+	if !reflect.DeepEqual(n.Clusters.Centroids.Flat(), []float64{1, 10}) {
+		t.Errorf("Read() centroids = %v, want [1 10]", n.Clusters.Centroids)
+	}
+	got.Neighbourhoods[0].Clusters = m.Neighbourhoods[0].Clusters
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Read() = %+v, want %+v", got, m)
+	}
+
+	for name, input := range map[string]string{
+		"other version": strings.Replace(written, `"version": 1`, `"version": 2`, 1),
+		"no clusters":   strings.Replace(written, `"clusters": {`, `"clusters": null, "old": {`, 1),
+		"not json":      "model",
+	} {
+		if _, err := Read(strings.NewReader(input)); err == nil {
+			t.Errorf("Read() of %s error = nil", name)
+		}
+	}
+}
@@ -6,10 +6,75 @@ import (
 )
 
 type Cluster struct {
-	center  float64
+	id     int
+	center float64
+	spread float64
+	// softSize is the sum of the probabilities of all the entries of the neighbourhood to belong to the cluster.
+	softSize float64
+	// silhouette is the mean silhouette of the entries of the cluster.
+	silhouette float64
+	// stability is the Jaccard stability of the cluster under the resampling of the entries, cohesion is the mean
+	// co-association of the pairs of its entries.
+	stability float64
+	cohesion  float64
+	// medoid is the entry the center of the cluster is, set by the medoid clusterers only.
+	medoid  *TextEntry
 	entries []*TextEntry
 }
 
+// ID returns the index of the cluster in the neighbourhood clusters ordered by center, the id of the cluster of the
+// model for the predicted clusters.
+func (c *Cluster) ID() int {
+	return c.id
+}
+
+// Center returns the mean location of the entries of the cluster.
+func (c *Cluster) Center() float64 {
+	return c.center
+}
+
+// Spread returns the standard deviation of the entries locations from the center.
+func (c *Cluster) Spread() float64 {
+	return c.spread
+}
+
+// Medoid returns the entry of the cluster the medoid clusterer chose as its center, nil for the other clusterers.
+func (c *Cluster) Medoid() *TextEntry {
+	return c.medoid
+}
+
+// Silhouette returns the mean silhouette of the entries of the cluster, NaN if the clustering is not scored with the
+// silhouette.
+func (c *Cluster) Silhouette() float64 {
+	return c.silhouette
+}
+
+// Stability returns the mean over the resamples of the entries of the highest Jaccard similarity of the cluster to a
+// cluster of the resample, NaN if the stability is not analyzed.
+func (c *Cluster) Stability() float64 {
+	return c.stability
+}
+
+// Cohesion returns the mean fraction of the resamples clustering the pairs of the entries of the cluster together, NaN
+// if the stability is not analyzed or the cluster has a single entry.
+func (c *Cluster) Cohesion() float64 {
+	return c.cohesion
+}
+
+func (c *Cluster) Entries() []*TextEntry {
+	return c.entries
+}
+
+func (c *Cluster) Size() int {
+	return len(c.entries)
+}
+
+// SoftSize returns the sum of the probabilities of all the entries of the neighbourhood to belong to the cluster, it
+// is the size for the hard clusterings.
+func (c *Cluster) SoftSize() float64 {
+	return c.softSize
+}
+
 func (c *Cluster) String() string {
 	b := strings.Builder{}
 	for _, e := range c.entries {
@@ -0,0 +1,195 @@
+package neighbourhood
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/boson-research/patterns/internal/cluster/points"
+)
+
+// DefaultDensityRadius is the radius in symbols the local density of the entries is counted within by default.
+const DefaultDensityRadius = 50
+
+type Feature int
+
+const (
+	// LocationFeature is the location of the entry in symbols.
+	LocationFeature Feature = iota
+	// PatternFeature is the index of the pattern of the entry in the neighbourhood elements.
+	PatternFeature
+	// DensityFeature is the number of the other entries of the neighbourhood within the density radius.
+	DensityFeature
+	// DocumentFeature is the index of the text the entry is found in.
+	DocumentFeature
+)
+
+// Features lists all the features.
+var Features = []Feature{LocationFeature, PatternFeature, DensityFeature, DocumentFeature}
+
+func (f Feature) String() string {
+	switch f {
+	case LocationFeature:
+		return "location"
+	case PatternFeature:
+		return "pattern"
+	case DensityFeature:
+		return "density"
+	case DocumentFeature:
+		return "document"
+	}
+	return "unknown"
+}
+
+// ParseFeature returns the feature by its name.
+func ParseFeature(s string) (Feature, error) {
+	for _, f := range Features {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown feature: %s", s)
+}
+
+// FeatureExtractor maps the text entries of a neighbourhood to the points to clusterize, a coordinate per feature, and
+// weighs the points by the weigher.
+type FeatureExtractor struct {
+	features      []Feature
+	densityRadius int
+	standardize   bool
+	weigher       *Weigher
+	// documentStarts holds the locations the analyzed texts start at in ascending order.
+	documentStarts []int
+}
+
+// NewFeatureExtractor returns the extractor of the features, the location only if none are given.
+func NewFeatureExtractor(features ...Feature) *FeatureExtractor {
+	if len(features) == 0 {
+		features = []Feature{LocationFeature}
+	}
+
+	return &FeatureExtractor{
+		features:      features,
+		densityRadius: DefaultDensityRadius,
+	}
+}
+
+// WithDensityRadius sets the radius in symbols the density feature is counted within.
+func (f *FeatureExtractor) WithDensityRadius(radius int) *FeatureExtractor {
+	f.densityRadius = radius
+	return f
+}
+
+// WithStandardization sets whether every feature is scaled to zero mean and unit variance, so that the features of
+// different scales weigh the same in the distances.
+func (f *FeatureExtractor) WithStandardization(standardize bool) *FeatureExtractor {
+	f.standardize = standardize
+	return f
+}
+
+// WithDocumentStarts sets the locations the analyzed texts start at in ascending order for the document feature.
+func (f *FeatureExtractor) WithDocumentStarts(starts []int) *FeatureExtractor {
+	f.documentStarts = starts
+	return f
+}
+
+// WithWeigher sets the weigher of the points, they are not weighted if it is nil.
+func (f *FeatureExtractor) WithWeigher(w *Weigher) *FeatureExtractor {
+	f.weigher = w
+	return f
+}
+
+// Extract returns the points of the text entries of the neighbourhood in the order of the entries.
+func (f *FeatureExtractor) Extract(n *Neighbourhood) (*points.Set, error) {
+	set, scaling, err := f.extract(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if scaling != nil {
+		set = scaling.Apply(set)
+	}
+
+	return set, nil
+}
+
+// extract returns the unscaled points of the text entries of the neighbourhood in the order of the entries and the
+// scaling the extractor standardizes them by, nil if they are not standardized.
+func (f *FeatureExtractor) extract(n *Neighbourhood) (*points.Set, *points.Scaling, error) {
+	locations := n.TextEntries.Locations()
+
+	columns := make([][]float64, len(f.features))
+	for j, feature := range f.features {
+		switch feature {
+		case LocationFeature:
+			columns[j] = make([]float64, len(locations))
+			for i, loc := range locations {
+				columns[j][i] = float64(loc)
+			}
+		case PatternFeature:
+			columns[j] = patternIndices(n)
+		case DensityFeature:
+			columns[j] = localDensities(locations, f.densityRadius)
+		case DocumentFeature:
+			columns[j] = make([]float64, len(locations))
+			for i, loc := range locations {
+				columns[j][i] = float64(max(sort.SearchInts(f.documentStarts, loc+1)-1, 0))
+			}
+		}
+	}
+
+	set := points.NewWithSize(len(f.features), len(locations))
+	p := make([]float64, len(f.features))
+	for i := range locations {
+		for j := range columns {
+			p[j] = columns[j][i]
+		}
+		set.Add(p)
+	}
+
+	weighted, err := set.WithWeights(f.weigher.Weigh(n))
+	if err != nil {
+		return nil, nil, fmt.Errorf("weigh entries by %s: %w", f.weigher.weighting, err)
+	}
+
+	if f.standardize {
+		return weighted, weighted.Standardization(), nil
+	}
+
+	return weighted, nil, nil
+}
+
+// patternIndices returns the indices of the patterns of the entries in the neighbourhood elements.
+func patternIndices(n *Neighbourhood) []float64 {
+	indexByValue := make(map[string]int, len(n.Elements))
+	for i, el := range n.Elements {
+		if _, ok := indexByValue[el.String()]; !ok {
+			indexByValue[el.String()] = i
+		}
+	}
+
+	indices := make([]float64, len(n.TextEntries.Patterns()))
+	for i, pat := range n.TextEntries.Patterns() {
+		indices[i] = float64(indexByValue[pat.String()])
+	}
+
+	return indices
+}
+
+// localDensities returns the number of the other locations within the radius of every location. The locations are
+// sorted, so the window slides over them.
+func localDensities(locations []int, radius int) []float64 {
+	densities := make([]float64, len(locations))
+	lo, hi := 0, 0
+	for i, loc := range locations {
+		for locations[lo] < loc-radius {
+			lo++
+		}
+		for hi < len(locations) && locations[hi] <= loc+radius {
+			hi++
+		}
+
+		densities[i] = float64(hi - lo - 1)
+	}
+
+	return densities
+}
@@ -0,0 +1,77 @@
+package neighbourhood
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/alphabet"
+)
+
+func TestFeatureExtractor_Extract(t *testing.T) {
+	n := New(pattern("abb")).WithElements([]*alphabet.Pattern{pattern("aab"), pattern("abb")})
+	n.TextEntries = NewTextEntries()
+	n.TextEntries.AddMany(
+		[]int{0, 10, 12, 100, 105},
+		[]int{0, 10, 12, 100, 105},
+		[]*alphabet.Pattern{pattern("aab"), pattern("abb"), pattern("aab"), pattern("abb"), pattern("abb")},
+	)
+
+	tests := []struct {
+		name      string
+		extractor *FeatureExtractor
+		wantDim   int
+		want      []float64
+	}{
+		{
+			name:      "location by default",
+			extractor: NewFeatureExtractor(),
+			wantDim:   1,
+			want:      []float64{0, 10, 12, 100, 105},
+		},
+		{
+			name: "all features",
+			extractor: NewFeatureExtractor(LocationFeature, PatternFeature, DensityFeature, DocumentFeature).
+				WithDensityRadius(5).
+				WithDocumentStarts([]int{0, 50}),
+			wantDim: 4,
+			want: []float64{
+				0, 0, 0, 0,
+				10, 1, 1, 0,
+				12, 0, 1, 0,
+				100, 1, 1, 1,
+				105, 1, 1, 1,
+			},
+		},
+		{
+			name:      "standardized",
+			extractor: NewFeatureExtractor(PatternFeature).WithStandardization(true),
+			wantDim:   1,
+			want: []float64{
+				-3 / math.Sqrt(6), 2 / math.Sqrt(6), -3 / math.Sqrt(6), 2 / math.Sqrt(6), 2 / math.Sqrt(6),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.extractor.Extract(n)
+			if err != nil {
+				t.Fatalf("FeatureExtractor.Extract() error = %v", err)
+			}
+			if got.Dim() != tt.wantDim {
+				t.Fatalf("FeatureExtractor.Extract() dimension = %d, want %d", got.Dim(), tt.wantDim)
+			}
+
+			rounded := make([]float64, len(got.Flat()))
+			for i, v := range got.Flat() {
+				rounded[i] = math.Round(v*1e9) / 1e9
+			}
+			for i, v := range tt.want {
+				tt.want[i] = math.Round(v*1e9) / 1e9
+			}
+			if !reflect.DeepEqual(rounded, tt.want) {
+				t.Errorf("FeatureExtractor.Extract() = %v, want %v", rounded, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package neighbourhood
+
+import (
+	"context"
+	"sort"
+
+	"github.com/boson-research/patterns/internal/ahocorasick"
+	"github.com/boson-research/patterns/internal/alphabet"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// target is the neighbourhood element a pattern of the automaton stands for.
+type target struct {
+	n       *Neighbourhood
+	element *alphabet.Pattern
+}
+
+// Matcher finds the entries of the elements of many neighbourhoods in a single pass over the text.
+type Matcher struct {
+	encoding  alphabet.Encoding
+	automaton *ahocorasick.Automaton
+	// targets holds the elements by the automaton pattern ids. Equal elements of different neighbourhoods share the
+	// same pattern.
+	targets [][]target
+	// idBySymbol holds the automaton ids of the symbols met in the elements, single byte symbols are looked up in
+	// idByByte instead.
+	idBySymbol map[alphabet.Symbol]int
+	idByByte   [256]int
+	// maxLen is the length of the longest element in symbols.
+	maxLen int
+}
+
+// NewMatcher builds the matcher over the elements of all the neighbourhoods. The text is split into symbols with
+// the encoding.
+func NewMatcher(ctx context.Context, encoding alphabet.Encoding, neighbourhoods []*Neighbourhood) *Matcher {
+	ctx, span := otel.Tracer("").Start(ctx, "NewMatcher")
+	defer span.End()
+
+	m := &Matcher{
+		encoding:   encoding,
+		idBySymbol: make(map[alphabet.Symbol]int),
+	}
+	for i := range m.idByByte {
+		m.idByByte[i] = -1
+	}
+
+	idByValue := make(map[string]int)
+	var patterns [][]int
+	for _, n := range neighbourhoods {
+		for _, el := range n.Elements {
+			id, ok := idByValue[el.String()]
+			if !ok {
+				id = len(patterns)
+				idByValue[el.String()] = id
+				patterns = append(patterns, m.symbolIDs(el.Symbols()))
+				m.targets = append(m.targets, nil)
+				m.maxLen = max(m.maxLen, el.Len())
+			}
+
+			m.targets[id] = append(m.targets[id], target{n: n, element: el})
+		}
+	}
+
+	logger.MustFromContext(ctx).Debugf("building matcher for %d patterns over %d symbols", len(patterns), len(m.idBySymbol))
+
+	m.automaton = ahocorasick.New(patterns, len(m.idBySymbol))
+
+	return m
+}
+
+// FindTextEntries adds the entries found in the text to the text entries of the neighbourhoods and returns the length
+// of the text in symbols. Offsets are added to the found locations and byte offsets, so that several texts could be
+// analyzed in the same locations space.
+func (m *Matcher) FindTextEntries(ctx context.Context, text []byte, offset int, byteOffset int) int {
+	ctx, span := otel.Tracer("").Start(ctx, "FindTextEntries")
+	defer span.End()
+
+	l := logger.MustFromContext(ctx)
+	l.Debugf("finding entries in text of length %d bytes", len(text))
+
+	touched := make(map[*Neighbourhood]struct{})
+	// byteOffsets holds the byte offsets of the last maxLen symbols to find the byte offsets of the matches
+	byteOffsets := make([]int, max(m.maxLen, 1))
+	s, it := 0, 0
+	m.encoding.Tokenize(text, func(symbol []byte, symbolByteOffset int) {
+		byteOffsets[it%len(byteOffsets)] = symbolByteOffset
+
+		s = m.automaton.Step(s, m.symbolID(symbol))
+		for _, id := range m.automaton.Matches(s) {
+			start := it - m.automaton.PatternLen(id) + 1
+			loc, locByteOffset := offset+start, byteOffset+byteOffsets[start%len(byteOffsets)]
+			for _, t := range m.targets[id] {
+				l.Tracef("adding entry %s at index %d", t.element, loc)
+
+				if t.n.TextEntries == nil {
+					t.n.TextEntries = NewTextEntries()
+				}
+
+				t.n.TextEntries.Add(loc, locByteOffset, t.element)
+				touched[t.n] = struct{}{}
+			}
+		}
+
+		it++
+	})
+
+	// matches are reported by their end, so the entries of the elements of different lengths could come unordered
+	for n := range touched {
+		if !sort.IsSorted(n.TextEntries) {
+			sort.Stable(n.TextEntries)
+		}
+	}
+
+	return it
+}
+
+// symbolIDs returns the automaton ids of the symbols assigning new ids to the symbols met for the first time.
+func (m *Matcher) symbolIDs(symbols []alphabet.Symbol) []int {
+	ids := make([]int, len(symbols))
+	for i, s := range symbols {
+		id, ok := m.idBySymbol[s]
+		if !ok {
+			id = len(m.idBySymbol)
+			m.idBySymbol[s] = id
+			if len(s) == 1 {
+				m.idByByte[s[0]] = id
+			}
+		}
+
+		ids[i] = id
+	}
+
+	return ids
+}
+
+// symbolID returns the automaton id of the text symbol or -1 if the symbol is not met in any element.
+func (m *Matcher) symbolID(symbol []byte) int {
+	if len(symbol) == 1 {
+		return m.idByByte[symbol[0]]
+	}
+
+	if id, ok := m.idBySymbol[alphabet.Symbol(symbol)]; ok {
+		return id
+	}
+
+	return -1
+}
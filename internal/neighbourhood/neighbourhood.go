@@ -3,11 +3,16 @@ package neighbourhood
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 
 	"github.com/boson-research/patterns/internal/alphabet"
 	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/cluster/hierarchical"
+	"github.com/boson-research/patterns/internal/cluster/kmeans"
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/segment"
 	"github.com/boson-research/patterns/internal/telemetry/logger"
 	"github.com/samber/lo"
 	"go.opentelemetry.io/otel"
@@ -18,6 +23,31 @@ type Neighbourhood struct {
 	Elements    []*alphabet.Pattern
 	TextEntries *TextEntries
 	Clusters    []*Cluster
+	// Noise holds the text entries the clusterer left out of any cluster.
+	Noise []*TextEntry
+	// ClustersParams and ClustersScore are the clusterer params and the quality score the clusters were chosen with,
+	// ClustersSpace is the space of the clusterer params naming them, ClustersSeed is the seed of the random numbers
+	// of the clustering.
+	ClustersParams params.Values
+	ClustersSpace  params.Space
+	ClustersSeed   int64
+	ClustersScore  float64
+	// Dendrogram is the tree of the nested clusters of the text entries, the leaves are the indices of the entries. It
+	// is set by the hierarchical clusterers only.
+	Dendrogram *hierarchical.Dendrogram
+	// ClustersCandidates holds the params and the quality scores of all the clusterings evaluated to choose the clusters.
+	ClustersCandidates []*cluster.Result
+	// Silhouettes are the silhouettes of the text entries by their indices, NaN for the noise and the entries left out
+	// of the sample. They are set if the clusterings are scored with the silhouette.
+	Silhouettes []float64
+	// Stability is the stability of the clusters under the resampling of the text entries, the consensus labels are
+	// by the indices of the entries. It is set by AnalyzeStability only.
+	Stability *cluster.Stability
+	// Model assigns the text entries of other texts to the clusters by their ids without refitting. It is set by the
+	// clusterings and could be loaded to predict the clusters.
+	Model *cluster.Model
+	// Segments are the regions of the text of the constant rate of the text entries.
+	Segments []segment.Segment
 }
 
 func New(c *alphabet.Pattern) *Neighbourhood {
@@ -31,77 +61,278 @@ func (n *Neighbourhood) WithElements(elements []*alphabet.Pattern) *Neighbourhoo
 	return n
 }
 
-func (n *Neighbourhood) FindTextEntries(ctx context.Context, text []byte) {
-	ctx, span := otel.Tracer("").Start(ctx, "FindTextEntries")
+// Segment splits the text of the length into the regions of the constant rate of the text entries with the segmenter.
+func (n *Neighbourhood) Segment(ctx context.Context, segmenter *segment.Segmenter, length int) error {
+	ctx, span := otel.Tracer("").Start(ctx, "Segment")
 	defer span.End()
 
-	logger.MustFromContext(ctx).Debugf("finding entries in text for %s", n)
+	logger.MustFromContext(ctx).Debugf("segmenting neighbourhood with center: %s", n.Center)
 
-	for it := range text {
-		for _, pat := range n.Elements {
-			if checkPattern(pat, text, it) {
-				logger.MustFromContext(ctx).Tracef("adding entry %s at index %d", pat, it)
+	segments, err := segmenter.Segment(ctx, n.TextEntries.Locations(), length)
+	if err != nil {
+		return fmt.Errorf("segment neighbourhood %s: %w", n.Center, err)
+	}
 
-				if n.TextEntries == nil {
-					n.TextEntries = NewTextEntries()
-				}
+	n.Segments = segments
 
-				n.TextEntries.Add(it, pat)
-			}
-		}
-	}
+	return nil
 }
 
-func (n *Neighbourhood) Clusterize(ctx context.Context) {
+// Clusterize clusterizes the text entries with the clusterizer in the space of the features of the extractor. The
+// centers and the spreads of the clusters are measured in locations whatever the features are.
+func (n *Neighbourhood) Clusterize(ctx context.Context, clusterizer *cluster.Clusterizer, features *FeatureExtractor) error {
 	ctx, span := otel.Tracer("").Start(ctx, "Clusterize")
 	defer span.End()
 
 	logger.MustFromContext(ctx).Debugf("clusterizing %s", n)
 
-	entryByLoc := make(map[int]*TextEntry, len(n.TextEntries.Locations()))
-	for i, loc := range n.TextEntries.Locations() {
-		entryByLoc[loc] = &TextEntry{loc: loc, pattern: n.TextEntries.Patterns()[i]}
+	logger.MustFromContext(ctx).Debugf("computing clusters for neighbourhood with center: %s", n.Center)
+	data, scaling, err := features.extract(n)
+	if err != nil {
+		return fmt.Errorf("clusterize neighbourhood %s: %w", n.Center, err)
+	}
+	if scaling != nil {
+		data = scaling.Apply(data)
 	}
 
-	clusterInput := lo.Map(n.TextEntries.Locations(), func(loc int, _ int) float64 {
-		return float64(loc)
-	})
+	res, err := clusterizer.Clusterize(ctx, data)
+	if err != nil {
+		return fmt.Errorf("clusterize neighbourhood %s: %w", n.Center, err)
+	}
 
-	fmt.Printf("computing kmeans for neighbourhood with center: %s\n", n.Center)
-	centroids, labels := cluster.New(cluster.KMeans, cluster.Silhouette).Clusterize(ctx, clusterInput)
-	for label, centroid := range centroids {
-		entries := make([]*TextEntry, 0, len(labels))
+	labels := n.setClusters(res.Labels, res.Centroids.Len(), res.Medoids, false)
 
-		for i, l := range labels {
-			if l == label {
-				entries = append(entries, entryByLoc[int(clusterInput[i])])
+	if res.Probabilities != nil {
+		for _, c := range n.Clusters {
+			c.softSize = 0
+			for i := range res.Labels {
+				c.softSize += res.Probabilities[i][labels[c]]
+			}
+
+			for _, e := range c.entries {
+				e.probabilities = make([]float64, len(n.Clusters))
+				for id, other := range n.Clusters {
+					e.probabilities[id] = res.Probabilities[e.index][labels[other]]
+				}
+				e.probability = e.probabilities[c.id]
 			}
 		}
+	}
 
-		n.Clusters = append(n.Clusters, &Cluster{
-			center:  centroid,
-			entries: entries,
-		})
+	n.Silhouettes = res.Silhouettes
+	for _, c := range n.Clusters {
+		c.silhouette = meanSilhouette(c.entries, res.Silhouettes)
 	}
 
-	// sort clusters by center
-	sort.Slice(n.Clusters, func(i, j int) bool {
-		return n.Clusters[i].center < n.Clusters[j].center
-	})
+	n.ClustersParams = res.Params
+	n.ClustersSpace = res.Space
+	n.ClustersSeed = res.Seed
+	n.ClustersScore = res.Score
+	n.ClustersCandidates = res.Candidates
+	n.Dendrogram = res.Dendrogram
+	n.Stability = nil
+	n.Model = n.selectModel(res.Model, labels)
+	n.Model.Scaling = scaling
+
+	return nil
 }
 
-func checkPattern(p *alphabet.Pattern, text []byte, it int) bool {
-	for ip := range p.Value() {
-		if it+ip >= len(text) {
-			return false
+// AnalyzeStability measures the stability of the clusters of the text entries by clustering the resamples of the
+// entries with the clusterizer, which should be the one the clusters were found with, and the params they were chosen
+// with. The entries are mapped to the points by the extractor as they are for clustering.
+func (n *Neighbourhood) AnalyzeStability(ctx context.Context, clusterizer *cluster.Clusterizer, features *FeatureExtractor) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AnalyzeStability")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debugf("analyzing stability of clusters of neighbourhood with center: %s", n.Center)
+
+	if n.Clusters == nil {
+		return fmt.Errorf("analyze stability of neighbourhood %s: not clusterized", n.Center)
+	}
+
+	data, err := features.Extract(n)
+	if err != nil {
+		return fmt.Errorf("analyze stability of neighbourhood %s: %w", n.Center, err)
+	}
+
+	labels := make([]int, n.TextEntries.Len())
+	for i := range labels {
+		labels[i] = cluster.Noise
+	}
+	for _, c := range n.Clusters {
+		for _, e := range c.entries {
+			labels[e.index] = c.id
 		}
+	}
+
+	stability, err := clusterizer.Stability(ctx, data, labels, n.ClustersParams)
+	if err != nil {
+		return fmt.Errorf("analyze stability of neighbourhood %s: %w", n.Center, err)
+	}
 
-		if p.Value()[ip] != text[it+ip] {
-			return false
+	n.Stability = stability
+	for _, c := range n.Clusters {
+		if j, ok := stability.Jaccard[c.id]; ok {
+			c.stability, c.cohesion = j, stability.Cohesion[c.id]
 		}
 	}
 
-	return true
+	return nil
+}
+
+// ClusterizeStream clusterizes the locations of the text entries with the streaming k-means, the locations are
+// consumed from the iterator until the centroids converge and every entry is labeled by the nearest centroid.
+func (n *Neighbourhood) ClusterizeStream(ctx context.Context, stream *kmeans.Stream) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ClusterizeStream")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debugf("clusterizing neighbourhood with center %s by stream", n.Center)
+
+	if err := stream.Consume(ctx, n.TextEntries.LocationIterator()); err != nil {
+		return fmt.Errorf("clusterize neighbourhood %s: %w", n.Center, err)
+	}
+
+	logger.MustFromContext(ctx).Debugf("consumed %d of %d entries, converged: %t", stream.Consumed(), n.TextEntries.Len(), stream.Converged())
+
+	labels := make([]int, n.TextEntries.Len())
+	point := make([]float64, 1)
+	for i, loc := range n.TextEntries.Locations() {
+		point[0] = float64(loc)
+		labels[i] = stream.Label(point)
+	}
+	clusterLabels := n.setClusters(labels, stream.Centroids().Len(), nil, false)
+
+	n.ClustersSpace = stream.Params()
+	n.ClustersParams = stream.Values()
+	n.ClustersSeed = stream.Seed()
+	n.ClustersScore = math.NaN()
+	n.Silhouettes = nil
+	n.ClustersCandidates = nil
+	n.Dendrogram = nil
+	n.Stability = nil
+	n.Model = n.selectModel(&cluster.Model{Centroids: stream.Centroids(), Metric: stream.Metric()}, clusterLabels)
+
+	return nil
+}
+
+// Predict assigns the text entries to the clusters of the model without refitting, the clusters keep the ids of the
+// model and the params and the seed of the clustering the model was fitted by. The entries are mapped to the points
+// by the extractor, which should extract the features the model was fitted on, the model scales them itself.
+func (n *Neighbourhood) Predict(ctx context.Context, features *FeatureExtractor) error {
+	ctx, span := otel.Tracer("").Start(ctx, "Predict")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debugf("predicting clusters for neighbourhood with center: %s", n.Center)
+
+	if n.Model == nil {
+		return fmt.Errorf("predict clusters of neighbourhood %s: no model", n.Center)
+	}
+
+	data, _, err := features.extract(n)
+	if err != nil {
+		return fmt.Errorf("predict clusters of neighbourhood %s: %w", n.Center, err)
+	}
+
+	labels, err := n.Model.Predict(ctx, data)
+	if err != nil {
+		return fmt.Errorf("predict clusters of neighbourhood %s: %w", n.Center, err)
+	}
+	n.setClusters(labels, n.Model.Centroids.Len(), nil, true)
+
+	n.ClustersScore = math.NaN()
+	n.Silhouettes = nil
+	n.ClustersCandidates = nil
+	n.Dendrogram = nil
+	n.Stability = nil
+
+	return nil
+}
+
+// selectModel returns the model of the clusters of the neighbourhood by their ids, the clusters are labeled by the
+// model as the labels map them.
+func (n *Neighbourhood) selectModel(model *cluster.Model, labels map[*Cluster]int) *cluster.Model {
+	selected := make([]int, len(n.Clusters))
+	for id, c := range n.Clusters {
+		selected[id] = labels[c]
+	}
+
+	return model.Select(selected)
+}
+
+// setClusters sets the clusters of the text entries by their labels, the noise and the empty clusters are dropped
+// and the rest are sorted by center, unless ordered is set: then they keep the order and the ids of the labels. It
+// returns the labels of the clusters.
+func (n *Neighbourhood) setClusters(entryLabels []int, clustersNum int, medoids []int, ordered bool) map[*Cluster]int {
+	n.Clusters = make([]*Cluster, clustersNum)
+	for label := range n.Clusters {
+		n.Clusters[label] = &Cluster{}
+	}
+
+	n.Noise = nil
+	for i, l := range entryLabels {
+		if l == cluster.Noise {
+			n.Noise = append(n.Noise, n.TextEntries.entry(i))
+			continue
+		}
+
+		c := n.Clusters[l]
+		c.entries = append(c.entries, n.TextEntries.entry(i))
+		c.center += float64(n.TextEntries.Locations()[i])
+	}
+
+	for label, i := range medoids {
+		n.Clusters[label].medoid = n.TextEntries.entry(i)
+	}
+
+	// drop empty clusters and sort the rest by center
+	labels := make(map[*Cluster]int, len(n.Clusters))
+	for label, c := range n.Clusters {
+		labels[c] = label
+	}
+	n.Clusters = lo.Filter(n.Clusters, func(c *Cluster, _ int) bool { return len(c.entries) > 0 })
+	for _, c := range n.Clusters {
+		c.center /= float64(len(c.entries))
+		for _, e := range c.entries {
+			c.spread += (float64(e.Loc()) - c.center) * (float64(e.Loc()) - c.center)
+		}
+		c.spread = math.Sqrt(c.spread / float64(len(c.entries)))
+	}
+	if !ordered {
+		sort.SliceStable(n.Clusters, func(i, j int) bool {
+			return n.Clusters[i].center < n.Clusters[j].center
+		})
+	}
+
+	for id, c := range n.Clusters {
+		c.id = id
+		if ordered {
+			c.id = labels[c]
+		}
+		c.silhouette, c.stability, c.cohesion = math.NaN(), math.NaN(), math.NaN()
+		c.softSize = float64(len(c.entries))
+		for _, e := range c.entries {
+			e.probability = 1
+		}
+	}
+
+	return labels
+}
+
+// meanSilhouette returns the mean silhouette of the entries, the NaN ones are skipped. It is NaN if there are no
+// silhouettes.
+func meanSilhouette(entries []*TextEntry, silhouettes []float64) float64 {
+	sum, count := 0.0, 0
+	for _, e := range entries {
+		if e.index < len(silhouettes) && !math.IsNaN(silhouettes[e.index]) {
+			sum += silhouettes[e.index]
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+
+	return sum / float64(count)
 }
 
 func (n *Neighbourhood) String() string {
@@ -119,6 +350,9 @@ func (n *Neighbourhood) String() string {
 		for _, c := range n.Clusters {
 			b.WriteString(c.String())
 		}
+		if len(n.Noise) > 0 {
+			b.WriteString(fmt.Sprintf("\nnoise %d\n", len(n.Noise)))
+		}
 	} else {
 		return b.String()
 	}
@@ -1,64 +1,209 @@
 package neighbourhood
 
 import (
+	"context"
+	"reflect"
 	"testing"
 
 	"github.com/boson-research/patterns/internal/alphabet"
+	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/cluster/kmeans"
+	"github.com/boson-research/patterns/internal/cluster/params"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"github.com/samber/lo"
 )
 
-func Test_checkPattern(t *testing.T) {
+var ctx = logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+func TestMatcher_FindTextEntries(t *testing.T) {
 	type args struct {
-		pattern *alphabet.Pattern
-		text    []byte
-		it      int
+		elements   [][]*alphabet.Pattern
+		text       []byte
+		offset     int
+		byteOffset int
 	}
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name            string
+		args            args
+		want            [][]int
+		wantByteOffsets [][]int
 	}{
 		{
 			name: "out of bounds",
 			args: args{
-				pattern: alphabet.NewPattern([]byte{'a', 'a', 'a'}),
-				text:    []byte("a"),
-				it:      0,
+				elements: [][]*alphabet.Pattern{{pattern("aaa")}},
+				text:     []byte("a"),
 			},
-			want: false,
+			want:            [][]int{nil},
+			wantByteOffsets: [][]int{nil},
 		},
 		{
 			name: "out of bounds 2",
 			args: args{
-				pattern: alphabet.NewPattern([]byte{'a', 'a', 'a'}),
-				text:    []byte("aaa"),
-				it:      1,
+				elements: [][]*alphabet.Pattern{{pattern("aaa")}},
+				text:     []byte("baa"),
 			},
-			want: false,
+			want:            [][]int{nil},
+			wantByteOffsets: [][]int{nil},
 		},
 		{
 			name: "success",
 			args: args{
-				pattern: alphabet.NewPattern([]byte{'a', 'b', 'c'}),
-				text:    []byte("dabc"),
-				it:      1,
+				elements: [][]*alphabet.Pattern{{pattern("abc")}},
+				text:     []byte("dabc"),
+			},
+			want:            [][]int{{1}},
+			wantByteOffsets: [][]int{{1}},
+		},
+		{
+			name: "overlapping",
+			args: args{
+				elements:   [][]*alphabet.Pattern{{pattern("aaa")}},
+				text:       []byte("aaaaa"),
+				offset:     10,
+				byteOffset: 20,
+			},
+			want:            [][]int{{10, 11, 12}},
+			wantByteOffsets: [][]int{{20, 21, 22}},
+		},
+		{
+			name: "several neighbourhoods",
+			args: args{
+				elements: [][]*alphabet.Pattern{
+					{pattern("aab"), pattern("abb")},
+					{pattern("baa"), pattern("abb")},
+				},
+				text: []byte("aabbaab"),
 			},
-			want: true,
+			want:            [][]int{{0, 1, 4}, {1, 3}},
+			wantByteOffsets: [][]int{{0, 1, 4}, {1, 3}},
 		},
 		{
-			name: "fail",
+			name: "different lengths",
 			args: args{
-				pattern: alphabet.NewPattern([]byte{'a', 'b', 'c'}),
-				text:    []byte("dabc"),
-				it:      0,
+				elements: [][]*alphabet.Pattern{
+					{pattern("abcd"), pattern("c")},
+				},
+				text: []byte("abcd"),
 			},
-			want: false,
+			want:            [][]int{{0, 2}},
+			wantByteOffsets: [][]int{{0, 2}},
+		},
+		{
+			name: "multi-byte symbols",
+			args: args{
+				elements: [][]*alphabet.Pattern{{pattern("жыж"), pattern("ыж")}},
+				text:     []byte("aжыжыжb"),
+			},
+			want:            [][]int{{1, 2, 3, 4}},
+			wantByteOffsets: [][]int{{1, 3, 5, 7}},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := checkPattern(tt.args.pattern, tt.args.text, tt.args.it); got != tt.want {
-				t.Errorf("Processor.checkPattern() = %v, want %v", got, tt.want)
+			ns := make([]*Neighbourhood, 0, len(tt.args.elements))
+			for _, elements := range tt.args.elements {
+				ns = append(ns, New(alphabet.NewPattern(nil)).WithElements(elements))
+			}
+
+			NewMatcher(ctx, alphabet.UTF8, ns).FindTextEntries(ctx, tt.args.text, tt.args.offset, tt.args.byteOffset)
+
+			for i, n := range ns {
+				if got := n.TextEntries.Locations(); !reflect.DeepEqual(got, tt.want[i]) {
+					t.Errorf("Matcher.FindTextEntries() neighbourhood %d locations = %v, want %v", i, got, tt.want[i])
+				}
+				if got := n.TextEntries.ByteOffsets(); !reflect.DeepEqual(got, tt.wantByteOffsets[i]) {
+					t.Errorf("Matcher.FindTextEntries() neighbourhood %d byte offsets = %v, want %v", i, got, tt.wantByteOffsets[i])
+				}
 			}
 		})
 	}
 }
+
+func TestTextEntries_LocationIterator(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, 12, 100} {
+		te := NewTextEntries()
+		for loc := 0; loc < n; loc++ {
+			te.Add(loc, loc, nil)
+		}
+
+		var got []int
+		it := te.LocationIterator()
+		for p, ok := it.Next(); ok; p, ok = it.Next() {
+			got = append(got, int(p[0]))
+		}
+
+		// every location is visited once and the first tenth of the visits reaches past the middle of the text
+		if len(got) != n || len(lo.Uniq(got)) != n {
+			t.Errorf("TextEntries.LocationIterator() of %d locations visited %v", n, got)
+		}
+		if n >= 10 && lo.Max(got[:n/10+1]) < n/2 {
+			t.Errorf("TextEntries.LocationIterator() of %d locations started with %v", n, got[:n/10+1])
+		}
+	}
+}
+
+func TestNeighbourhood_ClusterizeStream(t *testing.T) {
+	n := New(pattern("a"))
+	n.TextEntries = NewTextEntries()
+	for _, loc := range []int{1, 2, 3, 100, 101, 102, 103} {
+		n.TextEntries.Add(loc, loc, pattern("a"))
+	}
+
+	stream := kmeans.NewStream()
+	stream.SetMetric(points.Manhattan)
+	if err := stream.SetParams(ctx, params.Values{2, float64(kmeans.PlusPlusCentroidsIniter), 4, 1e-4, 10}); err != nil {
+		t.Fatalf("Stream.SetParams() error = %v", err)
+	}
+	if err := n.ClusterizeStream(ctx, stream); err != nil {
+		t.Fatalf("Neighbourhood.ClusterizeStream() error = %v", err)
+	}
+
+	var sizes []int
+	for _, c := range n.Clusters {
+		sizes = append(sizes, c.Size())
+	}
+	if !reflect.DeepEqual(sizes, []int{3, 4}) {
+		t.Errorf("Neighbourhood.ClusterizeStream() cluster sizes = %v, want [3 4]", sizes)
+	}
+	if got := n.ClustersSpace.Format(n.ClustersParams); got != "k=2 initer=plusplus batch-size=4 tolerance=0.0001 patience=10" {
+		t.Errorf("Neighbourhood.ClusterizeStream() params = %q", got)
+	}
+	if n.Model.Metric != points.Manhattan {
+		t.Errorf("Neighbourhood.ClusterizeStream() model metric = %s, want manhattan", n.Model.Metric.Name())
+	}
+}
+
+func TestNeighbourhood_Predict(t *testing.T) {
+	n := New(pattern("a"))
+	n.TextEntries = NewTextEntries()
+	for _, loc := range []int{1, 2, 3, 100, 101, 102, 103} {
+		n.TextEntries.Add(loc, loc, pattern("a"))
+	}
+
+	features := NewFeatureExtractor().WithStandardization(true)
+	if err := n.Clusterize(ctx, cluster.New(cluster.KMeans, cluster.Silhouette).WithParams(map[string]string{"k": "2"}), features); err != nil {
+		t.Fatalf("Neighbourhood.Clusterize() error = %v", err)
+	}
+
+	// the entries of another text close to the second cluster only keep its id
+	other := New(pattern("a"))
+	other.Model = n.Model
+	other.TextEntries = NewTextEntries()
+	for _, loc := range []int{90, 95, 200} {
+		other.TextEntries.Add(loc, loc, pattern("a"))
+	}
+
+	if err := other.Predict(ctx, NewFeatureExtractor()); err != nil {
+		t.Fatalf("Neighbourhood.Predict() error = %v", err)
+	}
+
+	if len(other.Clusters) != 1 || other.Clusters[0].ID() != 1 || other.Clusters[0].Size() != 3 {
+		t.Errorf("Neighbourhood.Predict() clusters = %v, want a cluster of id 1 of 3 entries", other.Clusters)
+	}
+}
+
+func pattern(s string) *alphabet.Pattern {
+	return alphabet.NewPattern(alphabet.UTF8.Split([]byte(s)))
+}
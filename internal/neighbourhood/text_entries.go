@@ -2,32 +2,68 @@ package neighbourhood
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/boson-research/patterns/internal/alphabet"
+	"github.com/boson-research/patterns/internal/cluster/points"
 	"github.com/samber/lo"
 )
 
 type TextEntry struct {
-	loc     int
-	pattern *alphabet.Pattern
+	// index is the index of the entry in the text entries of the neighbourhood.
+	index      int
+	loc        int
+	byteOffset int
+	pattern    *alphabet.Pattern
+	// probability is the probability of the entry to belong to its cluster, probabilities are the probabilities to
+	// belong to every cluster of the neighbourhood by their ids.
+	probability   float64
+	probabilities []float64
 }
 
+// Index returns the index of the entry in the text entries of the neighbourhood.
+func (te *TextEntry) Index() int {
+	return te.index
+}
+
+// Loc returns the location of the entry in symbols.
 func (te *TextEntry) Loc() int {
 	return te.loc
 }
 
+// ByteOffset returns the location of the entry in bytes.
+func (te *TextEntry) ByteOffset() int {
+	return te.byteOffset
+}
+
 func (te *TextEntry) Pattern() *alphabet.Pattern {
 	return te.pattern
 }
 
+// Probability returns the probability of the entry to belong to its cluster: 1 for the hard clusterings and 0 for the
+// noise.
+func (te *TextEntry) Probability() float64 {
+	return te.probability
+}
+
+// Probabilities returns the probabilities of the entry to belong to every cluster of the neighbourhood by the cluster
+// ids, nil for the hard clusterings. The probabilities of the clusters left empty by the clusterer are dropped, so they
+// could sum to less than 1.
+func (te *TextEntry) Probabilities() []float64 {
+	return te.probabilities
+}
+
 func (te *TextEntry) String() string {
 	return fmt.Sprintf("{%d - %s}", te.loc, te.pattern)
 }
 
+// TextEntries holds the entries ordered by location. Locations are measured in symbols, byte offsets are kept
+// alongside.
 type TextEntries struct {
-	locations []int
-	patterns  []*alphabet.Pattern
+	locations   []int
+	byteOffsets []int
+	patterns    []*alphabet.Pattern
 }
 
 func NewTextEntries() *TextEntries {
@@ -36,21 +72,28 @@ func NewTextEntries() *TextEntries {
 
 func NewTextEntriesWithSize(size int) *TextEntries {
 	return &TextEntries{
-		locations: make([]int, 0, size),
-		patterns:  make([]*alphabet.Pattern, 0, size),
+		locations:   make([]int, 0, size),
+		byteOffsets: make([]int, 0, size),
+		patterns:    make([]*alphabet.Pattern, 0, size),
 	}
 }
 
-func (te *TextEntries) Add(loc int, pat *alphabet.Pattern) {
+func (te *TextEntries) Add(loc int, byteOffset int, pat *alphabet.Pattern) {
 	te.locations = append(te.locations, loc)
+	te.byteOffsets = append(te.byteOffsets, byteOffset)
 	te.patterns = append(te.patterns, pat)
 }
 
-func (te *TextEntries) AddMany(locs []int, pats []*alphabet.Pattern) {
+func (te *TextEntries) AddMany(locs []int, byteOffsets []int, pats []*alphabet.Pattern) {
 	te.locations = append(te.locations, locs...)
+	te.byteOffsets = append(te.byteOffsets, byteOffsets...)
 	te.patterns = append(te.patterns, pats...)
 }
 
+func (te *TextEntries) entry(i int) *TextEntry {
+	return &TextEntry{index: i, loc: te.locations[i], byteOffset: te.byteOffsets[i], pattern: te.patterns[i]}
+}
+
 func (te *TextEntries) Locations() []int {
 	if te == nil {
 		return nil
@@ -59,6 +102,56 @@ func (te *TextEntries) Locations() []int {
 	return te.locations
 }
 
+// LocationIterator returns the iterator over the locations of the entries as 1-D points. The locations are visited
+// with a stride coprime with their number, close to its golden section, so that every prefix of the iteration spreads
+// over the whole text and the streaming clusterers stopping early see all its parts.
+func (te *TextEntries) LocationIterator() points.Iterator {
+	n := len(te.Locations())
+	stride := max(1, int(math.Round(float64(n)*(math.Sqrt(5)-1)/2)))
+	for gcd(stride, n) > 1 {
+		stride++
+	}
+
+	return &locationIterator{locations: te.Locations(), stride: stride, point: make([]float64, 1)}
+}
+
+type locationIterator struct {
+	locations []int
+	stride    int
+	// visited is the number of the locations visited, next is the index of the next one
+	visited int
+	next    int
+	point   []float64
+}
+
+func (it *locationIterator) Next() ([]float64, bool) {
+	if it.visited >= len(it.locations) {
+		return nil, false
+	}
+
+	it.point[0] = float64(it.locations[it.next])
+	it.visited++
+	it.next = (it.next + it.stride) % len(it.locations)
+
+	return it.point, true
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+func (te *TextEntries) ByteOffsets() []int {
+	if te == nil {
+		return nil
+	}
+
+	return te.byteOffsets
+}
+
 func (te *TextEntries) Patterns() []*alphabet.Pattern {
 	if te == nil {
 		return nil
@@ -67,6 +160,20 @@ func (te *TextEntries) Patterns() []*alphabet.Pattern {
 	return te.patterns
 }
 
+func (te *TextEntries) Len() int {
+	return len(te.locations)
+}
+
+func (te *TextEntries) Less(i, j int) bool {
+	return te.locations[i] < te.locations[j]
+}
+
+func (te *TextEntries) Swap(i, j int) {
+	te.locations[i], te.locations[j] = te.locations[j], te.locations[i]
+	te.byteOffsets[i], te.byteOffsets[j] = te.byteOffsets[j], te.byteOffsets[i]
+	te.patterns[i], te.patterns[j] = te.patterns[j], te.patterns[i]
+}
+
 func (te *TextEntries) String() string {
 	b := strings.Builder{}
 	b.WriteString(strings.Join(lo.Map(te.locations, func(_ int, i int) string {
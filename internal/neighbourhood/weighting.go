@@ -0,0 +1,163 @@
+package neighbourhood
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Weighting is the policy the text entries are weighted by in the clustering.
+type Weighting int
+
+const (
+	// UniformWeighting weighs all the entries the same.
+	UniformWeighting Weighting = iota
+	// RarityWeighting weighs an entry inversely to the number of the entries of its pattern in the neighbourhood, so
+	// that every pattern weighs the same in total.
+	RarityWeighting
+	// PatternWeighting weighs an entry by the weight set for its pattern, 1 if none is set.
+	PatternWeighting
+	// ScoreWeighting weighs an entry by the score set for its location, 1 if none is set.
+	ScoreWeighting
+)
+
+// Weightings lists all the weighting policies.
+var Weightings = []Weighting{UniformWeighting, RarityWeighting, PatternWeighting, ScoreWeighting}
+
+func (w Weighting) String() string {
+	switch w {
+	case UniformWeighting:
+		return "uniform"
+	case RarityWeighting:
+		return "rarity"
+	case PatternWeighting:
+		return "pattern"
+	case ScoreWeighting:
+		return "score"
+	}
+	return "unknown"
+}
+
+// ParseWeighting returns the weighting policy by its name.
+func ParseWeighting(s string) (Weighting, error) {
+	for _, w := range Weightings {
+		if w.String() == s {
+			return w, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weighting: %s", s)
+}
+
+// Weigher weighs the text entries of a neighbourhood by the weighting policy.
+type Weigher struct {
+	weighting Weighting
+	// patternWeights are the weights of the patterns by their strings, scores are the scores of the locations.
+	patternWeights map[string]float64
+	scores         map[int]float64
+}
+
+// NewWeigher returns the weigher by the policy.
+func NewWeigher(weighting Weighting) *Weigher {
+	return &Weigher{weighting: weighting}
+}
+
+// WithPatternWeights sets the weights of the patterns by their strings for the pattern weighting.
+func (w *Weigher) WithPatternWeights(weights map[string]float64) *Weigher {
+	w.patternWeights = weights
+	return w
+}
+
+// WithScores sets the scores of the locations for the score weighting.
+func (w *Weigher) WithScores(scores map[int]float64) *Weigher {
+	w.scores = scores
+	return w
+}
+
+// Weigh returns the weights of the text entries of the neighbourhood in the order of the entries, nil for the uniform
+// weighting. The weights are scaled to the mean of 1, so that the weighted entries count as many as the unweighted
+// ones in the quality scores.
+func (w *Weigher) Weigh(n *Neighbourhood) []float64 {
+	if w == nil || w.weighting == UniformWeighting {
+		return nil
+	}
+
+	patterns := n.TextEntries.Patterns()
+	weights := make([]float64, len(patterns))
+	switch w.weighting {
+	case RarityWeighting:
+		counts := make(map[string]int)
+		for _, pat := range patterns {
+			counts[pat.String()]++
+		}
+		for i, pat := range patterns {
+			weights[i] = 1 / float64(counts[pat.String()])
+		}
+	case PatternWeighting:
+		for i, pat := range patterns {
+			weights[i] = 1
+			if v, ok := w.patternWeights[pat.String()]; ok {
+				weights[i] = v
+			}
+		}
+	case ScoreWeighting:
+		for i, loc := range n.TextEntries.Locations() {
+			weights[i] = 1
+			if v, ok := w.scores[loc]; ok {
+				weights[i] = v
+			}
+		}
+	}
+
+	total := 0.0
+	for _, v := range weights {
+		total += v
+	}
+	if total > 0 {
+		for i := range weights {
+			weights[i] *= float64(len(weights)) / total
+		}
+	}
+
+	return weights
+}
+
+// ReadScores reads the scores of the locations, a location and its score separated by spaces per line. The empty lines
+// and the lines starting with # are skipped.
+func ReadScores(r io.Reader) (map[int]float64, error) {
+	scores := make(map[int]float64)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want location and score, got %q", line, text)
+		}
+
+		loc, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: parse location: %w", line, err)
+		}
+
+		score, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: parse score: %w", line, err)
+		}
+		if score < 0 || math.IsNaN(score) || math.IsInf(score, 0) {
+			return nil, fmt.Errorf("line %d: score must be non-negative, got %s", line, fields[1])
+		}
+
+		scores[loc] = score
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read scores: %w", err)
+	}
+
+	return scores, nil
+}
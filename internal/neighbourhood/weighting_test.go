@@ -0,0 +1,84 @@
+package neighbourhood
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/alphabet"
+)
+
+func TestWeigher_Weigh(t *testing.T) {
+	n := New(pattern("abb")).WithElements([]*alphabet.Pattern{pattern("aab"), pattern("abb")})
+	n.TextEntries = NewTextEntries()
+	n.TextEntries.AddMany(
+		[]int{0, 10, 12, 100},
+		[]int{0, 10, 12, 100},
+		[]*alphabet.Pattern{pattern("aab"), pattern("abb"), pattern("abb"), pattern("abb")},
+	)
+
+	tests := []struct {
+		name    string
+		weigher *Weigher
+		want    []float64
+	}{
+		{name: "nil", weigher: nil, want: nil},
+		{name: "uniform", weigher: NewWeigher(UniformWeighting), want: nil},
+		{name: "rarity", weigher: NewWeigher(RarityWeighting), want: []float64{2, 2.0 / 3, 2.0 / 3, 2.0 / 3}},
+		{
+			name:    "pattern",
+			weigher: NewWeigher(PatternWeighting).WithPatternWeights(map[string]float64{"aab": 5}),
+			want:    []float64{2.5, 0.5, 0.5, 0.5},
+		},
+		{
+			name:    "score",
+			weigher: NewWeigher(ScoreWeighting).WithScores(map[int]float64{10: 0, 12: 2}),
+			want:    []float64{1, 0, 2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.weigher.Weigh(n)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("Weigh() = %v, want nil", got)
+				}
+				return
+			}
+
+			for i := range got {
+				got[i] = math.Round(got[i]*1e9) / 1e9
+				tt.want[i] = math.Round(tt.want[i]*1e9) / 1e9
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Weigh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadScores(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[int]float64
+		wantErr bool
+	}{
+		{name: "scores", input: "# location score\n10 0.5\n\n 12\t2 \n", want: map[int]float64{10: 0.5, 12: 2}},
+		{name: "missing score", input: "10\n", wantErr: true},
+		{name: "bad location", input: "a 1\n", wantErr: true},
+		{name: "negative score", input: "10 -1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadScores(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadScores() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadScores() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,133 +1,471 @@
 package processor
 
 import (
-	// "fmt"
-	// "strings"
-
 	"context"
-	"encoding/csv"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"time"
 
 	"github.com/boson-research/patterns/internal/alphabet"
+	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/cluster/kmeans"
+	"github.com/boson-research/patterns/internal/cluster/points"
+	"github.com/boson-research/patterns/internal/export"
+	"github.com/boson-research/patterns/internal/model"
 	"github.com/boson-research/patterns/internal/neighbourhood"
+	"github.com/boson-research/patterns/internal/segment"
+	"github.com/boson-research/patterns/internal/shape"
 	"github.com/boson-research/patterns/internal/telemetry/logger"
 	"go.opentelemetry.io/otel"
-	// "github.com/samber/lo"
 )
 
+// Config holds the settings of the processing pipeline.
+type Config struct {
+	Shape                   *shape.Shape
+	Export                  export.Config
+	Clusterer               cluster.ClustererType
+	QualityEstimationMethod cluster.QualityEstimationMethod
+	// ReportedMethods are the quality estimation methods the clusterings are scored with besides the chosen one.
+	ReportedMethods []cluster.QualityEstimationMethod
+	// Seed is the seed of the random numbers of the clusterers and the search.
+	Seed int64
+	// Params fixes the clusterer params by their names, the rest of the tunable params are searched over.
+	Params map[string]string
+	// Search is the strategy choosing the evaluated clusterer params variations, MaxEvaluations and Timeout are its
+	// budget, unlimited if not positive.
+	Search         cluster.SearchStrategy
+	MaxEvaluations int
+	Timeout        time.Duration
+	// SilhouetteSample is the number of the random entries the silhouette is estimated on, all of them if not positive.
+	SilhouetteSample int
+	// Concurrency is the number of the clusterer params variations evaluated in parallel, GOMAXPROCS if not positive.
+	Concurrency int
+	// Features are the features of the text entries clustered, DensityRadius is the radius of the density feature.
+	// Standardize scales every feature to zero mean and unit variance.
+	Features      []neighbourhood.Feature
+	DensityRadius int
+	Standardize   bool
+	// Weighting is the policy the text entries are weighted by in the clustering, PatternWeights are the weights of
+	// the patterns by their strings for the pattern weighting, Scores are the scores of the locations for the score
+	// weighting.
+	Weighting      neighbourhood.Weighting
+	PatternWeights map[string]float64
+	Scores         map[int]float64
+	// Metric is the distance between the feature vectors.
+	Metric points.Metric
+	// Segmentation is the change point detection method the texts are segmented with, Penalty is the penalty of a
+	// change point, the bayesian information criterion if not positive.
+	Segmentation segment.Method
+	Penalty      float64
+	// Resampling is the method of the resampling of the text entries the stability of the clusters is measured on,
+	// Resamples is the number of the resamples and SampleFraction is the fraction of the entries subsampled, the
+	// defaults if not positive.
+	Resampling     cluster.ResamplingMethod
+	Resamples      int
+	SampleFraction float64
+	// Stream clusterizes the locations by the streaming k-means configured by Params instead of the clusterer, the
+	// features, the weighting, the metric and the search settings except the seed are ignored.
+	Stream bool
+}
+
+// DefaultConfig returns the config the processor used to be hard-coded with.
+func DefaultConfig() Config {
+	return Config{
+		Shape: shape.MustParse(shape.Default),
+		Export: export.Config{
+			Format: export.CSV,
+			Dir:    "output",
+		},
+		Clusterer:               cluster.KMeans,
+		QualityEstimationMethod: cluster.Silhouette,
+		Features:                []neighbourhood.Feature{neighbourhood.LocationFeature},
+		DensityRadius:           neighbourhood.DefaultDensityRadius,
+		Metric:                  points.Euclidean,
+	}
+}
+
 type Processor struct {
+	cfg            Config
+	alphabet       *alphabet.Alphabet
 	neighbourhoods []*neighbourhood.Neighbourhood
+	matcher        *neighbourhood.Matcher
+	// textOffset and textByteOffset are the total length of the texts analyzed so far in symbols and bytes.
+	textOffset     int
+	textByteOffset int
+	// documentStarts holds the locations the analyzed texts start at.
+	documentStarts []int
+}
+
+func New(ctx context.Context, cfg Config) *Processor {
+	return &Processor{cfg: cfg}
 }
 
-func New(ctx context.Context) *Processor {
-	return &Processor{}
+// Neighbourhoods returns the neighbourhoods extracted from the alphabet.
+func (p *Processor) Neighbourhoods() []*neighbourhood.Neighbourhood {
+	return p.neighbourhoods
 }
 
-func (p *Processor) AnalyzeAlphabet(ctx context.Context, a alphabet.Alphabet) {
+func (p *Processor) AnalyzeAlphabet(ctx context.Context, a *alphabet.Alphabet) error {
 	ctx, span := otel.Tracer("").Start(ctx, "AnalyzeAlphabet")
 	defer span.End()
 
 	logger.MustFromContext(ctx).Debug("analyzing alphabet")
 
-	centers := p.extractCenters(ctx, a)
+	centers, err := p.extractCenters(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	p.alphabet = a
 	p.neighbourhoods = p.extractNeighbourhoods(ctx, a, centers)
+	p.matcher = neighbourhood.NewMatcher(ctx, a.Encoding(), p.neighbourhoods)
 
 	logger.MustFromContext(ctx).Debugf("alphabet analyzed\n%s", p.neighbourhoods)
+
+	return nil
 }
 
+// AnalyzeText finds the neighbourhoods entries in the text. The text is split into symbols with the encoding of the
+// alphabet. It could be called several times, the locations of the entries found in the subsequent texts are shifted
+// by the total length of the previous ones.
 func (p *Processor) AnalyzeText(ctx context.Context, text []byte) {
 	ctx, span := otel.Tracer("").Start(ctx, "AnalyzeText")
 	defer span.End()
 
 	logger.MustFromContext(ctx).Debug("analyzing text")
 
-	p.findTextEntries(ctx, text)
-	p.exportNeighbourhoods()
-	// p.clusterize(ctx)
+	p.documentStarts = append(p.documentStarts, p.textOffset)
+	p.textOffset += p.findTextEntries(ctx, text)
+	p.textByteOffset += len(text)
+
+	logger.MustFromContext(ctx).Debug("text analyzed")
+}
+
+// Export writes the found text entries according to the export config.
+func (p *Processor) Export(ctx context.Context) error {
+	ctx, span := otel.Tracer("").Start(ctx, "Export")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debugf("exporting neighbourhoods as %s", p.cfg.Export.Format)
 
-	// logger.MustFromContext(ctx).Info("text analyzed")
+	if err := export.New(p.cfg.Export).Export(ctx, p.neighbourhoods); err != nil {
+		return fmt.Errorf("export neighbourhoods: %w", err)
+	}
 
-	// // print resulting neighbourhoods
-	// fmt.Print(
-	// 	strings.Join(
-	// 		lo.Map(
-	// 			lo.Filter(
-	// 				p.neighbourhoods,
-	// 				func(n *neighbourhood.Neighbourhood, _ int) bool { return n.TextEntries != nil },
-	// 			),
-	// 			func(n *neighbourhood.Neighbourhood, _ int) string { return n.String() },
-	// 		),
-	// 		"\n",
-	// 	),
-	// )
+	return nil
 }
 
-func (p *Processor) exportNeighbourhoods() {
+// Clusterize clusterizes the text entries of every neighbourhood, the ones of more entries than the clusterer could
+// take are skipped with a warning.
+func (p *Processor) Clusterize(ctx context.Context) error {
+	ctx, span := otel.Tracer("").Start(ctx, "Clusterize")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debug("clusterizing")
+
+	if p.cfg.Stream {
+		return p.clusterizeStream(ctx)
+	}
+
+	clusterizer, features := p.clusterizer(), p.features()
 	for _, n := range p.neighbourhoods {
 		if len(n.TextEntries.Locations()) == 0 {
 			continue
 		}
 
-		// write csv to file
-		file, err := os.Create(fmt.Sprintf("output/%s.csv", n.Center.String()))
+		if err := n.Clusterize(ctx, clusterizer, features); err != nil {
+			if errors.Is(err, points.ErrTooManyPoints) {
+				logger.MustFromContext(ctx).Warnf("skipping neighbourhood: %v", err)
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AnalyzeStability measures the stability of the clusters of every clusterized neighbourhood by clustering the
+// resamples of its text entries with the clusterer and the params the clusters were found with, the neighbourhoods of
+// more entries than the co-association fits are skipped with a warning.
+func (p *Processor) AnalyzeStability(ctx context.Context) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AnalyzeStability")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debugf("analyzing stability by %s resamples", p.cfg.Resampling)
+
+	if p.cfg.Stream {
+		return errors.New("stability of the stream clustering is not supported")
+	}
+
+	clusterizer, features := p.clusterizer(), p.features()
+	for _, n := range p.neighbourhoods {
+		if n.Clusters == nil {
+			continue
+		}
+
+		if err := n.AnalyzeStability(ctx, clusterizer, features); err != nil {
+			if errors.Is(err, points.ErrTooManyPoints) {
+				logger.MustFromContext(ctx).Warnf("skipping neighbourhood: %v", err)
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveModel writes the model of the clusters of every clusterized neighbourhood with the alphabet and the features
+// they were clustered on, so that the entries of other texts could be assigned to the clusters by Predict.
+func (p *Processor) SaveModel(ctx context.Context, w io.Writer) error {
+	ctx, span := otel.Tracer("").Start(ctx, "SaveModel")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debug("saving model")
+
+	if p.alphabet == nil {
+		return errors.New("save model: alphabet is not analyzed")
+	}
+
+	m := &model.Model{
+		Version:       model.Version,
+		Encoding:      p.alphabet.Encoding().String(),
+		Alphabet:      []byte(p.alphabet.String()),
+		Shape:         []byte(p.cfg.Shape.String()),
+		Clusterer:     p.cfg.Clusterer.String(),
+		DensityRadius: p.cfg.DensityRadius,
+	}
+	features := p.cfg.Features
+	if p.cfg.Stream {
+		m.Clusterer = "stream"
+		features = []neighbourhood.Feature{neighbourhood.LocationFeature}
+	}
+	for _, f := range features {
+		m.Features = append(m.Features, f.String())
+	}
+
+	for _, n := range p.neighbourhoods {
+		if n.Model == nil {
+			continue
+		}
+
+		elements := make([][]byte, len(n.Elements))
+		for i, el := range n.Elements {
+			elements[i] = el.Value()
+		}
+
+		m.Neighbourhoods = append(m.Neighbourhoods, model.Neighbourhood{
+			Center:   n.Center.Value(),
+			Elements: elements,
+			Space:    n.ClustersSpace,
+			Params:   n.ClustersParams,
+			Seed:     n.ClustersSeed,
+			Clusters: n.Model,
+		})
+	}
+
+	if err := model.Write(w, m); err != nil {
+		return fmt.Errorf("save model: %w", err)
+	}
+
+	return nil
+}
+
+// LoadModel takes the alphabet, the neighbourhoods and the features from the model instead of analyzing the alphabet,
+// the text entries found afterwards are assigned to the clusters of the model by Predict. Only the neighbourhoods
+// clusterized when the model was saved are loaded.
+func (p *Processor) LoadModel(ctx context.Context, m *model.Model) error {
+	ctx, span := otel.Tracer("").Start(ctx, "LoadModel")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debugf("loading model of %d neighbourhoods clusterized by %s", len(m.Neighbourhoods), m.Clusterer)
+
+	enc, err := alphabet.ParseEncoding(m.Encoding)
+	if err != nil {
+		return fmt.Errorf("load model: %w", err)
+	}
+	a := alphabet.New(m.Alphabet, enc)
+
+	sh, err := shape.Parse(string(m.Shape))
+	if err != nil {
+		return fmt.Errorf("load model: parse shape: %w", err)
+	}
+
+	features := make([]neighbourhood.Feature, 0, len(m.Features))
+	for _, name := range m.Features {
+		f, err := neighbourhood.ParseFeature(name)
+		if err != nil {
+			return fmt.Errorf("load model: %w", err)
+		}
+		features = append(features, f)
+	}
+
+	neighbourhoods := make([]*neighbourhood.Neighbourhood, 0, len(m.Neighbourhoods))
+	for i, mn := range m.Neighbourhoods {
+		center, err := modelPattern(a, mn.Center)
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("load model: center of neighbourhood %d: %w", i, err)
 		}
 
-		w := csv.NewWriter(file)
-		defer w.Flush()
-		for i, loc := range n.TextEntries.Locations() {
-			w.Write([]string{fmt.Sprintf("%d", loc), n.TextEntries.Patterns()[i].String()})
+		elements := make([]*alphabet.Pattern, len(mn.Elements))
+		for j, el := range mn.Elements {
+			if elements[j], err = modelPattern(a, el); err != nil {
+				return fmt.Errorf("load model: element of neighbourhood %q: %w", mn.Center, err)
+			}
 		}
+
+		n := neighbourhood.New(center).WithElements(elements)
+		n.ClustersSpace, n.ClustersParams, n.ClustersSeed = mn.Space, mn.Params, mn.Seed
+		n.Model = mn.Clusters
+		neighbourhoods = append(neighbourhoods, n)
 	}
+
+	p.cfg.Shape = sh
+	p.cfg.Features, p.cfg.DensityRadius = features, m.DensityRadius
+	// the models scale the features themselves and the weights do not affect the assignment
+	p.cfg.Standardize, p.cfg.Weighting = false, neighbourhood.UniformWeighting
+	p.alphabet = a
+	p.neighbourhoods = neighbourhoods
+	p.matcher = neighbourhood.NewMatcher(ctx, a.Encoding(), p.neighbourhoods)
+
+	return nil
 }
 
-func (p *Processor) clusterize(ctx context.Context) {
-	ctx, span := otel.Tracer("").Start(ctx, "clusterize")
+// Predict assigns the text entries of every neighbourhood to the clusters of its model without refitting.
+func (p *Processor) Predict(ctx context.Context) error {
+	ctx, span := otel.Tracer("").Start(ctx, "Predict")
 	defer span.End()
 
-	logger.MustFromContext(ctx).Debug("clusterizing")
+	logger.MustFromContext(ctx).Debug("predicting clusters")
+
+	features := p.features()
+	for _, n := range p.neighbourhoods {
+		if n.Model == nil || len(n.TextEntries.Locations()) == 0 {
+			continue
+		}
+
+		if err := n.Predict(ctx, features); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// modelPattern returns the pattern of the model split into the symbols of the alphabet, it fails if the pattern is
+// empty or some symbol is not in the alphabet.
+func modelPattern(a *alphabet.Alphabet, raw []byte) (*alphabet.Pattern, error) {
+	symbols := a.Split(raw)
+	if len(symbols) == 0 {
+		return nil, errors.New("empty pattern")
+	}
+	for _, sym := range symbols {
+		if a.Index(sym) < 0 {
+			return nil, fmt.Errorf("symbol %q of pattern %q is not in the alphabet", sym, raw)
+		}
+	}
+
+	return alphabet.NewPattern(symbols), nil
+}
+
+// clusterizer returns the clusterizer configured by the config.
+func (p *Processor) clusterizer() *cluster.Clusterizer {
+	return cluster.New(p.cfg.Clusterer, p.cfg.QualityEstimationMethod).
+		WithConcurrency(p.cfg.Concurrency).
+		WithReportedMethods(p.cfg.ReportedMethods...).
+		WithMetric(p.cfg.Metric).
+		WithParams(p.cfg.Params).
+		WithSeed(p.cfg.Seed).
+		WithSearchStrategy(p.cfg.Search).
+		WithBudget(p.cfg.MaxEvaluations, p.cfg.Timeout).
+		WithSilhouetteSample(p.cfg.SilhouetteSample).
+		WithResampling(p.cfg.Resampling, p.cfg.Resamples, p.cfg.SampleFraction)
+}
+
+// features returns the extractor of the features of the text entries configured by the config.
+func (p *Processor) features() *neighbourhood.FeatureExtractor {
+	return neighbourhood.NewFeatureExtractor(p.cfg.Features...).
+		WithDensityRadius(p.cfg.DensityRadius).
+		WithStandardization(p.cfg.Standardize).
+		WithDocumentStarts(p.documentStarts).
+		WithWeigher(neighbourhood.NewWeigher(p.cfg.Weighting).WithPatternWeights(p.cfg.PatternWeights).WithScores(p.cfg.Scores))
+}
+
+// clusterizeStream clusterizes the locations of the text entries of every neighbourhood by the streaming k-means.
+func (p *Processor) clusterizeStream(ctx context.Context) error {
+	stream := kmeans.NewStream()
+	stream.SetSeed(p.cfg.Seed)
+	stream.SetMetric(p.cfg.Metric)
+	space, err := stream.Params().Fix(p.cfg.Params)
+	if err != nil {
+		return fmt.Errorf("set stream params: %w", err)
+	}
+	if err := stream.SetParams(ctx, space.Defaults()); err != nil {
+		return fmt.Errorf("set stream params: %w", err)
+	}
 
 	for _, n := range p.neighbourhoods {
 		if len(n.TextEntries.Locations()) == 0 {
 			continue
 		}
 
-		n.Clusterize(ctx)
+		if err := n.ClusterizeStream(ctx, stream); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
-func (p *Processor) findTextEntries(ctx context.Context, text []byte) {
-	ctx, span := otel.Tracer("").Start(ctx, "findTextEntries")
+// Segment splits the texts into the regions of the constant rate of the text entries of every neighbourhood.
+func (p *Processor) Segment(ctx context.Context) error {
+	ctx, span := otel.Tracer("").Start(ctx, "Segment")
 	defer span.End()
 
-	logger.MustFromContext(ctx).Debug("finding text entries")
+	logger.MustFromContext(ctx).Debugf("segmenting by %s", p.cfg.Segmentation)
 
+	segmenter := segment.New(p.cfg.Segmentation).WithPenalty(p.cfg.Penalty)
 	for _, n := range p.neighbourhoods {
-		n.FindTextEntries(ctx, text)
+		if len(n.TextEntries.Locations()) == 0 {
+			continue
+		}
+
+		if err := n.Segment(ctx, segmenter, p.textOffset); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
-func (p *Processor) extractCenters(ctx context.Context, a alphabet.Alphabet) []*alphabet.Pattern {
+func (p *Processor) findTextEntries(ctx context.Context, text []byte) int {
+	ctx, span := otel.Tracer("").Start(ctx, "findTextEntries")
+	defer span.End()
+
+	logger.MustFromContext(ctx).Debug("finding text entries")
+
+	return p.matcher.FindTextEntries(ctx, text, p.textOffset, p.textByteOffset)
+}
+
+func (p *Processor) extractCenters(ctx context.Context, a *alphabet.Alphabet) ([]*alphabet.Pattern, error) {
 	ctx, span := otel.Tracer("").Start(ctx, "extractCenters")
 	defer span.End()
 
-	logger.MustFromContext(ctx).Debug("extracting centers")
+	logger.MustFromContext(ctx).Debugf("extracting centers for shape %s", p.cfg.Shape)
 
-	centers := make([]*alphabet.Pattern, 0, len(a))
-	for _, s1 := range a {
-		for _, s2 := range a {
-			centers = append(centers, alphabet.NewPattern([]byte{s2, s1, s1}))
-		}
+	centers, err := p.cfg.Shape.Centers(a)
+	if err != nil {
+		return nil, fmt.Errorf("extract centers: %w", err)
 	}
 
 	logger.MustFromContext(ctx).Debugf("extracted centers: %v", centers)
 
-	return centers
+	return centers, nil
 }
 
-func (p *Processor) extractNeighbourhoods(ctx context.Context, a alphabet.Alphabet, c []*alphabet.Pattern) []*neighbourhood.Neighbourhood {
+func (p *Processor) extractNeighbourhoods(ctx context.Context, a *alphabet.Alphabet, c []*alphabet.Pattern) []*neighbourhood.Neighbourhood {
 	ctx, span := otel.Tracer("").Start(ctx, "extractNeighbourhoods")
 	defer span.End()
 
@@ -135,12 +473,7 @@ func (p *Processor) extractNeighbourhoods(ctx context.Context, a alphabet.Alphab
 
 	neighbourhoods := make([]*neighbourhood.Neighbourhood, 0, len(c))
 	for _, center := range c {
-		var elements []*alphabet.Pattern
-		for _, symbol := range a {
-			elements = append(elements, alphabet.NewPattern([]byte{center.Value()[0], symbol, center.Value()[2]}))
-		}
-
-		neighbourhoods = append(neighbourhoods, neighbourhood.New(center).WithElements(elements))
+		neighbourhoods = append(neighbourhoods, neighbourhood.New(center).WithElements(p.cfg.Shape.Elements(a, center)))
 	}
 
 	logger.MustFromContext(ctx).Debugf("extracted neighbourhoods: %v", neighbourhoods)
@@ -165,20 +498,20 @@ func mergeStatsNeighbourhoods(a *neighbourhood.TextEntries, b *neighbourhood.Tex
 	ia, ib := 0, 0
 	for {
 		if ia == len(a.Locations()) {
-			res.AddMany(b.Locations()[ib:], b.Patterns()[ib:])
+			res.AddMany(b.Locations()[ib:], b.ByteOffsets()[ib:], b.Patterns()[ib:])
 			return res
 		}
 
 		if ib == len(b.Locations()) {
-			res.AddMany(a.Locations()[ia:], a.Patterns()[ia:])
+			res.AddMany(a.Locations()[ia:], a.ByteOffsets()[ia:], a.Patterns()[ia:])
 			return res
 		}
 
 		if a.Locations()[ia] >= b.Locations()[ib] {
-			res.Add(b.Locations()[ib], b.Patterns()[ib])
+			res.Add(b.Locations()[ib], b.ByteOffsets()[ib], b.Patterns()[ib])
 			ib++
 		} else {
-			res.Add(a.Locations()[ia], a.Patterns()[ia])
+			res.Add(a.Locations()[ia], a.ByteOffsets()[ia], a.Patterns()[ia])
 			ia++
 		}
 	}
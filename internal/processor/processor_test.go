@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/alphabet"
+	"github.com/boson-research/patterns/internal/cluster"
+	"github.com/boson-research/patterns/internal/cluster/hierarchical"
+	"github.com/boson-research/patterns/internal/model"
+	"github.com/boson-research/patterns/internal/neighbourhood"
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+var ctx = logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+func TestProcessor_LoadModel(t *testing.T) {
+	symbols := []byte("\x80\x81\x82")
+	r := rand.New(rand.NewSource(1))
+	text := make([]byte, 300)
+	for i := range text {
+		text[i] = symbols[r.Intn(len(symbols))]
+	}
+
+	fitted := New(ctx, DefaultConfig())
+	if err := fitted.AnalyzeAlphabet(ctx, alphabet.New(symbols, alphabet.Bytes)); err != nil {
+		t.Fatalf("AnalyzeAlphabet() error = %v", err)
+	}
+	fitted.AnalyzeText(ctx, text)
+	if err := fitted.Clusterize(ctx); err != nil {
+		t.Fatalf("Clusterize() error = %v", err)
+	}
+
+	b := bytes.Buffer{}
+	if err := fitted.SaveModel(ctx, &b); err != nil {
+		t.Fatalf("SaveModel() error = %v", err)
+	}
+	m, err := model.Read(&b)
+	if err != nil {
+		t.Fatalf("model.Read() error = %v", err)
+	}
+
+	predicted := New(ctx, DefaultConfig())
+	if err := predicted.LoadModel(ctx, m); err != nil {
+		t.Fatalf("LoadModel() error = %v", err)
+	}
+	predicted.AnalyzeText(ctx, text)
+	if err := predicted.Predict(ctx); err != nil {
+		t.Fatalf("Predict() error = %v", err)
+	}
+
+	want, got := clusterLabels(fitted.Neighbourhoods()), clusterLabels(predicted.Neighbourhoods())
+	if len(got) == 0 || len(got) != len(want) {
+		t.Fatalf("Predict() labeled %d neighbourhoods, want %d", len(got), len(want))
+	}
+	for center, labels := range want {
+		if !bytes.Equal(got[center], labels) {
+			t.Errorf("Predict() labels of %q = %v, want %v", center, got[center], labels)
+		}
+	}
+
+	m.Neighbourhoods[0].Center = []byte("\x80\x83\x83")
+	if err := New(ctx, DefaultConfig()).LoadModel(ctx, m); err == nil {
+		t.Error("LoadModel() of center out of alphabet error = nil")
+	}
+}
+
+// clusterLabels returns the cluster ids of the text entries of the clusterized neighbourhoods by their centers.
+func clusterLabels(neighbourhoods []*neighbourhood.Neighbourhood) map[string][]byte {
+	labels := make(map[string][]byte)
+	for _, n := range neighbourhoods {
+		if n.Clusters == nil {
+			continue
+		}
+
+		l := make([]byte, n.TextEntries.Len())
+		for _, c := range n.Clusters {
+			for _, e := range c.Entries() {
+				l[e.Index()] = byte(c.ID())
+			}
+		}
+		labels[n.Center.String()] = l
+	}
+
+	return labels
+}
+
+func TestProcessor_Clusterize_tooManyEntries(t *testing.T) {
+	// the neighbourhood of the center aaa has more entries than the agglomerative clusterer takes, the others few
+	text := append(bytes.Repeat([]byte("a"), hierarchical.MaxPoints+100), "babbabbbaabbaba"...)
+
+	cfg := DefaultConfig()
+	cfg.Clusterer = cluster.Agglomerative
+	cfg.Params = map[string]string{"k": "1", "linkage": "single"}
+	cfg.Export.Dir = t.TempDir()
+	p := New(ctx, cfg)
+	if err := p.AnalyzeAlphabet(ctx, alphabet.New([]byte("ab"), alphabet.Bytes)); err != nil {
+		t.Fatalf("AnalyzeAlphabet() error = %v", err)
+	}
+	p.AnalyzeText(ctx, text)
+	if err := p.Clusterize(ctx); err != nil {
+		t.Fatalf("Clusterize() error = %v", err)
+	}
+
+	for _, n := range p.Neighbourhoods() {
+		if tooMany := n.TextEntries.Len() > hierarchical.MaxPoints; (n.Clusters == nil) != tooMany {
+			t.Errorf("Clusterize() clusterized neighbourhood %s of %d entries = %v, want %v", n.Center, n.TextEntries.Len(), n.Clusters != nil, !tooMany)
+		}
+	}
+	if err := p.Export(ctx); err != nil {
+		t.Errorf("Export() error = %v", err)
+	}
+}
+
+func TestProcessor_AnalyzeStability_tooManyEntries(t *testing.T) {
+	// the neighbourhood of the center aaa has more entries than the co-association fits, the others few
+	text := append(bytes.Repeat([]byte("a"), cluster.MaxStabilityPoints+100), "babbabbbaabbaba"...)
+
+	cfg := DefaultConfig()
+	cfg.Params = map[string]string{"k": "1"}
+	cfg.Resamples = 2
+	cfg.Export.Dir = t.TempDir()
+	p := New(ctx, cfg)
+	if err := p.AnalyzeAlphabet(ctx, alphabet.New([]byte("ab"), alphabet.Bytes)); err != nil {
+		t.Fatalf("AnalyzeAlphabet() error = %v", err)
+	}
+	p.AnalyzeText(ctx, text)
+	if err := p.Clusterize(ctx); err != nil {
+		t.Fatalf("Clusterize() error = %v", err)
+	}
+	if err := p.AnalyzeStability(ctx); err != nil {
+		t.Fatalf("AnalyzeStability() error = %v", err)
+	}
+
+	for _, n := range p.Neighbourhoods() {
+		if tooMany := n.TextEntries.Len() > cluster.MaxStabilityPoints; (n.Stability == nil) != tooMany {
+			t.Errorf("AnalyzeStability() measured neighbourhood %s of %d entries = %v, want %v", n.Center, n.TextEntries.Len(), n.Stability != nil, !tooMany)
+		}
+	}
+	if err := p.Export(ctx); err != nil {
+		t.Errorf("Export() error = %v", err)
+	}
+}
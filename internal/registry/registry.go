@@ -0,0 +1,92 @@
+// Package registry holds the named implementations of the pluggable algorithms, so that they are listed and referred
+// to by their names and could be added from other packages.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Registry holds the entries by their names in the order of registration, the index of an entry is its id. It is safe
+// for concurrent use.
+type Registry[T any] struct {
+	mu      sync.RWMutex
+	names   []string
+	entries []T
+}
+
+// Register adds the entry by its name and returns its id. It fails if the name is empty or already registered.
+func (r *Registry[T]) Register(name string, entry T) (int, error) {
+	if name == "" {
+		return 0, errors.New("empty name")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, n := range r.names {
+		if n == name {
+			return 0, fmt.Errorf("%s is already registered", name)
+		}
+	}
+
+	r.names = append(r.names, name)
+	r.entries = append(r.entries, entry)
+
+	return len(r.entries) - 1, nil
+}
+
+// Get returns the entry by its id, ok is false if there is no such entry.
+func (r *Registry[T]) Get(id int) (entry T, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if id < 0 || id >= len(r.entries) {
+		return entry, false
+	}
+
+	return r.entries[id], true
+}
+
+// Name returns the name of the entry by its id, empty if there is no such entry.
+func (r *Registry[T]) Name(id int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if id < 0 || id >= len(r.names) {
+		return ""
+	}
+
+	return r.names[id]
+}
+
+// Lookup returns the id of the entry by its name, ok is false if there is no such entry.
+func (r *Registry[T]) Lookup(name string) (id int, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for id, n := range r.names {
+		if n == name {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+// Names returns the names of all the entries in the order of registration.
+func (r *Registry[T]) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]string(nil), r.names...)
+}
+
+// Entries returns all the entries in the order of registration.
+func (r *Registry[T]) Entries() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]T(nil), r.entries...)
+}
@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	var r Registry[string]
+	for i, name := range []string{"a", "b"} {
+		id, err := r.Register(name, name+"!")
+		if err != nil || id != i {
+			t.Fatalf("Register(%s) = %d, %v, want %d", name, id, err, i)
+		}
+	}
+
+	if _, err := r.Register("a", ""); err == nil {
+		t.Error("Register() error = nil for the registered name")
+	}
+	if _, err := r.Register("", ""); err == nil {
+		t.Error("Register() error = nil for the empty name")
+	}
+
+	if id, ok := r.Lookup("b"); !ok || id != 1 {
+		t.Errorf("Lookup(b) = %d, %t", id, ok)
+	}
+	if _, ok := r.Lookup("c"); ok {
+		t.Error("Lookup(c) found the unregistered name")
+	}
+	if e, ok := r.Get(1); !ok || e != "b!" {
+		t.Errorf("Get(1) = %q, %t", e, ok)
+	}
+	if _, ok := r.Get(2); ok {
+		t.Error("Get(2) found the unregistered id")
+	}
+	if r.Name(0) != "a" || r.Name(-1) != "" {
+		t.Errorf("Name() = %q, %q", r.Name(0), r.Name(-1))
+	}
+	if got := r.Names(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	if got := r.Entries(); !reflect.DeepEqual(got, []string{"a!", "b!"}) {
+		t.Errorf("Entries() = %v", got)
+	}
+}
@@ -0,0 +1,243 @@
+package segment
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// Segment is the region of the text with the constant rate of the entries.
+type Segment struct {
+	// Start and End are the locations the segment starts at and ends before.
+	Start int
+	End   int
+	// Count is the number of the entries in the segment.
+	Count int
+	// Rate is the number of the entries per symbol.
+	Rate float64
+}
+
+func (s Segment) String() string {
+	return fmt.Sprintf("[%d, %d) %d entries, rate %.4f", s.Start, s.End, s.Count, s.Rate)
+}
+
+type Method int
+
+const (
+	// PELT is the pruned exact linear time method: the optimal segmentation by dynamic programming with the candidates
+	// of the last change point which could not be optimal any more pruned.
+	PELT Method = iota
+	// BinarySegmentation splits the text at the best change point recursively while the split pays off the penalty.
+	// It is approximate, but faster on the dense entries.
+	BinarySegmentation
+)
+
+// Methods lists all the segmentation methods.
+var Methods = []Method{PELT, BinarySegmentation}
+
+func (m Method) String() string {
+	switch m {
+	case PELT:
+		return "pelt"
+	case BinarySegmentation:
+		return "binseg"
+	}
+	return "unknown"
+}
+
+// ParseMethod returns the segmentation method by its name.
+func ParseMethod(s string) (Method, error) {
+	for _, m := range Methods {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown segmentation method: %s", s)
+}
+
+// Segmenter splits the text into the segments of the constant rate of the entries. The entries are modeled as the
+// Poisson process, the cost of the segment is the negative log-likelihood of its entries under the process with the
+// rate fitted to the segment, every change point is penalized. Change points are searched for right before and right after the entries.
+type Segmenter struct {
+	method  Method
+	penalty float64
+}
+
+func New(method Method) *Segmenter {
+	return &Segmenter{method: method}
+}
+
+// WithPenalty sets the penalty of a change point in the units of the log-likelihood. Non-positive penalties stand for
+// the bayesian information criterion: the log of the length of the text, the number of the observed symbols, per the
+// two parameters of the new segment, its rate and its start, halved as the cost is not doubled.
+func (s *Segmenter) WithPenalty(penalty float64) *Segmenter {
+	s.penalty = penalty
+	return s
+}
+
+// Segment splits the text of the length into the segments by the sorted locations of the entries.
+func (s *Segmenter) Segment(ctx context.Context, locations []int, length int) ([]Segment, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "Segment")
+	defer span.End()
+
+	if !sort.IntsAreSorted(locations) {
+		return nil, fmt.Errorf("locations are not sorted")
+	}
+
+	if len(locations) > 0 {
+		length = max(length, locations[len(locations)-1]+1)
+	}
+
+	penalty := s.penalty
+	if penalty <= 0 {
+		penalty = math.Log(math.Max(float64(length), 2))
+	}
+
+	logger.MustFromContext(ctx).Debugf("segmenting %d entries over %d symbols by %s with penalty %.2f", len(locations), length, s.method, penalty)
+
+	c := newCosts(locations, length)
+
+	var changePoints []int
+	switch s.method {
+	case PELT:
+		changePoints = pelt(c, penalty)
+	case BinarySegmentation:
+		changePoints = binarySegmentation(c, penalty)
+	default:
+		return nil, fmt.Errorf("unknown segmentation method: %s", s.method)
+	}
+
+	segments := make([]Segment, 0, len(changePoints)-1)
+	for i := 0; i+1 < len(changePoints); i++ {
+		from, to := changePoints[i], changePoints[i+1]
+		count := c.counts[to] - c.counts[from]
+		segments = append(segments, Segment{
+			Start: c.positions[from],
+			End:   c.positions[to],
+			Count: count,
+			Rate:  float64(count) / float64(c.positions[to]-c.positions[from]),
+		})
+	}
+
+	return segments, nil
+}
+
+// costs holds the candidate change points: the start of the text, the distinct entry locations and the locations
+// right after them, so that the segments could both start and end with an entry, and the end of the text, along with
+// the numbers of the entries before them.
+type costs struct {
+	positions []int
+	counts    []int
+}
+
+func newCosts(locations []int, length int) *costs {
+	c := &costs{positions: []int{0}, counts: []int{0}}
+	add := func(position, count int) {
+		if position > c.positions[len(c.positions)-1] && position < length {
+			c.positions = append(c.positions, position)
+			c.counts = append(c.counts, count)
+		}
+	}
+
+	for i, loc := range locations {
+		add(loc, i)
+		if i+1 == len(locations) || locations[i+1] > loc {
+			add(loc+1, i+1)
+		}
+	}
+
+	c.positions = append(c.positions, length)
+	c.counts = append(c.counts, len(locations))
+
+	return c
+}
+
+// len returns the number of the candidate change points.
+func (c *costs) len() int {
+	return len(c.positions)
+}
+
+// cost returns the negative Poisson log-likelihood of the entries between the candidates from and to with the rate
+// fitted to them, the constant terms are dropped.
+func (c *costs) cost(from, to int) float64 {
+	count := float64(c.counts[to] - c.counts[from])
+	if count == 0 {
+		return 0
+	}
+
+	return count - count*math.Log(count/float64(c.positions[to]-c.positions[from]))
+}
+
+// pelt returns the indices of the candidates of the optimal change points including the start and the end of the
+// text.
+func pelt(c *costs, penalty float64) []int {
+	best := make([]float64, c.len())
+	last := make([]int, c.len())
+	best[0] = -penalty
+
+	candidates := []int{0}
+	for t := 1; t < c.len(); t++ {
+		best[t] = math.Inf(1)
+		for _, s := range candidates {
+			if v := best[s] + c.cost(s, t) + penalty; v < best[t] {
+				best[t], last[t] = v, s
+			}
+		}
+
+		// the cost of the segment does not increase when it is split, so the candidates worse than the optimum by
+		// more than the penalty are never optimal again
+		pruned := candidates[:0]
+		for _, s := range candidates {
+			if best[s]+c.cost(s, t) <= best[t] {
+				pruned = append(pruned, s)
+			}
+		}
+		candidates = append(pruned, t)
+	}
+
+	var changePoints []int
+	for t := c.len() - 1; t > 0; t = last[t] {
+		changePoints = append(changePoints, t)
+	}
+	changePoints = append(changePoints, 0)
+
+	for i, j := 0, len(changePoints)-1; i < j; i, j = i+1, j-1 {
+		changePoints[i], changePoints[j] = changePoints[j], changePoints[i]
+	}
+
+	return changePoints
+}
+
+// binarySegmentation returns the indices of the candidates of the change points including the start and the end of
+// the text.
+func binarySegmentation(c *costs, penalty float64) []int {
+	changePoints := []int{0}
+
+	var split func(from, to int)
+	split = func(from, to int) {
+		whole := c.cost(from, to)
+
+		best, bestGain := -1, penalty
+		for k := from + 1; k < to; k++ {
+			if gain := whole - c.cost(from, k) - c.cost(k, to); gain > bestGain {
+				best, bestGain = k, gain
+			}
+		}
+
+		if best < 0 {
+			changePoints = append(changePoints, to)
+			return
+		}
+
+		split(from, best)
+		split(best, to)
+	}
+
+	split(0, c.len()-1)
+
+	return changePoints
+}
@@ -0,0 +1,115 @@
+package segment
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/telemetry/logger"
+)
+
+var ctx = logger.InjectIntoContext(context.Background(), logger.MustCreate())
+
+// burst returns the locations every step from the start before the end.
+func burst(start, end, step int) []int {
+	var locations []int
+	for loc := start; loc < end; loc += step {
+		locations = append(locations, loc)
+	}
+
+	return locations
+}
+
+func TestSegmenter_Segment(t *testing.T) {
+	burstLocations := append(append(burst(0, 1000, 100), burst(1000, 1200, 5)...), burst(1250, 2200, 100)...)
+
+	tests := []struct {
+		name      string
+		locations []int
+		length    int
+		want      []Segment
+	}{
+		{
+			name:   "no entries",
+			length: 100,
+			want:   []Segment{{Start: 0, End: 100, Count: 0, Rate: 0}},
+		},
+		{
+			name:      "uniform",
+			locations: burst(0, 1000, 10),
+			length:    1000,
+			want:      []Segment{{Start: 0, End: 1000, Count: 100, Rate: 0.1}},
+		},
+		{
+			name:      "burst",
+			locations: burstLocations,
+			length:    2200,
+			want: []Segment{
+				{Start: 0, End: 1000, Count: 10, Rate: 0.01},
+				{Start: 1000, End: 1196, Count: 40, Rate: 40.0 / 196},
+				{Start: 1196, End: 2200, Count: 10, Rate: 10.0 / 1004},
+			},
+		},
+		{
+			name:      "entries beyond length",
+			locations: []int{0, 1, 2},
+			length:    1,
+			want:      []Segment{{Start: 0, End: 3, Count: 3, Rate: 1}},
+		},
+	}
+	for _, tt := range tests {
+		for _, m := range Methods {
+			t.Run(tt.name+"/"+m.String(), func(t *testing.T) {
+				got, err := New(m).Segment(ctx, tt.locations, tt.length)
+				if err != nil {
+					t.Fatalf("Segment() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Segment() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSegmenter_Segment_unsorted(t *testing.T) {
+	if _, err := New(PELT).Segment(ctx, []int{2, 1}, 10); err == nil {
+		t.Error("Segment() error = nil, want error")
+	}
+}
+
+// TestPELT_optimal compares the penalized cost of the PELT segmentation with the optimum found by the dynamic
+// programming without pruning.
+func TestPELT_optimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		var locations []int
+		for loc := 0; loc < 3000; loc += 1 + r.Intn(10+100*(loc/500%2)) {
+			locations = append(locations, loc)
+		}
+
+		c := newCosts(locations, 3000)
+		penalty := math.Log(float64(len(locations)))
+
+		want := make([]float64, c.len())
+		want[0] = -penalty
+		for to := 1; to < c.len(); to++ {
+			want[to] = math.Inf(1)
+			for from := 0; from < to; from++ {
+				want[to] = math.Min(want[to], want[from]+c.cost(from, to)+penalty)
+			}
+		}
+
+		changePoints := pelt(c, penalty)
+		got := -penalty
+		for j := 0; j+1 < len(changePoints); j++ {
+			got += c.cost(changePoints[j], changePoints[j+1]) + penalty
+		}
+
+		if math.Abs(got-want[c.len()-1]) > 1e-9 {
+			t.Errorf("pelt() cost = %v, want %v", got, want[c.len()-1])
+		}
+	}
+}
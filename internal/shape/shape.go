@@ -0,0 +1,234 @@
+package shape
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boson-research/patterns/internal/alphabet"
+)
+
+// Default is the shape of the neighbourhoods the processor used to be hard-coded with: 3-symbol centers with the
+// last two symbols equal and elements varying the middle symbol of the center.
+const Default = "center=ABB; element=A?B"
+
+const (
+	wildcard = '?'
+
+	keyCenter   = "center"
+	keyElement  = "element"
+	keyCenters  = "centers"
+	commentMark = "#"
+)
+
+// Shape defines how neighbourhoods are built from the alphabet.
+//
+// The shape is described by a spec of key=value statements separated by semicolons or new lines, lines starting
+// with # are ignored:
+//
+//	center=ABB       the template of the centers
+//	element=A?B      the template of the elements, could be repeated or hold several comma separated templates
+//	centers=abb,bcc  explicit centers, could be repeated; all the variables assignments are used if omitted
+//
+// Templates consist of variables (uppercase latin letters) and wildcards (?). Every variable of the center template
+// runs over the alphabet, so the center template with n distinct variables gives |alphabet|^n centers. Element
+// templates could refer only to the variables of the center template, every wildcard runs over the alphabet.
+type Shape struct {
+	center   template
+	elements []template
+	// centers holds the raw explicit centers, they are split into symbols with the encoding of the alphabet.
+	centers []string
+}
+
+// Parse parses the shape spec.
+func Parse(spec string) (*Shape, error) {
+	s := &Shape{}
+
+	statements := strings.FieldsFunc(spec, func(r rune) bool { return r == ';' || r == '\n' })
+	for _, statement := range statements {
+		statement = strings.TrimSpace(statement)
+		if statement == "" || strings.HasPrefix(statement, commentMark) {
+			continue
+		}
+
+		key, value, ok := strings.Cut(statement, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid statement %q: expected key=value", statement)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case keyCenter:
+			if s.center != nil {
+				return nil, fmt.Errorf("center template is defined twice")
+			}
+
+			t, err := parseTemplate(value)
+			if err != nil {
+				return nil, fmt.Errorf("parse center template: %w", err)
+			}
+			if t.hasWildcards() {
+				return nil, fmt.Errorf("center template %q must not have wildcards", value)
+			}
+
+			s.center = t
+		case keyElement:
+			for _, raw := range strings.Split(value, ",") {
+				t, err := parseTemplate(strings.TrimSpace(raw))
+				if err != nil {
+					return nil, fmt.Errorf("parse element template: %w", err)
+				}
+
+				s.elements = append(s.elements, t)
+			}
+		case keyCenters:
+			for _, raw := range strings.Split(value, ",") {
+				if raw = strings.TrimSpace(raw); raw == "" {
+					continue
+				}
+
+				s.centers = append(s.centers, raw)
+			}
+		default:
+			return nil, fmt.Errorf("unknown key %q", key)
+		}
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// MustParse is like Parse but panics on error.
+func MustParse(spec string) *Shape {
+	s, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+func (s *Shape) validate() error {
+	if s.center == nil {
+		return fmt.Errorf("center template is not defined")
+	}
+
+	if len(s.elements) == 0 {
+		return fmt.Errorf("element template is not defined")
+	}
+
+	for _, el := range s.elements {
+		for _, tok := range el {
+			if !isWildcard(tok) && !s.center.hasVariable(tok) {
+				return fmt.Errorf("element template %s refers to variable %c absent in center template %s", el, tok, s.center)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Centers returns the centers of the neighbourhoods over the alphabet. It fails if the explicit centers do not match
+// the center template.
+func (s *Shape) Centers(a *alphabet.Alphabet) ([]*alphabet.Pattern, error) {
+	if s.centers != nil {
+		centers := make([]*alphabet.Pattern, 0, len(s.centers))
+		for _, raw := range s.centers {
+			c := alphabet.NewPattern(a.Split([]byte(raw)))
+			if _, err := s.center.bind(c.Symbols()); err != nil {
+				return nil, fmt.Errorf("center %s: %w", c, err)
+			}
+
+			centers = append(centers, c)
+		}
+
+		return centers, nil
+	}
+
+	variables := s.center.variables()
+
+	var centers []*alphabet.Pattern
+	forEachAssignment(a, len(variables), func(values []alphabet.Symbol) {
+		bindings := make(map[byte]alphabet.Symbol, len(variables))
+		for i, v := range variables {
+			bindings[v] = values[i]
+		}
+
+		centers = append(centers, alphabet.NewPattern(s.center.fill(bindings, nil)))
+	})
+
+	return centers, nil
+}
+
+// Elements returns the elements of the neighbourhood with the center over the alphabet.
+func (s *Shape) Elements(a *alphabet.Alphabet, center *alphabet.Pattern) []*alphabet.Pattern {
+	bindings, err := s.center.bind(center.Symbols())
+	if err != nil {
+		// centers are either generated from the template or validated against it
+		panic(err)
+	}
+
+	var elements []*alphabet.Pattern
+	for _, el := range s.elements {
+		forEachAssignment(a, el.wildcardsNum(), func(values []alphabet.Symbol) {
+			elements = append(elements, alphabet.NewPattern(el.fill(bindings, values)))
+		})
+	}
+
+	return elements
+}
+
+func (s *Shape) String() string {
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf("%s=%s; %s=", keyCenter, s.center, keyElement))
+	for i, el := range s.elements {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(el.String())
+	}
+
+	if s.centers != nil {
+		b.WriteString(fmt.Sprintf("; %s=", keyCenters))
+		for i, c := range s.centers {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(c)
+		}
+	}
+
+	return b.String()
+}
+
+// forEachAssignment calls fn for every assignment of n values from the alphabet. The first value changes the fastest.
+func forEachAssignment(a *alphabet.Alphabet, n int, fn func(values []alphabet.Symbol)) {
+	symbols := a.Symbols()
+	if len(symbols) == 0 && n > 0 {
+		return
+	}
+
+	values := make([]alphabet.Symbol, n)
+	idx := make([]int, n)
+	for {
+		for i := range values {
+			values[i] = symbols[idx[i]]
+		}
+		fn(values)
+
+		i := 0
+		for ; i < n; i++ {
+			idx[i]++
+			if idx[i] < len(symbols) {
+				break
+			}
+			idx[i] = 0
+		}
+
+		if i == n {
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package shape
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/boson-research/patterns/internal/alphabet"
+	"github.com/samber/lo"
+)
+
+func TestShape(t *testing.T) {
+	type want struct {
+		centers  []string
+		elements map[string][]string
+	}
+	tests := []struct {
+		name     string
+		spec     string
+		alphabet *alphabet.Alphabet
+		want     want
+		wantErr  bool
+	}{
+		{
+			name:     "default",
+			spec:     Default,
+			alphabet: alphabet.New([]byte("ab"), alphabet.UTF8),
+			want: want{
+				centers: []string{"aaa", "baa", "abb", "bbb"},
+				elements: map[string][]string{
+					"baa": {"baa", "bba"},
+				},
+			},
+		},
+		{
+			name:     "several wildcards and templates",
+			spec:     "# comment\ncenter=AB\nelement=A??B, ?A",
+			alphabet: alphabet.New([]byte("ab"), alphabet.UTF8),
+			want: want{
+				centers: []string{"aa", "ba", "ab", "bb"},
+				elements: map[string][]string{
+					"ab": {"aaab", "abab", "aabb", "abbb", "aa", "ba"},
+				},
+			},
+		},
+		{
+			name:     "explicit centers",
+			spec:     "center=ABA; element=A?A; centers=aba, cdc",
+			alphabet: alphabet.New([]byte("xy"), alphabet.UTF8),
+			want: want{
+				centers: []string{"aba", "cdc"},
+				elements: map[string][]string{
+					"cdc": {"cxc", "cyc"},
+				},
+			},
+		},
+		{
+			name:     "multi-byte symbols",
+			spec:     "center=ABB; element=A?B; centers=жыы",
+			alphabet: alphabet.New([]byte("жы"), alphabet.UTF8),
+			want: want{
+				centers: []string{"жыы"},
+				elements: map[string][]string{
+					"жыы": {"жжы", "жыы"},
+				},
+			},
+		},
+		{
+			name:     "inconsistent center",
+			spec:     "center=ABA; element=A?A; centers=abc",
+			alphabet: alphabet.New([]byte("ab"), alphabet.UTF8),
+			wantErr:  true,
+		},
+		{
+			name:    "unknown variable",
+			spec:    "center=AB; element=A?C",
+			wantErr: true,
+		},
+		{
+			name:    "wildcard in center",
+			spec:    "center=A?; element=A?",
+			wantErr: true,
+		},
+		{
+			name:    "no elements",
+			spec:    "center=AB",
+			wantErr: true,
+		},
+		{
+			name:    "invalid symbol",
+			spec:    "center=Ab; element=A?",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err == nil {
+				_, err = s.Centers(tt.alphabet)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			gotCenters, _ := s.Centers(tt.alphabet)
+			centers := lo.Map(gotCenters, func(p *alphabet.Pattern, _ int) string { return p.String() })
+			if !reflect.DeepEqual(centers, tt.want.centers) {
+				t.Errorf("Shape.Centers() = %v, want %v", centers, tt.want.centers)
+			}
+
+			for center, want := range tt.want.elements {
+				got := lo.Map(s.Elements(tt.alphabet, alphabet.NewPattern(tt.alphabet.Split([]byte(center)))), func(p *alphabet.Pattern, _ int) string { return p.String() })
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Shape.Elements(%s) = %v, want %v", center, got, want)
+				}
+			}
+
+			if _, err := Parse(s.String()); err != nil {
+				t.Errorf("Parse(Shape.String()) error = %v", err)
+			}
+		})
+	}
+}
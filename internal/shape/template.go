@@ -0,0 +1,107 @@
+package shape
+
+import (
+	"fmt"
+
+	"github.com/boson-research/patterns/internal/alphabet"
+)
+
+// template is a sequence of variables and wildcards.
+type template []byte
+
+func parseTemplate(raw string) (template, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("empty template")
+	}
+
+	for _, c := range []byte(raw) {
+		if !isWildcard(c) && (c < 'A' || c > 'Z') {
+			return nil, fmt.Errorf("invalid symbol %q in template %q: expected variable A-Z or wildcard %c", c, raw, wildcard)
+		}
+	}
+
+	return template(raw), nil
+}
+
+func (t template) String() string {
+	return string(t)
+}
+
+func (t template) hasWildcards() bool {
+	return t.wildcardsNum() > 0
+}
+
+func (t template) wildcardsNum() int {
+	n := 0
+	for _, tok := range t {
+		if isWildcard(tok) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (t template) hasVariable(v byte) bool {
+	for _, tok := range t {
+		if tok == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// variables returns the distinct variables of the template in the order of their first appearance.
+func (t template) variables() []byte {
+	var variables []byte
+	for i, tok := range t {
+		if !isWildcard(tok) && !t[:i].hasVariable(tok) {
+			variables = append(variables, tok)
+		}
+	}
+
+	return variables
+}
+
+// bind returns the values of the variables of the template matched against the value.
+func (t template) bind(value []alphabet.Symbol) (map[byte]alphabet.Symbol, error) {
+	if len(value) != len(t) {
+		return nil, fmt.Errorf("length %d does not match template %s", len(value), t)
+	}
+
+	bindings := make(map[byte]alphabet.Symbol, len(t))
+	for i, tok := range t {
+		if isWildcard(tok) {
+			continue
+		}
+
+		if bound, ok := bindings[tok]; ok && bound != value[i] {
+			return nil, fmt.Errorf("variable %c of template %s is bound to both %q and %q", tok, t, bound, value[i])
+		}
+
+		bindings[tok] = value[i]
+	}
+
+	return bindings, nil
+}
+
+// fill builds the value of the template taking the variables values from the bindings and the wildcards values one
+// by one from wildcardValues.
+func (t template) fill(bindings map[byte]alphabet.Symbol, wildcardValues []alphabet.Symbol) []alphabet.Symbol {
+	value := make([]alphabet.Symbol, len(t))
+	for i, tok := range t {
+		if isWildcard(tok) {
+			value[i], wildcardValues = wildcardValues[0], wildcardValues[1:]
+			continue
+		}
+
+		value[i] = bindings[tok]
+	}
+
+	return value
+}
+
+func isWildcard(tok byte) bool {
+	return tok == wildcard
+}
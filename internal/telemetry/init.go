@@ -18,7 +18,7 @@ type Config struct {
 
 func Init(ctx context.Context, cfg Config) (*logrus.Logger, func(ctx context.Context) error, error) {
 	if cfg.JaegerOTLPEndpoint == "" {
-		return nil, func(_ context.Context) error {
+		return logger.MustCreate(), func(_ context.Context) error {
 			return nil
 		}, nil
 	}